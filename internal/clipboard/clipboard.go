@@ -0,0 +1,222 @@
+// Package clipboard copies text to the user's clipboard through one of
+// several backends: the native clipboard tool, an OSC 52 terminal escape,
+// the tmux paste buffer or a plain file. The backend is picked
+// automatically unless configured.
+package clipboard
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	sysclip "github.com/atotto/clipboard"
+	"github.com/aymanbagabas/go-osc52/v2"
+
+	"github.com/kwrkb/asql/internal/fsutil"
+)
+
+// Backend names a clipboard backend.
+type Backend string
+
+const (
+	Auto   Backend = "auto"   // first backend that works, in the order below
+	Native Backend = "native" // pbcopy, xclip, xsel, wl-copy or the Windows clipboard
+	Tmux   Backend = "tmux"   // tmux paste buffer (also forwarded to the terminal by tmux)
+	OSC52  Backend = "osc52"  // OSC 52 escape sequence written to the terminal
+	File   Backend = "file"   // plain file, for sessions without any clipboard
+)
+
+// Backends lists the valid backend names.
+var Backends = []Backend{Auto, Native, Tmux, OSC52, File}
+
+// ParseBackend validates a configured backend name; "" means Auto.
+func ParseBackend(s string) (Backend, error) {
+	if s == "" {
+		return Auto, nil
+	}
+	for _, b := range Backends {
+		if string(b) == strings.ToLower(strings.TrimSpace(s)) {
+			return b, nil
+		}
+	}
+	return Auto, fmt.Errorf("unknown clipboard backend %q (want one of auto, native, tmux, osc52, file)", s)
+}
+
+// Clipboard copies text through the configured backend.
+type Clipboard struct {
+	backend Backend
+	path    string // target of the file backend
+
+	getenv   func(string) string
+	native   func(string) error
+	tmux     func(string) error
+	terminal io.Writer // where OSC 52 sequences go; nil disables the backend
+}
+
+// New returns a clipboard using backend. path is the file the file backend
+// writes to; "" uses DefaultPath and a leading "~/" is the home directory.
+func New(backend Backend, path string) *Clipboard {
+	if path == "" {
+		path = DefaultPath()
+	} else if rest, ok := strings.CutPrefix(path, "~/"); ok {
+		if home, err := os.UserHomeDir(); err == nil {
+			path = filepath.Join(home, rest)
+		}
+	}
+	return &Clipboard{
+		backend: backend,
+		path:    path,
+		getenv:  os.Getenv,
+		native:  sysclip.WriteAll,
+		tmux:    loadTmuxBuffer,
+	}
+}
+
+// SetTerminal sets the writer the OSC 52 backend sends its escape sequence
+// to. It must be the UI's own output (see Terminal), so that the sequence
+// is not interleaved with a frame being drawn.
+func (c *Clipboard) SetTerminal(w io.Writer) {
+	c.terminal = w
+}
+
+// Terminal is a terminal file whose writes are serialized. Handing the same
+// Terminal to both the UI (tea.WithOutput) and SetTerminal lets an OSC 52
+// sequence go out between two frames instead of racing the renderer.
+type Terminal struct {
+	*os.File
+	mu sync.Mutex
+}
+
+// NewTerminal wraps f, usually os.Stdout.
+func NewTerminal(f *os.File) *Terminal {
+	return &Terminal{File: f}
+}
+
+func (t *Terminal) Write(p []byte) (int, error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return t.File.Write(p)
+}
+
+func (t *Terminal) WriteString(s string) (int, error) {
+	return t.Write([]byte(s))
+}
+
+// DefaultPath returns the file backend's default target,
+// <user cache dir>/asql/clipboard.txt.
+func DefaultPath() string {
+	dir, err := os.UserCacheDir()
+	if err != nil {
+		dir = os.TempDir()
+	}
+	return filepath.Join(dir, "asql", "clipboard.txt")
+}
+
+// Copy copies text and returns the backend that took it. With Auto, each
+// backend that applies to the session is tried in turn; the file backend
+// is the last resort.
+func (c *Clipboard) Copy(text string) (Backend, error) {
+	if c.backend != Auto {
+		return c.backend, c.copyWith(c.backend, text)
+	}
+	var errs []string
+	for _, b := range c.autoOrder() {
+		err := c.copyWith(b, text)
+		if err == nil {
+			return b, nil
+		}
+		errs = append(errs, fmt.Sprintf("%s: %v", b, err))
+	}
+	return Auto, fmt.Errorf("no clipboard backend worked (%s)", strings.Join(errs, "; "))
+}
+
+// autoOrder returns the backends Auto tries: the native clipboard, tmux
+// inside a tmux session, OSC 52 on a real terminal the UI writes to, then
+// the file.
+func (c *Clipboard) autoOrder() []Backend {
+	order := []Backend{Native}
+	if c.getenv("TMUX") != "" {
+		order = append(order, Tmux)
+	}
+	if term := c.getenv("TERM"); c.terminal != nil && term != "" && term != "dumb" {
+		order = append(order, OSC52)
+	}
+	return append(order, File)
+}
+
+// Describe returns a short description of where backend b puts the text,
+// for status messages.
+func (c *Clipboard) Describe(b Backend) string {
+	switch b {
+	case Native:
+		return "system clipboard"
+	case Tmux:
+		return "tmux buffer"
+	case OSC52:
+		return "terminal clipboard (OSC 52)"
+	case File:
+		return c.path
+	default:
+		return string(b)
+	}
+}
+
+func (c *Clipboard) copyWith(b Backend, text string) error {
+	switch b {
+	case Native:
+		return c.native(text)
+	case Tmux:
+		return c.tmux(text)
+	case OSC52:
+		if c.terminal == nil {
+			return fmt.Errorf("no terminal to send the OSC 52 sequence to")
+		}
+		seq := osc52.New(text)
+		switch {
+		case c.getenv("TMUX") != "":
+			seq = seq.Tmux()
+		case strings.HasPrefix(c.getenv("TERM"), "screen"):
+			seq = seq.Screen()
+		}
+		_, err := seq.WriteTo(c.terminal)
+		return err
+	case File:
+		if err := os.MkdirAll(filepath.Dir(c.path), 0o700); err != nil {
+			return err
+		}
+		// Copied rows may be sensitive: keep the file private.
+		return fsutil.AtomicWrite(c.path, []byte(text), 0o600)
+	default:
+		return fmt.Errorf("unknown clipboard backend %q", b)
+	}
+}
+
+// loadTmuxBuffer stores text in a new tmux paste buffer. -w (tmux 3.2+)
+// also forwards it to the outer terminal's clipboard when tmux's
+// set-clipboard option allows.
+func loadTmuxBuffer(text string) error {
+	err := runTmux(text, "load-buffer", "-w", "-")
+	if err != nil {
+		err = runTmux(text, "load-buffer", "-")
+	}
+	return err
+}
+
+func runTmux(text string, args ...string) error {
+	cmd := exec.Command("tmux", args...)
+	cmd.Stdin = strings.NewReader(text)
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		if msg := strings.TrimSpace(stderr.String()); msg != "" {
+			return fmt.Errorf("%w: %s", err, msg)
+		}
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,143 @@
+package clipboard
+
+import (
+	"bytes"
+	"encoding/base64"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// fakeClipboard returns a clipboard whose native and tmux backends fail
+// unless enabled, with env as its environment.
+func fakeClipboard(t *testing.T, backend Backend, env map[string]string) (*Clipboard, *bytes.Buffer) {
+	t.Helper()
+	var term bytes.Buffer
+	c := New(backend, filepath.Join(t.TempDir(), "sub", "clip.txt"))
+	c.getenv = func(k string) string { return env[k] }
+	c.native = func(string) error { return errors.New("no clipboard utility") }
+	c.tmux = func(string) error { return errors.New("no server running") }
+	c.terminal = &term
+	return c, &term
+}
+
+func TestParseBackend(t *testing.T) {
+	for in, want := range map[string]Backend{"": Auto, "OSC52": OSC52, " file ": File, "tmux": Tmux} {
+		got, err := ParseBackend(in)
+		if err != nil || got != want {
+			t.Errorf("ParseBackend(%q) = %q, %v; want %q", in, got, err, want)
+		}
+	}
+	if _, err := ParseBackend("x11"); err == nil {
+		t.Error("expected an error for an unknown backend")
+	}
+}
+
+func TestCopy_Auto(t *testing.T) {
+	t.Run("native first", func(t *testing.T) {
+		c, _ := fakeClipboard(t, Auto, map[string]string{"TERM": "xterm"})
+		var got string
+		c.native = func(s string) error { got = s; return nil }
+		if b, err := c.Copy("hi"); err != nil || b != Native || got != "hi" {
+			t.Errorf("Copy = %q, %v (native got %q)", b, err, got)
+		}
+	})
+
+	t.Run("tmux before OSC 52", func(t *testing.T) {
+		c, term := fakeClipboard(t, Auto, map[string]string{"TERM": "screen", "TMUX": "/tmp/tmux-1/default,1,0"})
+		c.tmux = func(string) error { return nil }
+		if b, err := c.Copy("hi"); err != nil || b != Tmux || term.Len() != 0 {
+			t.Errorf("Copy = %q, %v, terminal %q", b, err, term.String())
+		}
+	})
+
+	t.Run("OSC 52 on a terminal", func(t *testing.T) {
+		c, term := fakeClipboard(t, Auto, map[string]string{"TERM": "xterm-256color", "SSH_TTY": "/dev/pts/1"})
+		b, err := c.Copy("hi")
+		if err != nil || b != OSC52 {
+			t.Fatalf("Copy = %q, %v", b, err)
+		}
+		want := "\x1b]52;c;" + base64.StdEncoding.EncodeToString([]byte("hi")) + "\x07"
+		if term.String() != want {
+			t.Errorf("terminal got %q, want %q", term.String(), want)
+		}
+	})
+
+	t.Run("file without a terminal", func(t *testing.T) {
+		c, _ := fakeClipboard(t, Auto, map[string]string{"TERM": "dumb"})
+		b, err := c.Copy("hi")
+		if err != nil || b != File {
+			t.Fatalf("Copy = %q, %v", b, err)
+		}
+		data, err := os.ReadFile(c.path)
+		if err != nil || string(data) != "hi" {
+			t.Errorf("file = %q, %v", data, err)
+		}
+		if info, _ := os.Stat(c.path); info.Mode().Perm() != 0o600 {
+			t.Errorf("file mode = %o, want 600", info.Mode().Perm())
+		}
+		if c.Describe(b) != c.path {
+			t.Errorf("Describe(file) = %q", c.Describe(b))
+		}
+	})
+}
+
+func TestCopy_WithoutTerminal(t *testing.T) {
+	c, _ := fakeClipboard(t, Auto, map[string]string{"TERM": "xterm"})
+	c.SetTerminal(nil)
+	if b, err := c.Copy("hi"); err != nil || b != File {
+		t.Errorf("Copy = %q, %v; want the file backend", b, err)
+	}
+
+	c.backend = OSC52
+	if _, err := c.Copy("hi"); err == nil {
+		t.Error("expected OSC 52 to fail without a terminal")
+	}
+}
+
+func TestTerminal_WritesThrough(t *testing.T) {
+	f, err := os.Create(filepath.Join(t.TempDir(), "tty"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	c := New(OSC52, "")
+	c.getenv = func(string) string { return "" }
+	c.SetTerminal(NewTerminal(f))
+	if _, err := c.Copy("hi"); err != nil {
+		t.Fatal(err)
+	}
+	data, _ := os.ReadFile(f.Name())
+	if !strings.HasPrefix(string(data), "\x1b]52;c;") {
+		t.Errorf("terminal got %q", data)
+	}
+}
+
+func TestCopy_ConfiguredBackend(t *testing.T) {
+	c, term := fakeClipboard(t, Tmux, map[string]string{"TERM": "xterm"})
+	b, err := c.Copy("hi")
+	if b != Tmux || err == nil || !strings.Contains(err.Error(), "no server") {
+		t.Errorf("expected the tmux error without falling back, got %q, %v", b, err)
+	}
+	if term.Len() != 0 {
+		t.Error("a configured backend must not fall back to OSC 52")
+	}
+
+	c, term = fakeClipboard(t, OSC52, map[string]string{"TERM": "xterm", "TMUX": "x"})
+	if _, err := c.Copy("hi"); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.HasPrefix(term.String(), "\x1bPtmux;") {
+		t.Errorf("expected tmux passthrough, got %q", term.String())
+	}
+}
+
+func TestNew_ExpandsHome(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	if c := New(File, "~/clip.txt"); c.path != filepath.Join(home, "clip.txt") {
+		t.Errorf("path = %q", c.path)
+	}
+}
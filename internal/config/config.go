@@ -4,6 +4,8 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
+	"strings"
+	"time"
 
 	"gopkg.in/yaml.v3"
 )
@@ -14,15 +16,36 @@ type AIConfig struct {
 	APIKey   string `yaml:"ai_api_key"`
 }
 
+// ClipboardConfig selects how copies reach the clipboard.
+type ClipboardConfig struct {
+	Backend string `yaml:"backend"` // auto (default), native, tmux, osc52 or file
+	File    string `yaml:"file"`    // target of the file backend
+}
+
 type Config struct {
-	AI       AIConfig `yaml:"ai"`
-	Warnings []string `yaml:"-"` // non-fatal warnings collected during load
+	AI        AIConfig        `yaml:"ai"`
+	Clipboard ClipboardConfig `yaml:"clipboard"`
+	TimeZone  string          `yaml:"time_zone"` // display zone of zoned timestamps; "" or "Local" = system zone
+	Warnings  []string        `yaml:"-"`         // non-fatal warnings collected during load
 }
 
 func (c Config) AIEnabled() bool {
 	return c.AI.Endpoint != "" && c.AI.Model != ""
 }
 
+// LoadLocation resolves a time_zone setting: an IANA name such as
+// "Asia/Tokyo", "UTC", or "" / "Local" for the system zone.
+func LoadLocation(name string) (*time.Location, error) {
+	if name == "" || strings.EqualFold(name, "local") {
+		return time.Local, nil
+	}
+	loc, err := time.LoadLocation(name)
+	if err != nil {
+		return nil, fmt.Errorf("unknown time zone %q", name)
+	}
+	return loc, nil
+}
+
 func configDir() (string, error) {
 	if d := os.Getenv("XDG_CONFIG_HOME"); d != "" {
 		return d, nil
@@ -67,6 +90,16 @@ func Load() (Config, error) {
 	if v := os.Getenv("ASQL_AI_MODEL"); v != "" {
 		cfg.AI.Model = v
 	}
+	if v := os.Getenv("ASQL_CLIPBOARD"); v != "" {
+		cfg.Clipboard.Backend = v
+	}
+	if v := os.Getenv("ASQL_TIME_ZONE"); v != "" {
+		cfg.TimeZone = v
+	}
+	if _, err := LoadLocation(cfg.TimeZone); err != nil {
+		cfg.Warnings = append(cfg.Warnings, err.Error()+"; using the system time zone")
+		cfg.TimeZone = ""
+	}
 
 	return cfg, nil
 }
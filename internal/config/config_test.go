@@ -4,6 +4,7 @@ import (
 	"os"
 	"path/filepath"
 	"testing"
+	"time"
 )
 
 func TestLoad(t *testing.T) {
@@ -84,6 +85,70 @@ ai:
 			t.Error("expected AI disabled when model is missing")
 		}
 	})
+
+	t.Run("clipboard backend with env override", func(t *testing.T) {
+		dir := t.TempDir()
+		t.Setenv("XDG_CONFIG_HOME", dir)
+
+		asqlDir := filepath.Join(dir, "asql")
+		os.MkdirAll(asqlDir, 0o755)
+		os.WriteFile(filepath.Join(asqlDir, "config.yaml"), []byte(`
+clipboard:
+  backend: file
+  file: /tmp/asql-clip.txt
+`), 0o600)
+
+		cfg, err := Load()
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if cfg.Clipboard.Backend != "file" || cfg.Clipboard.File != "/tmp/asql-clip.txt" {
+			t.Errorf("unexpected clipboard config: %+v", cfg.Clipboard)
+		}
+
+		t.Setenv("ASQL_CLIPBOARD", "osc52")
+		cfg, _ = Load()
+		if cfg.Clipboard.Backend != "osc52" {
+			t.Errorf("expected env override, got %q", cfg.Clipboard.Backend)
+		}
+	})
+
+	t.Run("time zone with env override", func(t *testing.T) {
+		dir := t.TempDir()
+		t.Setenv("XDG_CONFIG_HOME", dir)
+
+		asqlDir := filepath.Join(dir, "asql")
+		os.MkdirAll(asqlDir, 0o755)
+		os.WriteFile(filepath.Join(asqlDir, "config.yaml"), []byte("time_zone: Asia/Tokyo\n"), 0o600)
+
+		cfg, err := Load()
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if cfg.TimeZone != "Asia/Tokyo" {
+			t.Errorf("unexpected time zone: %q", cfg.TimeZone)
+		}
+
+		t.Setenv("ASQL_TIME_ZONE", "Mars/Olympus")
+		cfg, _ = Load()
+		if cfg.TimeZone != "" || len(cfg.Warnings) != 1 {
+			t.Errorf("expected an unknown zone to fall back with a warning, got %q %v", cfg.TimeZone, cfg.Warnings)
+		}
+	})
+}
+
+func TestLoadLocation(t *testing.T) {
+	for _, name := range []string{"", "Local", "local"} {
+		if loc, err := LoadLocation(name); err != nil || loc != time.Local {
+			t.Errorf("LoadLocation(%q) = %v, %v; want the system zone", name, loc, err)
+		}
+	}
+	if loc, err := LoadLocation("UTC"); err != nil || loc.String() != "UTC" {
+		t.Errorf("LoadLocation(UTC) = %v, %v", loc, err)
+	}
+	if _, err := LoadLocation("Nowhere/City"); err == nil {
+		t.Error("expected an error for an unknown zone")
+	}
 }
 
 func TestAIEnabled(t *testing.T) {
@@ -8,13 +8,62 @@ type QueryResult struct {
 	Rows        [][]string
 	Message     string
 	Truncated   bool // true when rows were capped at the scan limit
+	// Derived flags, per column, the columns computed by asql rather than
+	// read from the database, such as extracted JSON paths. nil when none.
+	Derived []bool
+	// Nulls flags, per row and cell, the cells that are NULL in the
+	// database, since a text value "NULL" reads the same in Rows. nil when
+	// unknown, as for results computed by asql: "NULL" cells are NULL then.
+	Nulls [][]bool
+}
+
+// RowHandler receives the rows of a streamed query. Header is called once
+// before the first Row. Cells use the same encoding as QueryResult.Rows,
+// and nulls flags the NULL cells of row as QueryResult.Nulls does.
+type RowHandler interface {
+	Header(columns, columnTypes []string) error
+	Row(row []string, nulls []bool) error
+}
+
+// Statement is a parameterized statement run by DBAdapter.Exec. Placeholders
+// use the driver's native syntax (see dbutil.BindVar).
+type Statement struct {
+	SQL  string
+	Args []any
+	// ExpectRows, when > 0, makes Exec roll back the whole transaction
+	// unless the statement affected exactly this many rows.
+	ExpectRows int64
+}
+
+// ColumnInfo describes a column of a table.
+type ColumnInfo struct {
+	Name       string
+	Type       string // declared type, e.g. "integer", "varchar(255)"; "" if none
+	Nullable   bool
+	PrimaryKey bool
+	References string // "table.column" a foreign key points at, "" if none
 }
 
 type DBAdapter interface {
 	Type() string
 	Query(context.Context, string) (QueryResult, error)
+	// Stream runs a read-only query and passes every row to h without the
+	// scan limit applied by Query.
+	Stream(ctx context.Context, query string, h RowHandler) error
 	Tables(context.Context) ([]string, error)
 	Columns(ctx context.Context, tableName string) ([]string, error)
+	// ColumnDetails returns the columns of tableName in table order with
+	// their type, nullability and keys. tableName may be "schema.table".
+	ColumnDetails(ctx context.Context, tableName string) ([]ColumnInfo, error)
+	// AllColumnDetails returns the columns of every table listed by Tables,
+	// keyed by table name, in a single query.
+	AllColumnDetails(ctx context.Context) (map[string][]ColumnInfo, error)
+	// PrimaryKey returns the primary key columns of tableName in key order,
+	// or nil when the table has none. tableName may be "schema.table".
+	PrimaryKey(ctx context.Context, tableName string) ([]string, error)
+	// Exec runs stmts in a single transaction and rolls back all of them if
+	// any statement fails.
+	Exec(ctx context.Context, stmts []Statement) error
 	Schema(context.Context) (string, error)
 	QuoteIdentifier(name string) string
 	Close() error
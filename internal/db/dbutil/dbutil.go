@@ -1,6 +1,7 @@
 package dbutil
 
 import (
+	"context"
 	"database/sql"
 	"fmt"
 	"strings"
@@ -45,40 +46,23 @@ func ScanRowsLimit(rows *sql.Rows, limit int) (db.QueryResult, error) {
 		return db.QueryResult{}, err
 	}
 
-	// Retrieve column type names (best-effort; driver-dependent).
-	var colTypes []string
-	if cts, err := rows.ColumnTypes(); err == nil {
-		colTypes = make([]string, len(cts))
-		for i, ct := range cts {
-			colTypes[i] = ct.DatabaseTypeName()
-		}
-	}
-
-	values := make([]any, len(columns))
-	ptrs := make([]any, len(columns))
-	for i := range values {
-		ptrs[i] = &values[i]
-	}
+	colTypes := columnTypeNames(rows)
+	scan := newRowScanner(len(columns))
 
 	resultRows := make([][]string, 0)
+	nulls := make([][]bool, 0)
 	truncated := false
 	for rows.Next() {
 		if limit > 0 && len(resultRows) >= limit {
 			truncated = true
 			break
 		}
-		if err := rows.Scan(ptrs...); err != nil {
+		record, null, err := scan(rows)
+		if err != nil {
 			return db.QueryResult{}, err
 		}
-		record := make([]string, len(columns))
-		for i, value := range values {
-			s := StringifyValue(value)
-			if s == "" {
-				s = `""`
-			}
-			record[i] = s
-		}
 		resultRows = append(resultRows, record)
+		nulls = append(nulls, null)
 	}
 	if err := rows.Err(); err != nil {
 		return db.QueryResult{}, err
@@ -93,11 +77,141 @@ func ScanRowsLimit(rows *sql.Rows, limit int) (db.QueryResult, error) {
 		Columns:     columns,
 		ColumnTypes: colTypes,
 		Rows:        resultRows,
+		Nulls:       nulls,
 		Message:     msg,
 		Truncated:   truncated,
 	}, nil
 }
 
+// StreamRows passes every row of rows to h, without a row limit.
+// The caller is responsible for closing rows.
+func StreamRows(rows *sql.Rows, h db.RowHandler) error {
+	columns, err := rows.Columns()
+	if err != nil {
+		return err
+	}
+	if err := h.Header(columns, columnTypeNames(rows)); err != nil {
+		return err
+	}
+	scan := newRowScanner(len(columns))
+	for rows.Next() {
+		record, nulls, err := scan(rows)
+		if err != nil {
+			return err
+		}
+		if err := h.Row(record, nulls); err != nil {
+			return err
+		}
+	}
+	return rows.Err()
+}
+
+// StreamQuery re-runs query on conn and streams its rows to h. Queries
+// that readOnly rejects are refused, so that an export never repeats a
+// write.
+func StreamQuery(ctx context.Context, conn *sql.DB, query string, readOnly func(string) bool, h db.RowHandler) error {
+	query = strings.TrimSpace(query)
+	if query == "" {
+		return fmt.Errorf("query is empty")
+	}
+	if !readOnly(query) {
+		return fmt.Errorf("only read-only queries can be streamed")
+	}
+	rows, err := conn.QueryContext(ctx, query)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+	return StreamRows(rows, h)
+}
+
+// ExecTx runs stmts in a single transaction on conn. The transaction is
+// rolled back when a statement fails or affects a different number of rows
+// than its ExpectRows.
+func ExecTx(ctx context.Context, conn *sql.DB, stmts []db.Statement) error {
+	tx, err := conn.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	for i, st := range stmts {
+		res, err := tx.ExecContext(ctx, st.SQL, st.Args...)
+		if err != nil {
+			_ = tx.Rollback()
+			return fmt.Errorf("statement %d: %w", i+1, err)
+		}
+		if st.ExpectRows > 0 {
+			n, err := res.RowsAffected()
+			if err != nil {
+				_ = tx.Rollback()
+				return fmt.Errorf("statement %d: %w", i+1, err)
+			}
+			if n != st.ExpectRows {
+				_ = tx.Rollback()
+				return fmt.Errorf("statement %d affected %d row(s), expected %d", i+1, n, st.ExpectRows)
+			}
+		}
+	}
+	return tx.Commit()
+}
+
+// BindVar returns the n-th (1-based) bind parameter placeholder for the
+// given database type: "$n" for PostgreSQL, "?" otherwise.
+func BindVar(dbType string, n int) string {
+	if dbType == "postgres" {
+		return fmt.Sprintf("$%d", n)
+	}
+	return "?"
+}
+
+// SplitTableName splits "schema.table" at the last dot. schema is "" for
+// unqualified names.
+func SplitTableName(name string) (schema, table string) {
+	if i := strings.LastIndexByte(name, '.'); i >= 0 {
+		return name[:i], name[i+1:]
+	}
+	return "", name
+}
+
+// columnTypeNames retrieves column type names (best-effort; driver-dependent).
+func columnTypeNames(rows *sql.Rows) []string {
+	cts, err := rows.ColumnTypes()
+	if err != nil {
+		return nil
+	}
+	colTypes := make([]string, len(cts))
+	for i, ct := range cts {
+		colTypes[i] = ct.DatabaseTypeName()
+	}
+	return colTypes
+}
+
+// newRowScanner returns a function that scans the current row into display
+// strings, flagging the NULL cells. Empty strings are encoded as `""` to
+// distinguish them from NULL.
+func newRowScanner(ncols int) func(*sql.Rows) ([]string, []bool, error) {
+	values := make([]any, ncols)
+	ptrs := make([]any, ncols)
+	for i := range values {
+		ptrs[i] = &values[i]
+	}
+	return func(rows *sql.Rows) ([]string, []bool, error) {
+		if err := rows.Scan(ptrs...); err != nil {
+			return nil, nil, err
+		}
+		record := make([]string, ncols)
+		nulls := make([]bool, ncols)
+		for i, value := range values {
+			nulls[i] = value == nil
+			s := StringifyValue(value)
+			if s == "" {
+				s = `""`
+			}
+			record[i] = s
+		}
+		return record, nulls, nil
+	}
+}
+
 // LeadingKeyword returns the first SQL keyword from query, skipping comments
 // and leading semicolons. The result is always lowercase.
 func LeadingKeyword(query string) string {
@@ -350,6 +464,10 @@ type Dialect struct {
 	BracketQuote  bool // SQLite/MSSQL [identifier] style
 	DollarQuote   bool // PostgreSQL $$string$$ style
 	BacktickQuote bool // SQLite/MySQL `identifier` style
+
+	// The following only affect Tokenize.
+	HashComment     bool // MySQL # line comments
+	BackslashEscape bool // MySQL \' escapes inside string literals
 }
 
 // ContainsReturning scans query for the RETURNING keyword, correctly skipping
@@ -421,3 +539,153 @@ func parseDollarTag(query string, i int) string {
 	}
 	return query[i : j+1]
 }
+
+// SingleTableName returns the table read by a simple single-table SELECT
+// such as "SELECT a, b FROM users u WHERE ...". Schema-qualified names are
+// returned as "schema.table" with identifier quotes removed. It returns ""
+// for non-SELECT statements, subqueries in FROM, joins, comma joins and
+// set operations (UNION/INTERSECT/EXCEPT).
+func SingleTableName(query string) string {
+	if LeadingKeyword(query) != "select" {
+		return ""
+	}
+
+	i := nextTopLevelWord(query, 0, "from")
+	if i < 0 {
+		return ""
+	}
+
+	var parts []string
+	for {
+		i = skipWhitespaceAndComments(query, i)
+		name, end := readIdentifier(query, i)
+		if end == i {
+			return ""
+		}
+		parts = append(parts, name)
+		i = end
+		if i < len(query) && query[i] == '.' {
+			i++
+			continue
+		}
+		break
+	}
+
+	// Optional alias: [AS] name
+	i = skipWhitespaceAndComments(query, i)
+	word, end := readIdentifier(query, i)
+	if strings.EqualFold(word, "as") {
+		i = skipWhitespaceAndComments(query, end)
+		word, end = readIdentifier(query, i)
+		if end == i {
+			return ""
+		}
+		i = end
+	} else if end > i && !singleTableClauseKeywords[strings.ToLower(word)] {
+		i = end
+	}
+
+	// The table reference must be followed by the end of the statement or
+	// a clause keyword; anything else (",", JOIN, ...) means several tables.
+	i = skipWhitespaceAndComments(query, i)
+	if i < len(query) && query[i] != ';' {
+		word, _ := readIdentifier(query, i)
+		if !singleTableClauseKeywords[strings.ToLower(word)] {
+			return ""
+		}
+	}
+	for _, op := range []string{"union", "intersect", "except"} {
+		if nextTopLevelWord(query, i, op) >= 0 {
+			return ""
+		}
+	}
+	return strings.Join(parts, ".")
+}
+
+// singleTableClauseKeywords are the keywords that may directly follow the
+// table reference in a single-table SELECT.
+var singleTableClauseKeywords = map[string]bool{
+	"where": true, "group": true, "order": true, "limit": true, "having": true,
+	"offset": true, "fetch": true, "for": true, "window": true,
+}
+
+// nextTopLevelWord scans query from i for the keyword word outside of
+// parentheses, literals, quoted identifiers and comments. It returns the
+// index just past the keyword, or -1 if not found.
+func nextTopLevelWord(query string, i int, word string) int {
+	n := len(query)
+	depth := 0
+	for i < n {
+		if j := skipWhitespaceAndComments(query, i); j != i {
+			i = j
+			continue
+		}
+		c := query[i]
+		switch {
+		case c == '(':
+			depth++
+			i++
+		case c == ')':
+			depth--
+			i++
+		case c == '\'':
+			i = skipSingleQuoted(query, i)
+		case c == '"':
+			i = skipDoubleQuoted(query, i)
+		case c == '`':
+			i = skipBacktickQuoted(query, i)
+		case c == '$':
+			i = skipDollarQuoted(query, i)
+		case isIdentCharByte(c):
+			end := i
+			for end < n && isIdentCharByte(query[end]) {
+				end++
+			}
+			if depth == 0 && strings.EqualFold(query[i:end], word) {
+				return end
+			}
+			i = end
+		default:
+			i++
+		}
+	}
+	return -1
+}
+
+// readIdentifier reads a bare or quoted ("...", `...`, [...]) identifier
+// starting at i and returns its unquoted name and the index just past it.
+// If no identifier starts at i, it returns "" and i.
+func readIdentifier(query string, i int) (string, int) {
+	n := len(query)
+	if i >= n {
+		return "", i
+	}
+	switch query[i] {
+	case '"':
+		end := skipDoubleQuoted(query, i)
+		if end-i < 2 || query[end-1] != '"' {
+			return "", i
+		}
+		return strings.ReplaceAll(query[i+1:end-1], `""`, `"`), end
+	case '`':
+		end := skipBacktickQuoted(query, i)
+		if end-i < 2 || query[end-1] != '`' {
+			return "", i
+		}
+		return query[i+1 : end-1], end
+	case '[':
+		end := strings.IndexByte(query[i:], ']')
+		if end < 0 {
+			return "", i
+		}
+		return query[i+1 : i+end], i + end + 1
+	}
+	end := i
+	for end < n && (isIdentCharByte(query[end]) || query[end] >= 0x80 || query[end] == '$') {
+		end++
+	}
+	if end == i || (query[i] >= '0' && query[i] <= '9') {
+		return "", i
+	}
+	return query[i:end], end
+}
@@ -187,3 +187,63 @@ func TestCteBodyKeyword(t *testing.T) {
 		})
 	}
 }
+
+func TestSingleTableName(t *testing.T) {
+	tests := []struct {
+		name  string
+		query string
+		want  string
+	}{
+		{"simple", "SELECT * FROM users", "users"},
+		{"where and limit", "SELECT id, name FROM users WHERE id > 3 LIMIT 10;", "users"},
+		{"alias", "SELECT u.id FROM users u WHERE u.id = 1", "users"},
+		{"as alias", "SELECT u.id FROM users AS u ORDER BY u.id", "users"},
+		{"schema qualified", "SELECT * FROM public.orders", "public.orders"},
+		{"double quoted", `SELECT * FROM "Order ""Items"""`, `Order "Items"`},
+		{"backtick", "SELECT * FROM `my table`", "my table"},
+		{"bracket", "SELECT * FROM [my table]", "my table"},
+		{"leading comment", "-- hi\nSELECT * FROM t", "t"},
+		{"subquery in select list", "SELECT (SELECT 1 FROM other), a FROM t", "t"},
+		{"from inside string", "SELECT 'from x' FROM t", "t"},
+		{"order by with commas", "SELECT * FROM t ORDER BY a, b", "t"},
+		{"join", "SELECT * FROM a JOIN b ON a.id = b.id", ""},
+		{"left join with alias", "SELECT * FROM a x LEFT JOIN b y ON x.id = y.id", ""},
+		{"comma join", "SELECT * FROM a, b", ""},
+		{"union", "SELECT id FROM a UNION SELECT id FROM b", ""},
+		{"subquery in from", "SELECT * FROM (SELECT 1) s", ""},
+		{"no from", "SELECT 1", ""},
+		{"not a select", "DELETE FROM t", ""},
+		{"cte", "WITH x AS (SELECT 1) SELECT * FROM x", ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := SingleTableName(tt.query); got != tt.want {
+				t.Errorf("SingleTableName(%q) = %q, want %q", tt.query, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSplitTableName(t *testing.T) {
+	tests := []struct{ in, schema, table string }{
+		{"users", "", "users"},
+		{"public.users", "public", "users"},
+		{"db.s.t", "db.s", "t"},
+	}
+	for _, tt := range tests {
+		schema, table := SplitTableName(tt.in)
+		if schema != tt.schema || table != tt.table {
+			t.Errorf("SplitTableName(%q) = %q, %q; want %q, %q", tt.in, schema, table, tt.schema, tt.table)
+		}
+	}
+}
+
+func TestBindVar(t *testing.T) {
+	if got := BindVar("postgres", 2); got != "$2" {
+		t.Errorf("postgres: got %q", got)
+	}
+	if got := BindVar("mysql", 2); got != "?" {
+		t.Errorf("mysql: got %q", got)
+	}
+}
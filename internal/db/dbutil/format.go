@@ -0,0 +1,277 @@
+package dbutil
+
+import (
+	"fmt"
+	"strings"
+	"unicode/utf8"
+)
+
+// subqueryIndent is how far a subquery or CTE body is indented past the
+// line that opens it.
+const subqueryIndent = 4
+
+// formatLevel is a parenthesised part of the query being formatted, or the
+// statement itself.
+type formatLevel struct {
+	block      bool   // a statement or subquery: clauses start new lines
+	indent     int    // indent of the clause lines
+	openIndent int    // indent of the line with the opening bracket
+	clause     string // current clause keyword, lower-cased
+	alignCol   int    // column of the first SELECT item, -1 until known
+	between    bool   // inside BETWEEN, before its AND
+}
+
+// formatter writes formatted SQL and tracks the output column.
+type formatter struct {
+	b          strings.Builder
+	col        int
+	lineStart  bool
+	lineIndent int // indent of the current (or pending) line
+}
+
+// newline ends the current line, unless nothing has been written on it,
+// and starts the next one at indent.
+func (f *formatter) newline(indent int) {
+	if !f.lineStart && f.b.Len() > 0 {
+		f.b.WriteByte('\n')
+	}
+	f.lineStart = true
+	f.lineIndent = indent
+}
+
+func (f *formatter) write(text string, space bool) {
+	switch {
+	case f.lineStart:
+		f.b.WriteString(strings.Repeat(" ", f.lineIndent))
+		f.col = f.lineIndent
+		f.lineStart = false
+	case space:
+		f.b.WriteByte(' ')
+		f.col++
+	}
+	f.b.WriteString(text)
+	if i := strings.LastIndexByte(text, '\n'); i >= 0 {
+		f.col = utf8.RuneCountInString(text[i+1:])
+	} else {
+		f.col += utf8.RuneCountInString(text)
+	}
+}
+
+// Format pretty-prints query: keywords upper-cased, one clause per line,
+// subqueries and CTE bodies indented and SELECT items aligned under the
+// first one. Literals, quoted identifiers and comments are copied as they
+// are. A query with an unterminated literal or comment is refused, as is
+// one whose tokens would read differently once laid out.
+func Format(query string, dialect Dialect) (string, error) {
+	var tokens []Token
+	for _, t := range Tokenize(query, dialect) {
+		if t.Unterminated {
+			return "", fmt.Errorf("unterminated %s at offset %d", tokenKindName(t.Kind), t.Start)
+		}
+		if t.Kind != TokenSpace {
+			tokens = append(tokens, t)
+		}
+	}
+
+	f := &formatter{lineStart: true}
+	stack := []*formatLevel{{block: true, alignCol: -1}}
+	var prev *Token
+	unary := false // prev is a unary operator or SELECT's *
+
+	for i := range tokens {
+		tok := tokens[i]
+		text := tok.Text(query)
+		lower := strings.ToLower(text)
+		lvl := stack[len(stack)-1]
+		next := ""
+		if i+1 < len(tokens) {
+			next = strings.ToLower(tokens[i+1].Text(query))
+		}
+		prevText := ""
+		if prev != nil {
+			prevText = strings.ToLower(prev.Text(query))
+		}
+
+		space := prev != nil && !unary && spaceBetween(prev, prevText, tok, text)
+		isKeyword := tok.Kind == TokenKeyword
+		if isKeyword {
+			text = strings.ToUpper(text)
+		}
+
+		switch {
+		case tok.Kind == TokenComment:
+			f.write(text, space)
+			if strings.HasPrefix(text, "--") || strings.HasPrefix(text, "#") {
+				f.newline(f.lineIndent)
+			}
+		case isKeyword && lvl.block && startsClause(lower, prevText, next):
+			f.newline(lvl.indent)
+			f.write(text, false)
+			lvl.clause, lvl.alignCol, lvl.between = lower, -1, false
+		case isKeyword && (lower == "and" || lower == "or") && lvl.block && (lvl.clause == "where" || lvl.clause == "having"):
+			if lower == "and" && lvl.between {
+				lvl.between = false
+				f.write(text, space)
+				break
+			}
+			f.newline(lvl.indent + 2)
+			f.write(text, false)
+		case text == "(":
+			f.write(text, space)
+			block := next == "select" || next == "with"
+			open := &formatLevel{block: block, indent: f.lineIndent + subqueryIndent, openIndent: f.lineIndent, alignCol: -1}
+			stack = append(stack, open)
+			if block {
+				f.newline(open.indent)
+			}
+		case text == ")":
+			if len(stack) > 1 {
+				stack = stack[:len(stack)-1]
+				if lvl.block {
+					f.newline(lvl.openIndent)
+				}
+			}
+			f.write(text, false)
+		case text == ",":
+			f.write(text, false)
+			if lvl.block && lvl.clause == "select" && lvl.alignCol >= 0 {
+				f.newline(lvl.alignCol)
+			}
+		case text == ";":
+			f.write(text, false)
+			stack = []*formatLevel{{block: true, alignCol: -1}}
+			if i+1 < len(tokens) {
+				f.newline(0)
+				f.b.WriteByte('\n')
+			}
+		default:
+			if isKeyword && lower == "between" {
+				lvl.between = true
+			}
+			f.write(text, space)
+		}
+
+		// The first SELECT item (after DISTINCT or ALL) sets the column the
+		// other items line up under.
+		if lvl.block && lvl.clause == "select" && lvl.alignCol < 0 && lower != "select" && lower != "distinct" && lower != "all" {
+			lvl.alignCol = f.col - utf8.RuneCountInString(text)
+		}
+
+		unary = isUnary(tok, text, prev, prevText)
+		prev = &tokens[i]
+	}
+	out := f.b.String()
+	if err := sameTokens(query, tokens, out, dialect); err != nil {
+		return "", err
+	}
+	return out, nil
+}
+
+// sameTokens checks that formatted reads as the same tokens as query, so
+// that a layout change never changes what the query means. Keywords may
+// differ in case only.
+func sameTokens(query string, tokens []Token, formatted string, dialect Dialect) error {
+	var got []Token
+	for _, t := range Tokenize(formatted, dialect) {
+		if t.Kind != TokenSpace {
+			got = append(got, t)
+		}
+	}
+	for i, want := range tokens {
+		if i >= len(got) {
+			break
+		}
+		a, b := want.Text(query), got[i].Text(formatted)
+		if want.Kind != got[i].Kind || a != b && !(want.Kind == TokenKeyword && strings.EqualFold(a, b)) {
+			return fmt.Errorf("formatting would change the query at offset %d (%q)", want.Start, a)
+		}
+	}
+	if len(got) != len(tokens) {
+		return fmt.Errorf("formatting would change the query: %d tokens, %d after formatting", len(tokens), len(got))
+	}
+	return nil
+}
+
+// startsClause reports whether keyword kw, between the keywords prev and
+// next, begins a clause on a new line.
+func startsClause(kw, prev, next string) bool {
+	switch kw {
+	case "select", "where", "having", "limit", "offset", "returning", "union",
+		"intersect", "except", "values", "insert", "window", "fetch":
+		return true
+	case "from":
+		return prev != "delete" && prev != "distinct"
+	case "group", "order":
+		return next == "by"
+	case "on":
+		return next == "conflict"
+	case "update":
+		return prev != "do" && prev != "on" && prev != "for"
+	case "delete":
+		return prev != "on"
+	case "set":
+		return prev != "update" && prev != "delete"
+	case "join":
+		switch prev {
+		case "left", "right", "full", "inner", "cross", "natural", "outer":
+			return false
+		}
+		return true
+	case "left", "right", "full", "inner", "cross", "natural":
+		return next != "("
+	}
+	return false
+}
+
+// spaceBetween reports whether a space separates tok from prev.
+func spaceBetween(prev *Token, prevText string, tok Token, text string) bool {
+	switch text {
+	case ",", ";", ")", ".", "::", "]":
+		return false
+	case "(", "[":
+		// Keep calls like count(*) and CAST(...) as written.
+		if prev.End == tok.Start && prev.Kind != TokenPunct {
+			return false
+		}
+	}
+	switch prevText {
+	case "(", ".", "::", "[":
+		return false
+	}
+	return true
+}
+
+// isUnary reports whether tok is an operator that binds to the token after
+// it without a space: a sign, or * for all columns.
+func isUnary(tok Token, text string, prev *Token, prevText string) bool {
+	if tok.Kind != TokenPunct || (text != "-" && text != "+" && text != "*" && text != "~") {
+		return false
+	}
+	if prev == nil {
+		return true
+	}
+	switch prev.Kind {
+	case TokenPunct:
+		return prevText != ")" && prevText != "]"
+	case TokenKeyword:
+		switch prevText {
+		case "null", "true", "false", "end", "current_date", "current_time", "current_timestamp":
+			return false
+		}
+		return true
+	}
+	return false
+}
+
+func tokenKindName(k TokenKind) string {
+	switch k {
+	case TokenString:
+		return "string literal"
+	case TokenComment:
+		return "comment"
+	case TokenDollarBody:
+		return "dollar-quoted string"
+	default:
+		return "quoted identifier"
+	}
+}
@@ -0,0 +1,135 @@
+package dbutil
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestFormat(t *testing.T) {
+	tests := []struct {
+		name    string
+		query   string
+		dialect Dialect
+		want    string
+	}{
+		{
+			"clauses and aligned select list",
+			"select a, b as x, count(*) from t left join u on u.id = t.id where a = 1 and b between 1 and 2 order by b desc limit 10",
+			Dialect{},
+			`SELECT a,
+       b AS x,
+       count(*)
+FROM t
+LEFT JOIN u ON u.id = t.id
+WHERE a = 1
+  AND b BETWEEN 1 AND 2
+ORDER BY b DESC
+LIMIT 10`,
+		},
+		{
+			"subquery",
+			"select * from t where id in (select id from u where name = 'it''s -- not a comment')",
+			Dialect{},
+			`SELECT *
+FROM t
+WHERE id IN (
+    SELECT id
+    FROM u
+    WHERE name = 'it''s -- not a comment'
+)`,
+		},
+		{
+			"cte, comments and statements",
+			"with r as (select * from o) -- recent\nselect r.id, -1 from r /* keep  spacing */;select `Order`",
+			DialectFor("mysql"),
+			"WITH r AS (\n    SELECT *\n    FROM o\n) -- recent\nSELECT r.id,\n       -1\nFROM r /* keep  spacing */;\n\nSELECT `Order`",
+		},
+		{
+			"dollar body and casts",
+			"select $fn$ select  1 $fn$::text, a::int[] from t where x=$1",
+			Dialect{DollarQuote: true},
+			"SELECT $fn$ select  1 $fn$::text,\n       a::int[]\nFROM t\nWHERE x = $1",
+		},
+		{
+			"insert",
+			"insert into t (a, b) values (1, 2) on conflict (a) do update set b = excluded.b returning *",
+			Dialect{DollarQuote: true},
+			"INSERT INTO t (a, b)\nVALUES (1, 2)\nON CONFLICT (a) DO UPDATE SET b = excluded.b\nRETURNING *",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := Format(tt.query, tt.dialect)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got != tt.want {
+				t.Errorf("Format()\n got:\n%s\nwant:\n%s", got, tt.want)
+			}
+			again, _ := Format(got, tt.dialect)
+			if again != got {
+				t.Errorf("formatting twice changed the query:\n%s", again)
+			}
+		})
+	}
+}
+
+// TestFormatKeepsLexicalUnits checks that operators, prefixed literals,
+// variables and named parameters survive formatting in one piece.
+func TestFormatKeepsLexicalUnits(t *testing.T) {
+	pg := DialectFor("postgres")
+	my := DialectFor("mysql")
+	tests := []struct {
+		query   string
+		dialect Dialect
+		want    string
+	}{
+		{"select c #> '{a}' from t", pg, "SELECT c #> '{a}'\nFROM t"},
+		{"select data#>>'{a,b}' from t", pg, "SELECT data #>> '{a,b}'\nFROM t"},
+		{"select * from t where j ?| array['a','b']", pg, "SELECT *\nFROM t\nWHERE j ?| array['a', 'b']"},
+		{"select * from t where j ?& array['a'] and k ? 'b'", pg, "SELECT *\nFROM t\nWHERE j ?& array['a']\n  AND k ? 'b'"},
+		{"select x'ff', b'01', n'abc'", my, "SELECT x'ff',\n       b'01',\n       n'abc'"},
+		{"select E'it\\'s', U&'d\\0061t\\+000061'", pg, "SELECT E'it\\'s',\n       U&'d\\0061t\\+000061'"},
+		{"select @@version, @x := 1", my, "SELECT @@version,\n       @x := 1"},
+		{"select 1 # note\nfrom t where a=?", my, "SELECT 1 # note\nFROM t\nWHERE a = ?"},
+		{"select * from t where id = :name and a::int>1", pg, "SELECT *\nFROM t\nWHERE id = :name\n  AND a::int > 1"},
+		{"select tsv @@ q, a @> b from t", pg, "SELECT tsv @@ q,\n       a @> b\nFROM t"},
+	}
+	for _, tt := range tests {
+		got, err := Format(tt.query, tt.dialect)
+		if err != nil {
+			t.Errorf("Format(%q): %v", tt.query, err)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("Format(%q)\n got:\n%s\nwant:\n%s", tt.query, got, tt.want)
+		}
+		if again, _ := Format(got, tt.dialect); again != got {
+			t.Errorf("formatting %q twice changed it:\n%s", tt.query, again)
+		}
+	}
+}
+
+func TestSameTokens(t *testing.T) {
+	query := "select x'ff'"
+	var tokens []Token
+	for _, tok := range Tokenize(query, Dialect{}) {
+		if tok.Kind != TokenSpace {
+			tokens = append(tokens, tok)
+		}
+	}
+	if err := sameTokens(query, tokens, "SELECT x'ff'", Dialect{}); err != nil {
+		t.Errorf("expected a change of case to pass, got %v", err)
+	}
+	if err := sameTokens(query, tokens, "SELECT x 'ff'", Dialect{}); err == nil {
+		t.Error("expected splitting the literal to be refused")
+	}
+}
+
+func TestFormatRefusesUnterminated(t *testing.T) {
+	_, err := Format("select 'abc from t", Dialect{})
+	if err == nil || !strings.Contains(err.Error(), "unterminated string literal") {
+		t.Errorf("err = %v", err)
+	}
+}
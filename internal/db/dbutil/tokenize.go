@@ -0,0 +1,338 @@
+package dbutil
+
+import (
+	"strings"
+	"unicode/utf8"
+)
+
+// TokenKind classifies a Token.
+type TokenKind int
+
+const (
+	TokenSpace       TokenKind = iota // whitespace
+	TokenWord                         // identifier or unknown word
+	TokenKeyword                      // reserved word, see IsKeyword
+	TokenQuotedIdent                  // "ident", `ident` or [ident]
+	TokenString                       // 'string literal'
+	TokenNumber                       // 42, 3.14, 1e9, 0xFF
+	TokenComment                      // -- line, # line or /* block */
+	TokenDollarBody                   // PostgreSQL $tag$ body $tag$
+	TokenParam                        // ?, $1 or :name bind parameter, @var or @@var variable
+	TokenPunct                        // operators, commas and brackets
+)
+
+// Token is a span of a query. Start and End are byte offsets.
+type Token struct {
+	Kind         TokenKind
+	Start, End   int
+	Unterminated bool // a literal, quoted identifier or comment runs to the end of the query
+}
+
+// Text returns the text of t in query.
+func (t Token) Text(query string) string {
+	return query[t.Start:t.End]
+}
+
+// DialectFor returns the quoting styles of the given database type.
+func DialectFor(dbType string) Dialect {
+	switch dbType {
+	case "postgres":
+		return Dialect{DollarQuote: true}
+	case "mysql":
+		return Dialect{BacktickQuote: true, HashComment: true, BackslashEscape: true}
+	default:
+		return Dialect{BracketQuote: true, BacktickQuote: true}
+	}
+}
+
+// Tokenize splits query into tokens that cover it without gaps. Quoting
+// follows dialect; an unclosed literal or comment becomes a single token
+// marked Unterminated.
+func Tokenize(query string, dialect Dialect) []Token {
+	var tokens []Token
+	n := len(query)
+	i := 0
+	for i < n {
+		start := i
+		kind := TokenPunct
+		unterminated := false
+		c := query[i]
+		switch {
+		case c == ' ' || c == '\t' || c == '\n' || c == '\r':
+			kind = TokenSpace
+			for i < n && (query[i] == ' ' || query[i] == '\t' || query[i] == '\n' || query[i] == '\r') {
+				i++
+			}
+		case c == '-' && i+1 < n && query[i+1] == '-', dialect.HashComment && c == '#':
+			kind = TokenComment
+			for i < n && query[i] != '\n' {
+				i++
+			}
+		case c == '/' && i+1 < n && query[i+1] == '*':
+			kind = TokenComment
+			if end := strings.Index(query[i+2:], "*/"); end >= 0 {
+				i += 2 + end + 2
+			} else {
+				i, unterminated = n, true
+			}
+		case c == '\'':
+			kind = TokenString
+			i, unterminated = scanQuoted(query, i, '\'', dialect.BackslashEscape)
+		case c == '"':
+			kind = TokenQuotedIdent
+			i, unterminated = scanQuoted(query, i, '"', dialect.BackslashEscape)
+		case dialect.BacktickQuote && c == '`':
+			kind = TokenQuotedIdent
+			i, unterminated = scanQuoted(query, i, '`', false)
+		case dialect.BracketQuote && c == '[':
+			kind = TokenQuotedIdent
+			if end := strings.IndexByte(query[i:], ']'); end >= 0 {
+				i += end + 1
+			} else {
+				i, unterminated = n, true
+			}
+		case c == '$' && i+1 < n && query[i+1] >= '0' && query[i+1] <= '9':
+			kind = TokenParam
+			i++
+			for i < n && query[i] >= '0' && query[i] <= '9' {
+				i++
+			}
+		case dialect.DollarQuote && c == '$' && parseDollarTag(query, i) != "":
+			kind = TokenDollarBody
+			tag := parseDollarTag(query, i)
+			if end := strings.Index(query[i+len(tag):], tag); end >= 0 {
+				i += len(tag) + end + len(tag)
+			} else {
+				i, unterminated = n, true
+			}
+		case c == '?' && !(i+1 < n && strings.IndexByte("|&#-", query[i+1]) >= 0):
+			// ?|, ?& and ?# are PostgreSQL operators.
+			kind = TokenParam
+			i++
+		case c == '@' && i+1 < n && (isIdentStart(query[i+1]) || query[i+1] == '@' && i+2 < n && isIdentStart(query[i+2])),
+			c == ':' && i+1 < n && isIdentStart(query[i+1]):
+			// MySQL @user and @@system variables, :name parameters.
+			kind = TokenParam
+			i++
+			if query[i] == '@' {
+				i++
+			}
+			for i < n && (isIdentCharByte(query[i]) || query[i] == '$') {
+				i++
+			}
+		case prefixedLiteral(query, i) > 0:
+			// x'ff', b'01', n'abc', E'\n', U&'\0041' and U&"ident".
+			kind = TokenString
+			i += prefixedLiteral(query, i)
+			quote := query[i]
+			if quote == '"' {
+				kind = TokenQuotedIdent
+			}
+			backslash := dialect.BackslashEscape || c == 'e' || c == 'E'
+			i, unterminated = scanQuoted(query, i, quote, backslash)
+		case c >= '0' && c <= '9', c == '.' && i+1 < n && query[i+1] >= '0' && query[i+1] <= '9':
+			kind = TokenNumber
+			i = scanNumber(query, i)
+		case isIdentCharByte(c) || c >= utf8.RuneSelf:
+			kind = TokenWord
+			for i < n && (isIdentCharByte(query[i]) || query[i] >= utf8.RuneSelf || query[i] == '$') {
+				i++
+			}
+			if IsKeyword(query[start:i]) {
+				kind = TokenKeyword
+			}
+		default:
+			// Operators made of several characters (<=, ::, ||) stay
+			// together; brackets and commas are tokens of their own.
+			i++
+			if strings.IndexByte(operatorChars, c) >= 0 {
+				for i < n && continuesOperator(query, i, dialect) {
+					i++
+				}
+			}
+		}
+		tokens = append(tokens, Token{Kind: kind, Start: start, End: i, Unterminated: unterminated})
+	}
+	return tokens
+}
+
+// scanQuoted returns the index just past the literal opened by quote at i.
+// A doubled quote stands for itself; with backslash, so does \quote.
+func scanQuoted(query string, i int, quote byte, backslash bool) (int, bool) {
+	n := len(query)
+	i++
+	for i < n {
+		switch query[i] {
+		case '\\':
+			if backslash {
+				i += 2
+				continue
+			}
+		case quote:
+			if i+1 < n && query[i+1] == quote && quote != '`' {
+				i += 2
+				continue
+			}
+			return i + 1, false
+		}
+		i++
+	}
+	return n, true
+}
+
+func scanNumber(query string, i int) int {
+	n := len(query)
+	if i+1 < n && query[i] == '0' && (query[i+1] == 'x' || query[i+1] == 'X') {
+		i += 2
+		for i < n && strings.IndexByte("0123456789abcdefABCDEF", query[i]) >= 0 {
+			i++
+		}
+		return i
+	}
+	for i < n && (query[i] >= '0' && query[i] <= '9' || query[i] == '.') {
+		i++
+	}
+	if i < n && (query[i] == 'e' || query[i] == 'E') {
+		j := i + 1
+		if j < n && (query[j] == '+' || query[j] == '-') {
+			j++
+		}
+		if j < n && query[j] >= '0' && query[j] <= '9' {
+			i = j
+			for i < n && query[i] >= '0' && query[i] <= '9' {
+				i++
+			}
+		}
+	}
+	return i
+}
+
+// operatorChars are the characters that combine into multi-character
+// operators, such as PostgreSQL's #>> and ?|.
+const operatorChars = "<>=!|&:+-*/%^~@#?"
+
+// continuesOperator reports whether the operator before i goes on with
+// the character at i. A comment, a ? parameter (outside PostgreSQL, whose
+// operators include @? and ?|), a :name parameter or an @var variable
+// ends it, as in a=?, a=:name and a=@x.
+func continuesOperator(query string, i int, dialect Dialect) bool {
+	c := query[i]
+	next := byte(0)
+	if i+1 < len(query) {
+		next = query[i+1]
+	}
+	switch {
+	case strings.IndexByte(operatorChars, c) < 0, startsComment(query, i), dialect.HashComment && c == '#':
+		return false
+	case c == '?':
+		return dialect.DollarQuote
+	case c == ':':
+		return query[i-1] == ':' || !isIdentStart(next)
+	case c == '@':
+		return dialect.DollarQuote || !isIdentStart(next) && next != '@'
+	}
+	return true
+}
+
+// prefixedLiteral returns the length of the prefix when a typed or
+// prefixed literal starts at i: x'ff', b'01', n'abc', E'a\tb' or U&'a'
+// (also U&"ident"). It returns 0 otherwise.
+func prefixedLiteral(query string, i int) int {
+	if i > 0 && (isIdentCharByte(query[i-1]) || query[i-1] >= utf8.RuneSelf) {
+		return 0 // inside a word, as in "max'"
+	}
+	n := len(query)
+	switch query[i] {
+	case 'x', 'X', 'b', 'B', 'n', 'N', 'e', 'E':
+		if i+1 < n && query[i+1] == '\'' {
+			return 1
+		}
+	case 'u', 'U':
+		if i+2 < n && query[i+1] == '&' && (query[i+2] == '\'' || query[i+2] == '"') {
+			return 2
+		}
+	}
+	return 0
+}
+
+func isIdentStart(c byte) bool {
+	return c == '_' || c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c >= utf8.RuneSelf
+}
+
+func startsComment(query string, i int) bool {
+	return i+1 < len(query) && (query[i] == '-' && query[i+1] == '-' || query[i] == '/' && query[i+1] == '*')
+}
+
+// sqlKeywords are the words Tokenize reports as TokenKeyword.
+var sqlKeywords = func() map[string]bool {
+	words := make(map[string]bool)
+	for _, w := range strings.Fields(`
+		add all alter analyze and any as asc attach autoincrement begin between by
+		cascade case cast check collate column commit conflict constraint create cross
+		current_date current_time current_timestamp database default deferrable
+		delete desc describe detach distinct do drop each else end escape except
+		exclusive exists explain false fetch filter first following for foreign from
+		full function glob grant group having if ignore ilike in index inner insert
+		instead intersect interval into is isnull join key language last lateral left
+		like limit match materialized natural not nothing notnull null nulls of offset
+		on or order outer over partition pragma preceding primary range recursive
+		references regexp reindex release rename replace restrict returning returns
+		revoke right rollback row rows savepoint schema select sequence set show
+		similar table temp temporary then ties to transaction trigger true truncate
+		unbounded union unique update use using vacuum values view virtual when where
+		window with without`) {
+		words[w] = true
+	}
+	return words
+}()
+
+// IsKeyword reports whether word is an SQL keyword, ignoring case.
+func IsKeyword(word string) bool {
+	return sqlKeywords[strings.ToLower(word)]
+}
+
+// SelectsInto reports whether query has INTO outside parentheses, as in
+// PostgreSQL's SELECT … INTO new_table or MySQL's SELECT … INTO OUTFILE,
+// which write even though they read like a SELECT.
+func SelectsInto(query string, dialect Dialect) bool {
+	depth := 0
+	for _, t := range Tokenize(query, dialect) {
+		text := t.Text(query)
+		switch t.Kind {
+		case TokenPunct:
+			depth += strings.Count(text, "(") - strings.Count(text, ")")
+		case TokenKeyword, TokenWord:
+			if depth <= 0 && strings.EqualFold(text, "into") {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// ChangesSchema reports whether any statement of query starts with a DDL
+// keyword (CREATE, ALTER, DROP, RENAME, ATTACH or DETACH), so that a cached
+// list of tables and columns may be out of date after running it.
+func ChangesSchema(query string, dialect Dialect) bool {
+	first := true // the next word starts a statement
+	for _, t := range Tokenize(query, dialect) {
+		switch t.Kind {
+		case TokenSpace, TokenComment:
+			continue
+		case TokenPunct:
+			if t.Text(query) == ";" {
+				first = true
+				continue
+			}
+		case TokenKeyword:
+			if first {
+				switch strings.ToLower(t.Text(query)) {
+				case "create", "alter", "drop", "rename", "attach", "detach":
+					return true
+				}
+			}
+		}
+		first = false
+	}
+	return false
+}
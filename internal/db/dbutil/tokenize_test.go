@@ -0,0 +1,148 @@
+package dbutil
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+)
+
+// describe renders the non-space tokens as "kind:text", with a trailing !
+// for unterminated ones.
+func describe(query string, tokens []Token) string {
+	names := map[TokenKind]string{
+		TokenWord: "word", TokenKeyword: "kw", TokenQuotedIdent: "ident", TokenString: "str",
+		TokenNumber: "num", TokenComment: "comment", TokenDollarBody: "dollar", TokenParam: "param", TokenPunct: "punct",
+	}
+	var parts []string
+	for _, t := range tokens {
+		if t.Kind == TokenSpace {
+			continue
+		}
+		s := fmt.Sprintf("%s:%s", names[t.Kind], t.Text(query))
+		if t.Unterminated {
+			s += "!"
+		}
+		parts = append(parts, s)
+	}
+	return strings.Join(parts, " ")
+}
+
+func TestTokenize(t *testing.T) {
+	tests := []struct {
+		name    string
+		query   string
+		dialect Dialect
+		want    string
+	}{
+		{"keywords and words", "SELECT id FROM users", Dialect{},
+			"kw:SELECT word:id kw:FROM word:users"},
+		{"numbers", "x >= 1.5e3 + .5 - 0xFF", Dialect{},
+			"word:x punct:>= num:1.5e3 punct:+ num:.5 punct:- num:0xFF"},
+		{"doubled quote", "'it''s' || 'x'", Dialect{},
+			"str:'it''s' punct:|| str:'x'"},
+		{"comments", "a -- note\n/* block */ b", Dialect{},
+			"word:a comment:-- note comment:/* block */ word:b"},
+		{"operator before comment", "1 +-- c", Dialect{},
+			"num:1 punct:+ comment:-- c"},
+		{"cast and brackets", "f(x)::int", Dialect{},
+			"word:f punct:( word:x punct:) punct::: word:int"},
+		{"postgres dollar body", "DO $fn$ SELECT 'a' $fn$; $1", Dialect{DollarQuote: true},
+			"kw:DO dollar:$fn$ SELECT 'a' $fn$ punct:; param:$1"},
+		{"sqlite quoting", "[my col], `t`, \"x\"\"y\"", Dialect{BracketQuote: true, BacktickQuote: true},
+			"ident:[my col] punct:, ident:`t` punct:, ident:\"x\"\"y\""},
+		{"brackets without bracket quoting", "a[1]", Dialect{},
+			"word:a punct:[ num:1 punct:]"},
+		{"mysql escapes and hash comments", `'a\'b' # tail`, DialectFor("mysql"),
+			`str:'a\'b' comment:# tail`},
+		{"hash outside mysql", "a #> b", Dialect{},
+			"word:a punct:#> word:b"},
+		{"postgres json operators", "d#>>'{a}' ?| k ?& k @? p ? k", DialectFor("postgres"),
+			"word:d punct:#>> str:'{a}' punct:?| word:k punct:?& word:k punct:@? word:p param:? word:k"},
+		{"prefixed literals", "x'ff' B'01' n'abc' E'a\\'b' U&'\\0041' U&\"t\" max'", Dialect{},
+			`str:x'ff' str:B'01' str:n'abc' str:E'a\'b' str:U&'\0041' ident:U&"t" word:max str:'!`},
+		{"variables and named parameters", "@@session.time_zone, @x:=1, a=@y, b=:name, c::int, d=?", DialectFor("mysql"),
+			"param:@@session punct:. word:time_zone punct:, param:@x punct::= num:1 punct:, word:a punct:= param:@y punct:, " +
+				"word:b punct:= param::name punct:, word:c punct::: word:int punct:, word:d punct:= param:?"},
+		{"unterminated string", "WHERE a = 'abc", Dialect{},
+			"kw:WHERE word:a punct:= str:'abc!"},
+		{"unterminated comment", "a /* open", Dialect{},
+			"word:a comment:/* open!"},
+		{"unterminated dollar body", "$$ body", Dialect{DollarQuote: true},
+			"dollar:$$ body!"},
+		{"unicode words", "SELECT 名前 FROM t", Dialect{},
+			"kw:SELECT word:名前 kw:FROM word:t"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tokens := Tokenize(tt.query, tt.dialect)
+			if got := describe(tt.query, tokens); got != tt.want {
+				t.Errorf("Tokenize(%q)\n got %s\nwant %s", tt.query, got, tt.want)
+			}
+			// Tokens must cover the query without gaps.
+			pos := 0
+			for _, tok := range tokens {
+				if tok.Start != pos || tok.End <= tok.Start {
+					t.Fatalf("token %+v does not continue at %d", tok, pos)
+				}
+				pos = tok.End
+			}
+			if pos != len(tt.query) {
+				t.Errorf("tokens end at %d, want %d", pos, len(tt.query))
+			}
+		})
+	}
+}
+
+func TestIsKeyword(t *testing.T) {
+	for _, w := range []string{"select", "FROM", "Returning"} {
+		if !IsKeyword(w) {
+			t.Errorf("IsKeyword(%q) = false", w)
+		}
+	}
+	for _, w := range []string{"users", "", "id"} {
+		if IsKeyword(w) {
+			t.Errorf("IsKeyword(%q) = true", w)
+		}
+	}
+}
+
+func TestSelectsInto(t *testing.T) {
+	tests := []struct {
+		query string
+		want  bool
+	}{
+		{"SELECT * INTO archive FROM t", true},
+		{"WITH x AS (SELECT 1) SELECT * INTO copy FROM x", true},
+		{"select id into outfile '/tmp/t' from t", true},
+		{"SELECT * FROM t WHERE id IN (SELECT id FROM u)", false},
+		{"SELECT 'into' AS \"into\" FROM t", false},
+		{"SELECT * FROM t -- into x", false},
+		{"SELECT * FROM t", false},
+	}
+	for _, tt := range tests {
+		if got := SelectsInto(tt.query, Dialect{}); got != tt.want {
+			t.Errorf("SelectsInto(%q) = %v, want %v", tt.query, got, tt.want)
+		}
+	}
+}
+
+func TestChangesSchema(t *testing.T) {
+	tests := []struct {
+		query string
+		want  bool
+	}{
+		{"CREATE TABLE t (id int)", true},
+		{"  -- add a column\nalter table t add c int", true},
+		{"SELECT 1; DROP VIEW v", true},
+		{"SELECT 'drop table t'", false},
+		{"UPDATE t SET created = 1", false},
+		{"INSERT INTO t VALUES (1)", false},
+		{"", false},
+	}
+	for _, tt := range tests {
+		if got := ChangesSchema(tt.query, Dialect{}); got != tt.want {
+			t.Errorf("ChangesSchema(%q) = %v, want %v", tt.query, got, tt.want)
+		}
+	}
+}
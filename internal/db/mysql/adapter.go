@@ -8,7 +8,7 @@ import (
 	"strings"
 	"time"
 
-	_ "github.com/go-sql-driver/mysql"
+	gomysql "github.com/go-sql-driver/mysql"
 
 	"github.com/kwrkb/asql/internal/db"
 	"github.com/kwrkb/asql/internal/db/dbutil"
@@ -35,6 +35,19 @@ func Open(dsn string) (*Adapter, error) {
 		return nil, err
 	}
 
+	if !hasParam(driverDSN, "loc") {
+		// MySQL sends TIMESTAMP values as wall-clock times in the session
+		// time zone. Read them in that zone, rather than the driver's
+		// default of UTC, so that they arrive as the right instants.
+		loc, err := sessionLocation(ctx, conn)
+		if err == nil && loc != time.UTC {
+			conn, err = reopenIn(conn, driverDSN, loc)
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+
 	conn.SetMaxOpenConns(5)
 	conn.SetMaxIdleConns(2)
 	conn.SetConnMaxLifetime(5 * time.Minute)
@@ -42,6 +55,60 @@ func Open(dsn string) (*Adapter, error) {
 	return &Adapter{conn: conn}, nil
 }
 
+// hasParam reports whether the go-sql-driver DSN sets the parameter name.
+func hasParam(driverDSN, name string) bool {
+	_, query, ok := strings.Cut(driverDSN, "?")
+	if !ok {
+		return false
+	}
+	params, err := url.ParseQuery(query)
+	return err == nil && params.Has(name)
+}
+
+// sessionLocation returns the time zone of the session: a named zone when
+// the server uses one that is known here, else its current UTC offset.
+func sessionLocation(ctx context.Context, conn *sql.DB) (*time.Location, error) {
+	var name string
+	var offset int
+	err := conn.QueryRowContext(ctx, "SELECT @@session.time_zone, TIMESTAMPDIFF(SECOND, UTC_TIMESTAMP(), NOW())").Scan(&name, &offset)
+	if err != nil {
+		return nil, err
+	}
+	return resolveZone(name, offset), nil
+}
+
+// resolveZone turns a time_zone setting (SYSTEM, an offset such as
+// "+09:00", or a name such as "Asia/Tokyo") into a location. offset, in
+// seconds, is used when the name cannot be loaded.
+func resolveZone(name string, offset int) *time.Location {
+	if name != "SYSTEM" && !strings.HasPrefix(name, "+") && !strings.HasPrefix(name, "-") {
+		if loc, err := time.LoadLocation(name); err == nil {
+			return loc
+		}
+	}
+	// NOW() and UTC_TIMESTAMP() may fall on either side of a second.
+	d := (time.Duration(offset) * time.Second).Round(15 * time.Minute)
+	if d == 0 {
+		return time.UTC
+	}
+	return time.FixedZone("", int(d/time.Second))
+}
+
+// reopenIn replaces conn with a pool that reads temporal values in loc.
+func reopenIn(conn *sql.DB, driverDSN string, loc *time.Location) (*sql.DB, error) {
+	_ = conn.Close()
+	cfg, err := gomysql.ParseDSN(driverDSN)
+	if err != nil {
+		return nil, err
+	}
+	cfg.Loc = loc
+	connector, err := gomysql.NewConnector(cfg)
+	if err != nil {
+		return nil, err
+	}
+	return sql.OpenDB(connector), nil
+}
+
 func (a *Adapter) Type() string { return "mysql" }
 
 func (a *Adapter) QuoteIdentifier(name string) string {
@@ -91,6 +158,91 @@ func (a *Adapter) Columns(ctx context.Context, tableName string) ([]string, erro
 	return cols, rows.Err()
 }
 
+func (a *Adapter) PrimaryKey(ctx context.Context, tableName string) ([]string, error) {
+	schema, table := dbutil.SplitTableName(tableName)
+	rows, err := a.conn.QueryContext(ctx, `
+		SELECT COLUMN_NAME FROM information_schema.KEY_COLUMN_USAGE
+		WHERE TABLE_SCHEMA = COALESCE(NULLIF(?, ''), DATABASE())
+		  AND TABLE_NAME = ? AND CONSTRAINT_NAME = 'PRIMARY'
+		ORDER BY ORDINAL_POSITION`, schema, table)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var cols []string
+	for rows.Next() {
+		var name string
+		if err := rows.Scan(&name); err != nil {
+			return nil, err
+		}
+		cols = append(cols, name)
+	}
+	return cols, rows.Err()
+}
+
+func (a *Adapter) ColumnDetails(ctx context.Context, tableName string) ([]db.ColumnInfo, error) {
+	schema, table := dbutil.SplitTableName(tableName)
+	rows, err := a.conn.QueryContext(ctx, `
+		SELECT c.COLUMN_NAME, c.COLUMN_TYPE, c.IS_NULLABLE = 'YES', c.COLUMN_KEY = 'PRI',
+			COALESCE((SELECT CONCAT(k.REFERENCED_TABLE_NAME, '.', k.REFERENCED_COLUMN_NAME)
+				FROM information_schema.KEY_COLUMN_USAGE k
+				WHERE k.TABLE_SCHEMA = c.TABLE_SCHEMA AND k.TABLE_NAME = c.TABLE_NAME
+				  AND k.COLUMN_NAME = c.COLUMN_NAME AND k.REFERENCED_TABLE_NAME IS NOT NULL
+				LIMIT 1), '')
+		FROM information_schema.COLUMNS c
+		WHERE c.TABLE_SCHEMA = COALESCE(NULLIF(?, ''), DATABASE()) AND c.TABLE_NAME = ?
+		ORDER BY c.ORDINAL_POSITION`, schema, table)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var cols []db.ColumnInfo
+	for rows.Next() {
+		var c db.ColumnInfo
+		if err := rows.Scan(&c.Name, &c.Type, &c.Nullable, &c.PrimaryKey, &c.References); err != nil {
+			return nil, err
+		}
+		cols = append(cols, c)
+	}
+	return cols, rows.Err()
+}
+
+func (a *Adapter) AllColumnDetails(ctx context.Context) (map[string][]db.ColumnInfo, error) {
+	rows, err := a.conn.QueryContext(ctx, `
+		SELECT c.TABLE_NAME, c.COLUMN_NAME, c.COLUMN_TYPE, c.IS_NULLABLE = 'YES', c.COLUMN_KEY = 'PRI',
+			COALESCE(k.ref, '')
+		FROM information_schema.COLUMNS c
+		LEFT JOIN (
+			SELECT TABLE_NAME, COLUMN_NAME, MIN(CONCAT(REFERENCED_TABLE_NAME, '.', REFERENCED_COLUMN_NAME)) AS ref
+			FROM information_schema.KEY_COLUMN_USAGE
+			WHERE TABLE_SCHEMA = DATABASE() AND REFERENCED_TABLE_NAME IS NOT NULL
+			GROUP BY TABLE_NAME, COLUMN_NAME
+		) k ON k.TABLE_NAME = c.TABLE_NAME AND k.COLUMN_NAME = c.COLUMN_NAME
+		WHERE c.TABLE_SCHEMA = DATABASE()
+		ORDER BY c.TABLE_NAME, c.ORDINAL_POSITION`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	tables := make(map[string][]db.ColumnInfo)
+	for rows.Next() {
+		var table string
+		var c db.ColumnInfo
+		if err := rows.Scan(&table, &c.Name, &c.Type, &c.Nullable, &c.PrimaryKey, &c.References); err != nil {
+			return nil, err
+		}
+		tables[table] = append(tables[table], c)
+	}
+	return tables, rows.Err()
+}
+
+func (a *Adapter) Exec(ctx context.Context, stmts []db.Statement) error {
+	return dbutil.ExecTx(ctx, a.conn, stmts)
+}
+
 func (a *Adapter) Schema(ctx context.Context) (string, error) {
 	tables, err := a.Tables(ctx)
 	if err != nil {
@@ -140,6 +292,17 @@ func (a *Adapter) Query(ctx context.Context, query string) (db.QueryResult, erro
 	}, nil
 }
 
+// Stream re-runs a read-only query and streams its rows to h.
+func (a *Adapter) Stream(ctx context.Context, query string, h db.RowHandler) error {
+	return dbutil.StreamQuery(ctx, a.conn, query, readOnly, h)
+}
+
+// readOnly reports whether query only reads: it returns rows and has no
+// SELECT … INTO, which writes a file or sets variables.
+func readOnly(query string) bool {
+	return returnsRows(query) && !dbutil.SelectsInto(query, dbutil.DialectFor("mysql"))
+}
+
 // returnsRows determines whether a SQL statement returns a result set.
 // MySQL does not support RETURNING clause.
 func returnsRows(query string) bool {
@@ -5,6 +5,7 @@ import (
 	"os"
 	"strings"
 	"testing"
+	"time"
 )
 
 func TestReturnsRows(t *testing.T) {
@@ -43,6 +44,23 @@ func TestReturnsRows(t *testing.T) {
 	}
 }
 
+func TestReadOnly(t *testing.T) {
+	tests := []struct {
+		query string
+		want  bool
+	}{
+		{"SELECT * FROM t", true},
+		{"SELECT id INTO OUTFILE '/tmp/t' FROM t", false},
+		{"SELECT id FROM t INTO @id", false},
+		{"DELETE FROM t", false},
+	}
+	for _, tt := range tests {
+		if got := readOnly(tt.query); got != tt.want {
+			t.Errorf("readOnly(%q) = %v, want %v", tt.query, got, tt.want)
+		}
+	}
+}
+
 func TestConvertDSN(t *testing.T) {
 	tests := []struct {
 		name  string
@@ -64,6 +82,11 @@ func TestConvertDSN(t *testing.T) {
 			"mysql://root@localhost:3306/db?parseTime=false",
 			"root@tcp(localhost:3306)/db?parseTime=false",
 		},
+		{
+			"loc already set",
+			"mysql://root@localhost:3306/db?loc=Local",
+			"root@tcp(localhost:3306)/db?loc=Local&parseTime=true",
+		},
 		{
 			"no port",
 			"mysql://root@localhost/db",
@@ -74,6 +97,11 @@ func TestConvertDSN(t *testing.T) {
 			"mysql://localhost:3306/db",
 			"@tcp(localhost:3306)/db?parseTime=true",
 		},
+		{
+			"session time zone set",
+			"mysql://root@localhost:3306/db?time_zone=%27Asia%2FTokyo%27",
+			"root@tcp(localhost:3306)/db?parseTime=true&time_zone=%27Asia%2FTokyo%27",
+		},
 		{
 			"already go-sql-driver format",
 			"root:pass@tcp(127.0.0.1:3306)/testdb",
@@ -91,6 +119,36 @@ func TestConvertDSN(t *testing.T) {
 	}
 }
 
+func TestHasParam(t *testing.T) {
+	if !hasParam("root@tcp(h)/db?loc=Local&parseTime=true", "loc") {
+		t.Error("expected loc to be found")
+	}
+	if hasParam("root@tcp(h)/db?parseTime=true", "loc") || hasParam("root@tcp(h)/db", "loc") {
+		t.Error("expected no loc")
+	}
+}
+
+func TestResolveZone(t *testing.T) {
+	tests := []struct {
+		name   string
+		offset int
+		want   string // the offset of 2026-01-15 12:00 in the zone
+	}{
+		{"Asia/Tokyo", 0, "+09:00"},
+		{"+05:30", 5*3600 + 30*60 - 1, "+05:30"},
+		{"SYSTEM", -5 * 3600, "-05:00"},
+		{"SYSTEM", 1, "Z"},
+		{"Unknown/Zone", 3600, "+01:00"},
+	}
+	for _, tt := range tests {
+		loc := resolveZone(tt.name, tt.offset)
+		got := time.Date(2026, 1, 15, 12, 0, 0, 0, loc).Format("Z07:00")
+		if got != tt.want {
+			t.Errorf("resolveZone(%q, %d) offset = %s, want %s", tt.name, tt.offset, got, tt.want)
+		}
+	}
+}
+
 func TestType(t *testing.T) {
 	a := &Adapter{}
 	if got := a.Type(); got != "mysql" {
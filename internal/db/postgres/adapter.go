@@ -89,6 +89,106 @@ func (a *Adapter) Columns(ctx context.Context, tableName string) ([]string, erro
 	return cols, rows.Err()
 }
 
+func (a *Adapter) PrimaryKey(ctx context.Context, tableName string) ([]string, error) {
+	schema, table := dbutil.SplitTableName(tableName)
+	qualified := a.QuoteIdentifier(table)
+	if schema != "" {
+		qualified = a.QuoteIdentifier(schema) + "." + qualified
+	}
+	rows, err := a.conn.QueryContext(ctx, `
+		SELECT a.attname FROM pg_index i
+		JOIN pg_attribute a ON a.attrelid = i.indrelid AND a.attnum = ANY(i.indkey)
+		WHERE i.indrelid = $1::regclass AND i.indisprimary
+		ORDER BY array_position(i.indkey::int2[], a.attnum)`, qualified)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var cols []string
+	for rows.Next() {
+		var name string
+		if err := rows.Scan(&name); err != nil {
+			return nil, err
+		}
+		cols = append(cols, name)
+	}
+	return cols, rows.Err()
+}
+
+func (a *Adapter) ColumnDetails(ctx context.Context, tableName string) ([]db.ColumnInfo, error) {
+	schema, table := dbutil.SplitTableName(tableName)
+	qualified := a.QuoteIdentifier(table)
+	if schema != "" {
+		qualified = a.QuoteIdentifier(schema) + "." + qualified
+	}
+	rows, err := a.conn.QueryContext(ctx, `
+		SELECT a.attname, format_type(a.atttypid, a.atttypmod), NOT a.attnotnull,
+			EXISTS (SELECT 1 FROM pg_index i
+				WHERE i.indrelid = a.attrelid AND i.indisprimary AND a.attnum = ANY(i.indkey)),
+			COALESCE((SELECT c.confrelid::regclass::text || '.' || f.attname
+				FROM pg_constraint c
+				JOIN pg_attribute f ON f.attrelid = c.confrelid
+					AND f.attnum = c.confkey[array_position(c.conkey, a.attnum)]
+				WHERE c.conrelid = a.attrelid AND c.contype = 'f' AND a.attnum = ANY(c.conkey)
+				LIMIT 1), '')
+		FROM pg_attribute a
+		WHERE a.attrelid = $1::regclass AND a.attnum > 0 AND NOT a.attisdropped
+		ORDER BY a.attnum`, qualified)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var cols []db.ColumnInfo
+	for rows.Next() {
+		var c db.ColumnInfo
+		if err := rows.Scan(&c.Name, &c.Type, &c.Nullable, &c.PrimaryKey, &c.References); err != nil {
+			return nil, err
+		}
+		cols = append(cols, c)
+	}
+	return cols, rows.Err()
+}
+
+func (a *Adapter) AllColumnDetails(ctx context.Context) (map[string][]db.ColumnInfo, error) {
+	rows, err := a.conn.QueryContext(ctx, `
+		SELECT t.relname, a.attname, format_type(a.atttypid, a.atttypmod), NOT a.attnotnull,
+			EXISTS (SELECT 1 FROM pg_index i
+				WHERE i.indrelid = a.attrelid AND i.indisprimary AND a.attnum = ANY(i.indkey)),
+			COALESCE((SELECT c.confrelid::regclass::text || '.' || f.attname
+				FROM pg_constraint c
+				JOIN pg_attribute f ON f.attrelid = c.confrelid
+					AND f.attnum = c.confkey[array_position(c.conkey, a.attnum)]
+				WHERE c.conrelid = a.attrelid AND c.contype = 'f' AND a.attnum = ANY(c.conkey)
+				LIMIT 1), '')
+		FROM pg_class t
+		JOIN pg_namespace n ON n.oid = t.relnamespace
+		JOIN pg_attribute a ON a.attrelid = t.oid
+		WHERE n.nspname = 'public' AND t.relkind IN ('r', 'p')
+			AND a.attnum > 0 AND NOT a.attisdropped
+		ORDER BY t.relname, a.attnum`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	tables := make(map[string][]db.ColumnInfo)
+	for rows.Next() {
+		var table string
+		var c db.ColumnInfo
+		if err := rows.Scan(&table, &c.Name, &c.Type, &c.Nullable, &c.PrimaryKey, &c.References); err != nil {
+			return nil, err
+		}
+		tables[table] = append(tables[table], c)
+	}
+	return tables, rows.Err()
+}
+
+func (a *Adapter) Exec(ctx context.Context, stmts []db.Statement) error {
+	return dbutil.ExecTx(ctx, a.conn, stmts)
+}
+
 func (a *Adapter) Schema(ctx context.Context) (string, error) {
 	// Build CREATE TABLE statements from information_schema.columns
 	tables, err := a.Tables(ctx)
@@ -176,6 +276,17 @@ func (a *Adapter) Query(ctx context.Context, query string) (db.QueryResult, erro
 	}, nil
 }
 
+// Stream re-runs a read-only query and streams its rows to h.
+func (a *Adapter) Stream(ctx context.Context, query string, h db.RowHandler) error {
+	return dbutil.StreamQuery(ctx, a.conn, query, readOnly, h)
+}
+
+// readOnly reports whether query only reads: it returns rows, without
+// RETURNING, and has no SELECT … INTO, which creates a table.
+func readOnly(query string) bool {
+	return returnsRows(query) && !containsReturning(query) && !dbutil.SelectsInto(query, postgresDialect)
+}
+
 // returnsRows determines whether a SQL statement returns a result set.
 // PostgreSQL supports RETURNING clause.
 func returnsRows(query string) bool {
@@ -79,6 +79,25 @@ func TestContainsReturning(t *testing.T) {
 	}
 }
 
+func TestReadOnly(t *testing.T) {
+	tests := []struct {
+		query string
+		want  bool
+	}{
+		{"SELECT * FROM t", true},
+		{"SELECT * FROM t WHERE id IN (SELECT id FROM u)", true},
+		{"SELECT * INTO archive FROM t", false},
+		{"WITH x AS (SELECT 1) SELECT * INTO copy FROM x", false},
+		{"INSERT INTO t VALUES (1) RETURNING id", false},
+		{"DELETE FROM t", false},
+	}
+	for _, tt := range tests {
+		if got := readOnly(tt.query); got != tt.want {
+			t.Errorf("readOnly(%q) = %v, want %v", tt.query, got, tt.want)
+		}
+	}
+}
+
 func TestType(t *testing.T) {
 	a := &Adapter{}
 	if got := a.Type(); got != "postgres" {
@@ -87,6 +87,128 @@ func (a *Adapter) Columns(ctx context.Context, tableName string) ([]string, erro
 	return cols, rows.Err()
 }
 
+func (a *Adapter) PrimaryKey(ctx context.Context, tableName string) ([]string, error) {
+	rows, err := a.conn.QueryContext(ctx, a.tablePragma("table_info", tableName))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	// pk is the 1-based position of the column in the primary key, 0 if
+	// the column is not part of it.
+	byPos := map[int]string{}
+	for rows.Next() {
+		var cid int
+		var name, colType string
+		var notNull, pk int
+		var dfltValue *string
+		if err := rows.Scan(&cid, &name, &colType, &notNull, &dfltValue, &pk); err != nil {
+			return nil, err
+		}
+		if pk > 0 {
+			byPos[pk] = name
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	var cols []string
+	for i := 1; i <= len(byPos); i++ {
+		cols = append(cols, byPos[i])
+	}
+	return cols, nil
+}
+
+func (a *Adapter) ColumnDetails(ctx context.Context, tableName string) ([]db.ColumnInfo, error) {
+	rows, err := a.conn.QueryContext(ctx, a.tablePragma("table_info", tableName))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var cols []db.ColumnInfo
+	for rows.Next() {
+		var cid int
+		var name, colType string
+		var notNull, pk int
+		var dfltValue *string
+		if err := rows.Scan(&cid, &name, &colType, &notNull, &dfltValue, &pk); err != nil {
+			return nil, err
+		}
+		// SQLite lets most primary key columns hold NULL, but nobody means
+		// them to.
+		cols = append(cols, db.ColumnInfo{Name: name, Type: colType, Nullable: notNull == 0 && pk == 0, PrimaryKey: pk > 0})
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	fks, err := a.conn.QueryContext(ctx, a.tablePragma("foreign_key_list", tableName))
+	if err != nil {
+		return nil, err
+	}
+	defer fks.Close()
+	for fks.Next() {
+		var id, seq int
+		var table, from string
+		var to, onUpdate, onDelete, match *string
+		if err := fks.Scan(&id, &seq, &table, &from, &to, &onUpdate, &onDelete, &match); err != nil {
+			return nil, err
+		}
+		ref := table
+		if to != nil {
+			ref += "." + *to
+		}
+		for i := range cols {
+			if cols[i].Name == from && cols[i].References == "" {
+				cols[i].References = ref
+			}
+		}
+	}
+	return cols, fks.Err()
+}
+
+func (a *Adapter) AllColumnDetails(ctx context.Context) (map[string][]db.ColumnInfo, error) {
+	rows, err := a.conn.QueryContext(ctx, `
+		SELECT m.name, p.name, p.type, p."notnull", p.pk,
+			COALESCE((SELECT f."table" || COALESCE('.' || f."to", '')
+				FROM pragma_foreign_key_list(m.name) f WHERE f."from" = p.name LIMIT 1), '')
+		FROM sqlite_master m JOIN pragma_table_info(m.name) p
+		WHERE m.type = 'table'
+		ORDER BY m.name, p.cid`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	tables := make(map[string][]db.ColumnInfo)
+	for rows.Next() {
+		var table string
+		var c db.ColumnInfo
+		var notNull, pk int
+		if err := rows.Scan(&table, &c.Name, &c.Type, &notNull, &pk, &c.References); err != nil {
+			return nil, err
+		}
+		c.Nullable, c.PrimaryKey = notNull == 0 && pk == 0, pk > 0
+		tables[table] = append(tables[table], c)
+	}
+	return tables, rows.Err()
+}
+
+// tablePragma builds "PRAGMA [schema.]pragma(table)" for a possibly
+// schema-qualified table name.
+func (a *Adapter) tablePragma(pragma, tableName string) string {
+	schema, table := dbutil.SplitTableName(tableName)
+	if schema != "" {
+		return "PRAGMA " + a.QuoteIdentifier(schema) + "." + pragma + "(" + a.QuoteIdentifier(table) + ")"
+	}
+	return "PRAGMA " + pragma + "(" + a.QuoteIdentifier(table) + ")"
+}
+
+func (a *Adapter) Exec(ctx context.Context, stmts []db.Statement) error {
+	return dbutil.ExecTx(ctx, a.conn, stmts)
+}
+
 func (a *Adapter) Schema(ctx context.Context) (string, error) {
 	rows, err := a.conn.QueryContext(ctx, "SELECT sql FROM sqlite_master WHERE type='table' AND sql IS NOT NULL ORDER BY name")
 	if err != nil {
@@ -143,6 +265,16 @@ func (a *Adapter) queryRows(ctx context.Context, query string) (db.QueryResult,
 	return dbutil.ScanRows(rows)
 }
 
+// Stream re-runs a read-only query and streams its rows to h.
+func (a *Adapter) Stream(ctx context.Context, query string, h db.RowHandler) error {
+	return dbutil.StreamQuery(ctx, a.conn, query, readOnly, h)
+}
+
+// readOnly reports whether query only reads: it returns rows without RETURNING.
+func readOnly(query string) bool {
+	return returnsRows(query) && !containsReturning(query)
+}
+
 // returnsRows determines whether a SQL statement will produce a result set.
 // Two strategies:
 //  1. Leading keyword: SELECT, PRAGMA, WITH, EXPLAIN, VALUES always return rows.
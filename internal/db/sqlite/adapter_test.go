@@ -2,8 +2,12 @@ package sqlite
 
 import (
 	"context"
+	"reflect"
+	"slices"
 	"strings"
 	"testing"
+
+	"github.com/kwrkb/asql/internal/db"
 )
 
 func TestContainsReturning(t *testing.T) {
@@ -496,3 +500,228 @@ func TestQuery(t *testing.T) {
 		}
 	})
 }
+
+// collectHandler records streamed rows for assertions.
+type collectHandler struct {
+	columns, types []string
+	rows           [][]string
+	nulls          [][]bool
+}
+
+func (c *collectHandler) Header(columns, types []string) error {
+	c.columns, c.types = columns, types
+	return nil
+}
+
+func (c *collectHandler) Row(row []string, nulls []bool) error {
+	c.rows = append(c.rows, row)
+	c.nulls = append(c.nulls, nulls)
+	return nil
+}
+
+func TestStream(t *testing.T) {
+	ctx := context.Background()
+	a, err := Open(":memory:")
+	if err != nil {
+		t.Fatalf("Open failed: %v", err)
+	}
+	t.Cleanup(func() { a.Close() })
+
+	t.Run("streams beyond the scan limit", func(t *testing.T) {
+		const n = 12_000
+		var h collectHandler
+		query := "WITH RECURSIVE c(x) AS (SELECT 1 UNION ALL SELECT x+1 FROM c WHERE x < 12000) SELECT x, '' AS e FROM c"
+		if err := a.Stream(ctx, query, &h); err != nil {
+			t.Fatalf("Stream failed: %v", err)
+		}
+		if len(h.columns) != 2 || h.columns[0] != "x" {
+			t.Errorf("unexpected columns: %v", h.columns)
+		}
+		if len(h.rows) != n {
+			t.Fatalf("expected %d rows, got %d", n, len(h.rows))
+		}
+		if h.rows[n-1][0] != "12000" || h.rows[0][1] != `""` {
+			t.Errorf("unexpected rows: first=%v last=%v", h.rows[0], h.rows[n-1])
+		}
+	})
+
+	t.Run("flags NULL cells", func(t *testing.T) {
+		const query = "SELECT NULL AS n, 'NULL' AS t"
+		var h collectHandler
+		if err := a.Stream(ctx, query, &h); err != nil {
+			t.Fatalf("Stream failed: %v", err)
+		}
+		result, err := a.Query(ctx, query)
+		if err != nil {
+			t.Fatal(err)
+		}
+		want := []bool{true, false}
+		if !slices.Equal(h.nulls[0], want) || !slices.Equal(result.Nulls[0], want) {
+			t.Errorf("nulls = %v (streamed) and %v, want %v", h.nulls[0], result.Nulls[0], want)
+		}
+		if !slices.Equal(h.rows[0], []string{"NULL", "NULL"}) {
+			t.Errorf("rows = %v", h.rows[0])
+		}
+	})
+
+	t.Run("rejects writes", func(t *testing.T) {
+		if _, err := a.Query(ctx, "CREATE TABLE t (id INTEGER)"); err != nil {
+			t.Fatalf("CREATE TABLE failed: %v", err)
+		}
+		for _, q := range []string{
+			"INSERT INTO t VALUES (1) RETURNING id",
+			"DELETE FROM t",
+		} {
+			var h collectHandler
+			if err := a.Stream(ctx, q, &h); err == nil {
+				t.Errorf("expected %q to be rejected", q)
+			}
+		}
+		result, err := a.Query(ctx, "SELECT count(*) FROM t")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if result.Rows[0][0] != "0" {
+			t.Errorf("rejected statements must not run, count = %s", result.Rows[0][0])
+		}
+	})
+}
+
+func TestPrimaryKey(t *testing.T) {
+	ctx := context.Background()
+	a, err := Open(":memory:")
+	if err != nil {
+		t.Fatalf("Open failed: %v", err)
+	}
+	t.Cleanup(func() { a.Close() })
+
+	for _, q := range []string{
+		"CREATE TABLE single (id INTEGER PRIMARY KEY, name TEXT)",
+		"CREATE TABLE composite (a TEXT, b INTEGER, v TEXT, PRIMARY KEY (b, a))",
+		"CREATE TABLE nokey (v TEXT)",
+	} {
+		if _, err := a.Query(ctx, q); err != nil {
+			t.Fatalf("%s: %v", q, err)
+		}
+	}
+
+	tests := []struct {
+		table string
+		want  []string
+	}{
+		{"single", []string{"id"}},
+		{"main.composite", []string{"b", "a"}},
+		{"nokey", nil},
+	}
+	for _, tt := range tests {
+		got, err := a.PrimaryKey(ctx, tt.table)
+		if err != nil {
+			t.Fatalf("PrimaryKey(%q) failed: %v", tt.table, err)
+		}
+		if strings.Join(got, ",") != strings.Join(tt.want, ",") {
+			t.Errorf("PrimaryKey(%q) = %v, want %v", tt.table, got, tt.want)
+		}
+	}
+}
+
+func TestColumnDetails(t *testing.T) {
+	ctx := context.Background()
+	a, err := Open(":memory:")
+	if err != nil {
+		t.Fatalf("Open failed: %v", err)
+	}
+	t.Cleanup(func() { a.Close() })
+
+	for _, q := range []string{
+		"CREATE TABLE users (id INTEGER PRIMARY KEY, email TEXT NOT NULL)",
+		"CREATE TABLE orders (id INTEGER PRIMARY KEY, user_id INTEGER REFERENCES users(id), note)",
+	} {
+		if _, err := a.Query(ctx, q); err != nil {
+			t.Fatalf("%s: %v", q, err)
+		}
+	}
+
+	got, err := a.ColumnDetails(ctx, "main.orders")
+	if err != nil {
+		t.Fatalf("ColumnDetails failed: %v", err)
+	}
+	want := []db.ColumnInfo{
+		{Name: "id", Type: "INTEGER", PrimaryKey: true},
+		{Name: "user_id", Type: "INTEGER", Nullable: true, References: "users.id"},
+		{Name: "note", Nullable: true},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("ColumnDetails = %+v, want %+v", got, want)
+	}
+
+	got, err = a.ColumnDetails(ctx, "users")
+	if err != nil {
+		t.Fatalf("ColumnDetails failed: %v", err)
+	}
+	if len(got) != 2 || got[1].Nullable {
+		t.Errorf("expected email to be NOT NULL, got %+v", got)
+	}
+
+	// AllColumnDetails reads the same for every table at once.
+	all, err := a.AllColumnDetails(ctx)
+	if err != nil {
+		t.Fatalf("AllColumnDetails failed: %v", err)
+	}
+	if len(all) != 2 || !reflect.DeepEqual(all["orders"], want) || !reflect.DeepEqual(all["users"], got) {
+		t.Errorf("AllColumnDetails = %+v", all)
+	}
+}
+
+func TestExec(t *testing.T) {
+	ctx := context.Background()
+	a, err := Open(":memory:")
+	if err != nil {
+		t.Fatalf("Open failed: %v", err)
+	}
+	t.Cleanup(func() { a.Close() })
+
+	if _, err := a.Query(ctx, "CREATE TABLE t (id INTEGER PRIMARY KEY, flag TEXT)"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := a.Query(ctx, "INSERT INTO t VALUES (1, 'a'), (2, 'b')"); err != nil {
+		t.Fatal(err)
+	}
+
+	flags := func() string {
+		res, err := a.Query(ctx, "SELECT flag FROM t ORDER BY id")
+		if err != nil {
+			t.Fatal(err)
+		}
+		var out []string
+		for _, r := range res.Rows {
+			out = append(out, r[0])
+		}
+		return strings.Join(out, ",")
+	}
+
+	t.Run("commits all statements", func(t *testing.T) {
+		err := a.Exec(ctx, []db.Statement{
+			{SQL: "UPDATE t SET flag = ? WHERE id = ?", Args: []any{"x", "1"}, ExpectRows: 1},
+			{SQL: "UPDATE t SET flag = ? WHERE id = ?", Args: []any{nil, "2"}, ExpectRows: 1},
+		})
+		if err != nil {
+			t.Fatalf("Exec failed: %v", err)
+		}
+		if got := flags(); got != "x,NULL" {
+			t.Errorf("flags = %q", got)
+		}
+	})
+
+	t.Run("rolls back on unexpected row count", func(t *testing.T) {
+		err := a.Exec(ctx, []db.Statement{
+			{SQL: "UPDATE t SET flag = ? WHERE id = ?", Args: []any{"y", "1"}, ExpectRows: 1},
+			{SQL: "UPDATE t SET flag = ? WHERE id = ?", Args: []any{"y", "99"}, ExpectRows: 1},
+		})
+		if err == nil || !strings.Contains(err.Error(), "affected 0 row(s)") {
+			t.Fatalf("expected row count error, got %v", err)
+		}
+		if got := flags(); got != "x,NULL" {
+			t.Errorf("expected rollback, flags = %q", got)
+		}
+	})
+}
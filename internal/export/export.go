@@ -3,11 +3,8 @@ package export
 import (
 	"bytes"
 	"encoding/csv"
-	"encoding/json"
 	"fmt"
-	"os"
 	"strings"
-	"time"
 )
 
 // FormatCSV formats query results as CSV.
@@ -29,27 +26,48 @@ func FormatCSV(headers []string, rows [][]string) (string, error) {
 	return buf.String(), nil
 }
 
-// FormatJSON formats query results as a JSON array of objects.
-// Duplicate column names get a numeric suffix (e.g. "id", "id_2").
-func FormatJSON(headers []string, rows [][]string) (string, error) {
-	keys := deduplicateHeaders(headers)
-	records := make([]map[string]string, 0, len(rows))
-	for _, row := range rows {
-		record := make(map[string]string, len(keys))
-		for i, k := range keys {
-			if i < len(row) {
-				record[k] = row[i]
-			} else {
-				record[k] = ""
-			}
+// FormatTSV formats query results as tab-separated values.
+func FormatTSV(headers []string, rows [][]string) (string, error) {
+	var b strings.Builder
+	if err := Write(&b, TSV, headers, rows, Options{}); err != nil {
+		return "", err
+	}
+	return b.String(), nil
+}
+
+// FormatINList formats cells as a parenthesized SQL list for use with IN,
+// e.g. "(1, 'a')". colTypes[i] types cells[i] (see SQLLiteral). NULL cells
+// are skipped because they never match, and repeated values are listed once.
+func FormatINList(cells, colTypes []string, dialect string) string {
+	seen := make(map[string]bool, len(cells))
+	var items []string
+	for i, cell := range cells {
+		if cell == "NULL" {
+			continue
+		}
+		colType := ""
+		if i < len(colTypes) {
+			colType = colTypes[i]
+		}
+		lit := SQLLiteral(cell, colType, dialect)
+		if seen[lit] {
+			continue
 		}
-		records = append(records, record)
+		seen[lit] = true
+		items = append(items, lit)
 	}
-	b, err := json.MarshalIndent(records, "", "  ")
-	if err != nil {
-		return "", fmt.Errorf("marshaling JSON: %w", err)
+	return "(" + strings.Join(items, ", ") + ")"
+}
+
+// FormatJSON formats query results as a JSON array of objects with keys in
+// column order. Duplicate column names get a numeric suffix (e.g. "id_1",
+// "id_2"). Values are typed from opts.ColumnTypes (see JSONValue).
+func FormatJSON(headers []string, rows [][]string, opts Options) (string, error) {
+	var b strings.Builder
+	if err := Write(&b, JSON, headers, rows, opts); err != nil {
+		return "", err
 	}
-	return string(b), nil
+	return b.String(), nil
 }
 
 func deduplicateHeaders(headers []string) []string {
@@ -74,57 +92,8 @@ func deduplicateHeaders(headers []string) []string {
 
 // FormatMarkdown formats query results as a GitHub Flavored Markdown table.
 func FormatMarkdown(headers []string, rows [][]string) string {
-	escape := func(s string) string {
-		s = strings.ReplaceAll(s, "\r\n", " ")
-		s = strings.ReplaceAll(s, "\n", " ")
-		s = strings.ReplaceAll(s, "\r", " ")
-		return strings.ReplaceAll(s, "|", "\\|")
-	}
-
 	var b strings.Builder
-
-	// Header row
-	b.WriteByte('|')
-	for _, h := range headers {
-		b.WriteByte(' ')
-		b.WriteString(escape(h))
-		b.WriteString(" |")
-	}
-	b.WriteByte('\n')
-
-	// Separator row
-	b.WriteByte('|')
-	for range headers {
-		b.WriteString(" --- |")
-	}
-	b.WriteByte('\n')
-
-	// Data rows
-	for _, row := range rows {
-		b.WriteByte('|')
-		for i := range headers {
-			b.WriteByte(' ')
-			if i < len(row) {
-				b.WriteString(escape(row[i]))
-			}
-			b.WriteString(" |")
-		}
-		b.WriteByte('\n')
-	}
-
+	// Writing to a strings.Builder cannot fail.
+	_ = Write(&b, Markdown, headers, rows, Options{})
 	return b.String()
 }
-
-// SaveCSVFile writes query results to a CSV file with a timestamped name
-// in the current directory. Returns the filename.
-func SaveCSVFile(headers []string, rows [][]string) (string, error) {
-	content, err := FormatCSV(headers, rows)
-	if err != nil {
-		return "", err
-	}
-	filename := fmt.Sprintf("result_%s.csv", time.Now().Format("20060102_150405.000"))
-	if err := os.WriteFile(filename, []byte(content), 0600); err != nil {
-		return "", fmt.Errorf("writing file %s: %w", filename, err)
-	}
-	return filename, nil
-}
@@ -2,8 +2,6 @@ package export
 
 import (
 	"encoding/json"
-	"os"
-	"path/filepath"
 	"strings"
 	"testing"
 )
@@ -92,7 +90,7 @@ func TestFormatJSON(t *testing.T) {
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			got, err := FormatJSON(tt.headers, tt.rows)
+			got, err := FormatJSON(tt.headers, tt.rows, Options{})
 			if err != nil {
 				t.Fatalf("unexpected error: %v", err)
 			}
@@ -172,33 +170,51 @@ func TestFormatMarkdown(t *testing.T) {
 	}
 }
 
-func TestSaveCSVFile(t *testing.T) {
-	// Use temp dir to avoid polluting working directory
-	tmpDir := t.TempDir()
-	origDir, err := os.Getwd()
-	if err != nil {
-		t.Fatal(err)
-	}
-	if err := os.Chdir(tmpDir); err != nil {
-		t.Fatal(err)
-	}
-	t.Cleanup(func() { os.Chdir(origDir) })
-
-	filename, err := SaveCSVFile(testHeaders, testRows)
+func TestFormatTSV(t *testing.T) {
+	got, err := FormatTSV(testHeaders, testRows)
 	if err != nil {
 		t.Fatalf("unexpected error: %v", err)
 	}
-
-	if !strings.HasPrefix(filename, "result_") || !strings.HasSuffix(filename, ".csv") {
-		t.Errorf("unexpected filename format: %s", filename)
+	want := "id\tname\temail\n1\tAlice\talice@example.com\n2\tBob\tbob@example.com\n"
+	if got != want {
+		t.Errorf("got:\n%s\nwant:\n%s", got, want)
 	}
+}
 
-	content, err := os.ReadFile(filepath.Join(tmpDir, filename))
-	if err != nil {
-		t.Fatalf("reading saved file: %v", err)
+func TestFormatINList(t *testing.T) {
+	tests := []struct {
+		name    string
+		cells   []string
+		types   []string
+		dialect string
+		want    string
+	}{
+		{
+			name:  "numbers",
+			cells: []string{"3", "1", "3"},
+			types: []string{"INTEGER", "INTEGER", "INTEGER"},
+			want:  "(3, 1)",
+		},
+		{
+			name:    "strings skip NULL",
+			cells:   []string{"it's", "NULL", `""`},
+			types:   []string{"TEXT", "TEXT", "TEXT"},
+			dialect: "postgres",
+			want:    "('it''s', '')",
+		},
+		{
+			name:    "mixed columns",
+			cells:   []string{"7", `a\b`},
+			types:   []string{"INT"},
+			dialect: "mysql",
+			want:    `(7, 'a\\b')`,
+		},
 	}
-
-	if !strings.Contains(string(content), "id,name,email") {
-		t.Errorf("file content missing headers: %s", string(content))
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := FormatINList(tt.cells, tt.types, tt.dialect); got != tt.want {
+				t.Errorf("got %s, want %s", got, tt.want)
+			}
+		})
 	}
 }
@@ -0,0 +1,142 @@
+package export
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+// jsonRowWriter writes an array of objects (or one object per line when
+// lines is set). Keys keep the column order of the result. With
+// Options.JSONArrays the header and each row are written as arrays instead.
+type jsonRowWriter struct {
+	w     *bufio.Writer
+	opts  Options
+	lines bool
+	keys  []string
+	count int
+}
+
+func (j *jsonRowWriter) WriteHeader(headers []string) error {
+	j.keys = make([]string, len(headers))
+	for i, k := range deduplicateHeaders(headers) {
+		b, err := json.Marshal(k)
+		if err != nil {
+			return fmt.Errorf("marshaling JSON key: %w", err)
+		}
+		j.keys[i] = string(b)
+	}
+	if !j.lines {
+		if _, err := j.w.WriteString("["); err != nil {
+			return err
+		}
+	}
+	if j.opts.JSONArrays {
+		return j.writeRecord("[" + strings.Join(j.keys, ",") + "]")
+	}
+	return nil
+}
+
+func (j *jsonRowWriter) WriteRow(row []string, nulls []bool) error {
+	var b strings.Builder
+	start, end := "{", "}"
+	if j.opts.JSONArrays {
+		start, end = "[", "]"
+	}
+	b.WriteString(start)
+	for i, key := range j.keys {
+		if i > 0 {
+			b.WriteByte(',')
+		}
+		if !j.opts.JSONArrays {
+			b.WriteString(key)
+			b.WriteByte(':')
+		}
+		val := ""
+		if i < len(row) {
+			val = row[i]
+		}
+		colType := ""
+		if i < len(j.opts.ColumnTypes) {
+			colType = j.opts.ColumnTypes[i]
+		}
+		v, err := JSONValue(val, colType, isNull(nulls, i, val))
+		if err != nil {
+			return fmt.Errorf("marshaling JSON value: %w", err)
+		}
+		b.Write(v)
+	}
+	b.WriteString(end)
+	return j.writeRecord(b.String())
+}
+
+// writeRecord writes one top-level record, handling array separators or
+// line breaks.
+func (j *jsonRowWriter) writeRecord(record string) error {
+	var b strings.Builder
+	if j.lines {
+		b.WriteString(record)
+		b.WriteByte('\n')
+	} else {
+		if j.count > 0 {
+			b.WriteByte(',')
+		}
+		b.WriteString("\n  ")
+		b.WriteString(record)
+	}
+	j.count++
+	_, err := j.w.WriteString(b.String())
+	return err
+}
+
+func (j *jsonRowWriter) Close() error {
+	if !j.lines {
+		if j.count > 0 {
+			j.w.WriteString("\n")
+		}
+		j.w.WriteString("]\n")
+	}
+	return j.w.Flush()
+}
+
+// JSONValue encodes a result cell as a JSON value. A NULL cell becomes null,
+// while a text value "NULL" stays a string, and the `""` marker becomes an
+// empty string. Numeric and boolean columns are written as numbers and
+// booleans, and JSON/JSONB columns are embedded as-is (compacted), whenever
+// the cell parses as such; otherwise the cell is a string.
+func JSONValue(cell, colType string, null bool) ([]byte, error) {
+	if null {
+		return []byte("null"), nil
+	}
+	if cell == `""` {
+		return []byte(`""`), nil
+	}
+	switch {
+	case isNumericType(colType):
+		if isJSONNumber(cell) {
+			return []byte(cell), nil
+		}
+	case isBooleanType(colType):
+		if b, ok := parseBool(cell); ok {
+			return json.Marshal(b)
+		}
+	case isJSONType(colType):
+		var buf bytes.Buffer
+		if err := json.Compact(&buf, []byte(cell)); err == nil {
+			return buf.Bytes(), nil
+		}
+	}
+	return json.Marshal(cell)
+}
+
+// isJSONNumber reports whether s is valid JSON number syntax (no leading
+// zeros, plus sign or bare decimal point).
+func isJSONNumber(s string) bool {
+	if !isSQLNumber(s) {
+		return false
+	}
+	var n json.Number
+	return json.Unmarshal([]byte(s), &n) == nil
+}
@@ -0,0 +1,117 @@
+package export
+
+import (
+	"bytes"
+	"encoding/json"
+	"testing"
+)
+
+func TestJSONValue(t *testing.T) {
+	tests := []struct {
+		cell, colType, want string
+	}{
+		{"NULL", "TEXT", `null`},
+		{"NULL", "", `null`},
+		{`""`, "TEXT", `""`},
+		{"42", "INTEGER", `42`},
+		{"-1.5e3", "float8", `-1.5e3`},
+		{"12.50", "NUMERIC", `12.50`},
+		{"007", "INTEGER", `"007"`},
+		{"NaN", "float8", `"NaN"`},
+		{"42", "TEXT", `"42"`},
+		{"42", "", `"42"`},
+		{"t", "bool", `true`},
+		{"0", "BOOLEAN", `false`},
+		{"maybe", "bool", `"maybe"`},
+		{`{"a": [1, 2]}`, "jsonb", `{"a":[1,2]}`},
+		{`[1,2]`, "JSON", `[1,2]`},
+		{`not json`, "json", `"not json"`},
+		{`{"a":1}`, "TEXT", `"{\"a\":1}"`},
+	}
+	for _, tt := range tests {
+		got, err := JSONValue(tt.cell, tt.colType, tt.cell == "NULL")
+		if err != nil {
+			t.Fatalf("JSONValue(%q, %q): %v", tt.cell, tt.colType, err)
+		}
+		if string(got) != tt.want {
+			t.Errorf("JSONValue(%q, %q) = %s, want %s", tt.cell, tt.colType, got, tt.want)
+		}
+	}
+}
+
+func TestWrite_JSONTyped(t *testing.T) {
+	headers := []string{"id", "active", "meta", "name"}
+	types := []string{"INT4", "BOOL", "JSONB", "TEXT"}
+	rows := [][]string{
+		{"1", "true", `{"k": "v"}`, "alice"},
+		{"2", "NULL", "NULL", `""`},
+	}
+
+	var buf bytes.Buffer
+	if err := Write(&buf, JSON, headers, rows, Options{ColumnTypes: types}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	want := "[\n" +
+		`  {"id":1,"active":true,"meta":{"k":"v"},"name":"alice"},` + "\n" +
+		`  {"id":2,"active":null,"meta":null,"name":""}` + "\n]\n"
+	if got := buf.String(); got != want {
+		t.Errorf("got:\n%s\nwant:\n%s", got, want)
+	}
+	var decoded []map[string]any
+	if err := json.Unmarshal(buf.Bytes(), &decoded); err != nil {
+		t.Fatalf("invalid JSON: %v", err)
+	}
+}
+
+func TestWrite_NullFlags(t *testing.T) {
+	headers := []string{"id", "name"}
+	rows := [][]string{{"1", "NULL"}, {"2", "NULL"}}
+	opts := Options{
+		ColumnTypes: []string{"INTEGER", "TEXT"},
+		Nulls:       [][]bool{{false, false}, {false, true}},
+		Dialect:     "postgres",
+	}
+
+	got, err := FormatJSON(headers, rows, opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := "[\n  {\"id\":1,\"name\":\"NULL\"},\n  {\"id\":2,\"name\":null}\n]\n"; got != want {
+		t.Errorf("JSON:\n%s\nwant:\n%s", got, want)
+	}
+
+	var buf bytes.Buffer
+	if err := Write(&buf, SQL, headers, rows, opts); err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{"(1, 'NULL');", "(2, NULL);"} {
+		if !bytes.Contains(buf.Bytes(), []byte(want)) {
+			t.Errorf("SQL missing %s:\n%s", want, buf.String())
+		}
+	}
+}
+
+func TestWrite_JSONArrays(t *testing.T) {
+	headers := []string{"id", "name"}
+	rows := [][]string{{"1", "a"}, {"2", "NULL"}}
+	opts := Options{ColumnTypes: []string{"INTEGER", "TEXT"}, JSONArrays: true}
+
+	tests := []struct {
+		format Format
+		want   string
+	}{
+		{JSON, "[\n  [\"id\",\"name\"],\n  [1,\"a\"],\n  [2,null]\n]\n"},
+		{JSONLines, "[\"id\",\"name\"]\n[1,\"a\"]\n[2,null]\n"},
+	}
+	for _, tt := range tests {
+		t.Run(string(tt.format), func(t *testing.T) {
+			var buf bytes.Buffer
+			if err := Write(&buf, tt.format, headers, rows, opts); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if got := buf.String(); got != tt.want {
+				t.Errorf("got:\n%s\nwant:\n%s", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,177 @@
+package export
+
+import (
+	"bufio"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+func (o Options) table() string {
+	if o.Table == "" {
+		return "result"
+	}
+	return o.Table
+}
+
+func (o Options) quoteIdent(name string) string {
+	if o.QuoteIdent != nil {
+		return o.QuoteIdent(name)
+	}
+	return `"` + strings.ReplaceAll(name, `"`, `""`) + `"`
+}
+
+// quoteTable quotes each dot-separated part of a possibly schema-qualified
+// table name.
+func (o Options) quoteTable() string {
+	parts := strings.Split(o.table(), ".")
+	for i, p := range parts {
+		parts[i] = o.quoteIdent(p)
+	}
+	return strings.Join(parts, ".")
+}
+
+// sqlRowWriter emits one INSERT (or upsert) statement per row.
+type sqlRowWriter struct {
+	w      *bufio.Writer
+	opts   Options
+	prefix string // "INSERT INTO t (a, b) VALUES "
+	suffix string // upsert clause, including the leading space
+	ncols  int
+}
+
+func (s *sqlRowWriter) WriteHeader(headers []string) error {
+	s.ncols = len(headers)
+	cols := make([]string, len(headers))
+	for i, h := range headers {
+		cols[i] = s.opts.quoteIdent(h)
+	}
+	s.prefix = fmt.Sprintf("INSERT INTO %s (%s) VALUES ", s.opts.quoteTable(), strings.Join(cols, ", "))
+	if s.opts.Upsert {
+		suffix, err := upsertClause(s.opts, headers)
+		if err != nil {
+			return err
+		}
+		s.suffix = suffix
+	}
+	return nil
+}
+
+func (s *sqlRowWriter) WriteRow(row []string, nulls []bool) error {
+	vals := make([]string, s.ncols)
+	for i, cell := range padRow(row, s.ncols) {
+		colType := ""
+		if i < len(s.opts.ColumnTypes) {
+			colType = s.opts.ColumnTypes[i]
+		}
+		switch {
+		case isNull(nulls, i, cell):
+			vals[i] = "NULL"
+		case cell == "NULL":
+			// A text value that reads "NULL".
+			vals[i] = quoteString(cell, s.opts.Dialect)
+		default:
+			vals[i] = SQLLiteral(cell, colType, s.opts.Dialect)
+		}
+	}
+	_, err := s.w.WriteString(s.prefix + "(" + strings.Join(vals, ", ") + ")" + s.suffix + ";\n")
+	return err
+}
+
+func (s *sqlRowWriter) Close() error {
+	return s.w.Flush()
+}
+
+// upsertClause builds the dialect-specific conflict clause that updates
+// every non-key column with the incoming value.
+func upsertClause(opts Options, headers []string) (string, error) {
+	keys := make(map[string]bool, len(opts.KeyColumns))
+	for _, k := range opts.KeyColumns {
+		keys[k] = true
+	}
+
+	var sets []string
+	for _, h := range headers {
+		if keys[h] {
+			continue
+		}
+		q := opts.quoteIdent(h)
+		if opts.Dialect == "mysql" {
+			sets = append(sets, fmt.Sprintf("%s = VALUES(%s)", q, q))
+		} else {
+			sets = append(sets, fmt.Sprintf("%s = excluded.%s", q, q))
+		}
+	}
+
+	if opts.Dialect == "mysql" {
+		// MySQL resolves conflicts against any unique key; no target list.
+		if len(sets) == 0 {
+			first := opts.quoteIdent(headers[0])
+			sets = []string{fmt.Sprintf("%s = %s", first, first)}
+		}
+		return " ON DUPLICATE KEY UPDATE " + strings.Join(sets, ", "), nil
+	}
+
+	if len(opts.KeyColumns) == 0 {
+		return "", fmt.Errorf("upsert requires at least one key column")
+	}
+	target := make([]string, len(opts.KeyColumns))
+	for i, k := range opts.KeyColumns {
+		target[i] = opts.quoteIdent(k)
+	}
+	if len(sets) == 0 {
+		return fmt.Sprintf(" ON CONFLICT (%s) DO NOTHING", strings.Join(target, ", ")), nil
+	}
+	return fmt.Sprintf(" ON CONFLICT (%s) DO UPDATE SET %s", strings.Join(target, ", "), strings.Join(sets, ", ")), nil
+}
+
+// SQLLiteral renders a result cell as a SQL literal for the given dialect.
+// The "NULL" cell becomes a real NULL and the `""` empty-string marker an
+// empty string. Values of numeric and boolean columns are written unquoted
+// when they parse as such; everything else is a quoted string literal.
+func SQLLiteral(cell, colType, dialect string) string {
+	switch cell {
+	case "NULL":
+		return "NULL"
+	case `""`:
+		return "''"
+	}
+	switch {
+	case isNumericType(colType):
+		if isSQLNumber(cell) {
+			return cell
+		}
+	case isBooleanType(colType):
+		if b, ok := parseBool(cell); ok {
+			if b {
+				return "TRUE"
+			}
+			return "FALSE"
+		}
+	}
+	return quoteString(cell, dialect)
+}
+
+// isSQLNumber reports whether s is a plain decimal literal (optionally signed,
+// with fraction and exponent) that every dialect accepts unquoted.
+func isSQLNumber(s string) bool {
+	if _, err := strconv.ParseFloat(s, 64); err != nil {
+		return false
+	}
+	for _, r := range strings.TrimLeft(s, "+-") {
+		if (r < '0' || r > '9') && r != '.' && r != 'e' && r != 'E' && r != '+' && r != '-' {
+			return false
+		}
+	}
+	return true
+}
+
+// quoteString quotes s as a string literal. MySQL treats backslash as an
+// escape character by default, so it is doubled there as well.
+func quoteString(s, dialect string) string {
+	s = strings.ReplaceAll(s, "'", "''")
+	if dialect == "mysql" {
+		s = strings.ReplaceAll(s, `\`, `\\`)
+	}
+	return "'" + s + "'"
+}
@@ -0,0 +1,94 @@
+package export
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func TestSQLLiteral(t *testing.T) {
+	tests := []struct {
+		cell, colType, dialect, want string
+	}{
+		{"NULL", "INTEGER", "sqlite", "NULL"},
+		{`""`, "TEXT", "sqlite", "''"},
+		{"42", "INTEGER", "sqlite", "42"},
+		{"-1.5e3", "double precision", "postgres", "-1.5e3"},
+		{"12.50", "DECIMAL(10,2)", "mysql", "12.50"},
+		{"42", "int4", "postgres", "42"},
+		{"42", "TEXT", "sqlite", "'42'"},
+		{"42", "", "sqlite", "'42'"},
+		{"NaN", "float8", "postgres", "'NaN'"},
+		{"0x10", "INTEGER", "sqlite", "'0x10'"},
+		{"t", "bool", "postgres", "TRUE"},
+		{"false", "BOOLEAN", "sqlite", "FALSE"},
+		{"maybe", "bool", "postgres", "'maybe'"},
+		{"O'Brien", "TEXT", "postgres", "'O''Brien'"},
+		{`a\b`, "TEXT", "postgres", `'a\b'`},
+		{`a\b`, "VARCHAR", "mysql", `'a\\b'`},
+	}
+	for _, tt := range tests {
+		if got := SQLLiteral(tt.cell, tt.colType, tt.dialect); got != tt.want {
+			t.Errorf("SQLLiteral(%q, %q, %q) = %s, want %s", tt.cell, tt.colType, tt.dialect, got, tt.want)
+		}
+	}
+}
+
+func TestWrite_SQLSchemaQualifiedTable(t *testing.T) {
+	var buf bytes.Buffer
+	opts := Options{Table: "public.users", ColumnTypes: []string{"int4"}}
+	if err := Write(&buf, SQL, []string{"id"}, [][]string{{"1"}}, opts); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	want := "INSERT INTO \"public\".\"users\" (\"id\") VALUES (1);\n"
+	if got := buf.String(); got != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+}
+
+func TestWrite_SQLUpsert(t *testing.T) {
+	headers := []string{"id", "name"}
+	rows := [][]string{{"1", "a"}}
+	backtick := func(s string) string { return "`" + s + "`" }
+
+	tests := []struct {
+		name string
+		opts Options
+		want string
+	}{
+		{
+			name: "postgres",
+			opts: Options{Table: "t", Dialect: "postgres", Upsert: true, KeyColumns: []string{"id"}},
+			want: `INSERT INTO "t" ("id", "name") VALUES ('1', 'a') ON CONFLICT ("id") DO UPDATE SET "name" = excluded."name";` + "\n",
+		},
+		{
+			name: "sqlite all keys",
+			opts: Options{Table: "t", Dialect: "sqlite", Upsert: true, KeyColumns: []string{"id", "name"}},
+			want: `INSERT INTO "t" ("id", "name") VALUES ('1', 'a') ON CONFLICT ("id", "name") DO NOTHING;` + "\n",
+		},
+		{
+			name: "mysql",
+			opts: Options{Table: "t", Dialect: "mysql", QuoteIdent: backtick, Upsert: true, KeyColumns: []string{"id"}},
+			want: "INSERT INTO `t` (`id`, `name`) VALUES ('1', 'a') ON DUPLICATE KEY UPDATE `name` = VALUES(`name`);\n",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var buf bytes.Buffer
+			if err := Write(&buf, SQL, headers, rows, tt.opts); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if got := buf.String(); got != tt.want {
+				t.Errorf("got:\n%s\nwant:\n%s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestWrite_SQLUpsertRequiresKeys(t *testing.T) {
+	var buf bytes.Buffer
+	err := Write(&buf, SQL, []string{"id"}, nil, Options{Dialect: "postgres", Upsert: true})
+	if err == nil || !strings.Contains(err.Error(), "key column") {
+		t.Errorf("expected key column error, got %v", err)
+	}
+}
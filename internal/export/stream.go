@@ -0,0 +1,92 @@
+package export
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync/atomic"
+)
+
+// FileSink writes a streamed result to a file. It satisfies db.RowHandler,
+// so rows can flow straight from the database into the file without being
+// buffered. Written may be called from another goroutine to report progress.
+//
+// Rows go to a temporary file in the same directory, which replaces path
+// only when Close succeeds, so a failed or cancelled export leaves an
+// existing file at path as it was.
+type FileSink struct {
+	path    string
+	format  Format
+	opts    Options
+	file    *os.File // the temporary file
+	rw      RowWriter
+	written atomic.Int64
+}
+
+// NewFileSink returns a sink that encodes rows in format f and saves them
+// to path on Close. The row writer is created once the header is known, so
+// column types reported by the driver are used when opts has none.
+func NewFileSink(path string, f Format, opts Options) (*FileSink, error) {
+	file, err := os.CreateTemp(filepath.Dir(path), ".tmp-*")
+	if err != nil {
+		return nil, fmt.Errorf("creating file %s: %w", path, err)
+	}
+	if err := file.Chmod(0600); err != nil {
+		file.Close()
+		os.Remove(file.Name())
+		return nil, fmt.Errorf("creating file %s: %w", path, err)
+	}
+	return &FileSink{path: path, format: f, opts: opts, file: file}, nil
+}
+
+func (s *FileSink) Header(columns, columnTypes []string) error {
+	opts := s.opts
+	if opts.ColumnTypes == nil {
+		opts.ColumnTypes = columnTypes
+	}
+	rw, err := NewRowWriter(s.file, s.format, opts)
+	if err != nil {
+		return err
+	}
+	s.rw = rw
+	if err := rw.WriteHeader(columns); err != nil {
+		return fmt.Errorf("writing file %s: %w", s.path, err)
+	}
+	return nil
+}
+
+func (s *FileSink) Row(row []string, nulls []bool) error {
+	if err := s.rw.WriteRow(row, nulls); err != nil {
+		return fmt.Errorf("writing file %s: %w", s.path, err)
+	}
+	s.written.Add(1)
+	return nil
+}
+
+// Written returns the number of rows written so far.
+func (s *FileSink) Written() int64 {
+	return s.written.Load()
+}
+
+// Close flushes pending output and moves the file into place. On error
+// the caller still has to Abort.
+func (s *FileSink) Close() error {
+	if s.rw != nil {
+		if err := s.rw.Close(); err != nil {
+			return fmt.Errorf("writing file %s: %w", s.path, err)
+		}
+	}
+	if err := s.file.Close(); err != nil {
+		return fmt.Errorf("closing file %s: %w", s.path, err)
+	}
+	if err := os.Rename(s.file.Name(), s.path); err != nil {
+		return fmt.Errorf("saving file %s: %w", s.path, err)
+	}
+	return nil
+}
+
+// Abort discards the partially written temporary file; path is untouched.
+func (s *FileSink) Abort() {
+	s.file.Close()
+	os.Remove(s.file.Name())
+}
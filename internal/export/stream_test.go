@@ -0,0 +1,69 @@
+package export
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestFileSink(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "out.jsonl")
+	s, err := NewFileSink(path, JSONLines, Options{})
+	if err != nil {
+		t.Fatalf("NewFileSink: %v", err)
+	}
+	if err := s.Header([]string{"id", "name"}, []string{"INTEGER", "TEXT"}); err != nil {
+		t.Fatalf("Header: %v", err)
+	}
+	for _, row := range [][]string{{"1", "a"}, {"2", "NULL"}} {
+		if err := s.Row(row, nil); err != nil {
+			t.Fatalf("Row: %v", err)
+		}
+	}
+	if got := s.Written(); got != 2 {
+		t.Errorf("Written() = %d, want 2", got)
+	}
+	if err := s.Close(); err != nil {
+		t.Fatalf("Close: %v", err)
+	}
+
+	info, err := os.Stat(path)
+	if err != nil || info.Mode().Perm() != 0o600 {
+		t.Fatalf("stat = %v, %v", info, err)
+	}
+	got, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Driver column types are used for typed JSON values.
+	want := "{\"id\":1,\"name\":\"a\"}\n{\"id\":2,\"name\":null}\n"
+	if string(got) != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+}
+
+func TestFileSink_Abort(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "out.csv")
+	if err := os.WriteFile(path, []byte("old export\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	s, err := NewFileSink(path, CSV, Options{})
+	if err != nil {
+		t.Fatalf("NewFileSink: %v", err)
+	}
+	s.Header([]string{"id"}, nil)
+	s.Row([]string{"1"}, nil)
+
+	// Until Close, the existing file is left alone.
+	if got, _ := os.ReadFile(path); string(got) != "old export\n" {
+		t.Errorf("existing file changed while streaming: %q", got)
+	}
+	s.Abort()
+	if got, _ := os.ReadFile(path); string(got) != "old export\n" {
+		t.Errorf("existing file changed by Abort: %q", got)
+	}
+	if entries, _ := os.ReadDir(dir); len(entries) != 1 {
+		t.Errorf("expected the temporary file to be removed, got %v", entries)
+	}
+}
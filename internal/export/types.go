@@ -0,0 +1,52 @@
+package export
+
+import "strings"
+
+// numericTypePrefixes lists type name prefixes that denote numeric columns.
+var numericTypePrefixes = []string{
+	"int", "integer", "bigint", "smallint", "tinyint", "mediumint",
+	"unsigned", "real", "float", "double", "decimal", "numeric", "number",
+	"serial", "bigserial", "smallserial",
+}
+
+func isNumericType(colType string) bool {
+	lower := strings.ToLower(strings.TrimSpace(colType))
+	if lower == "" {
+		return false
+	}
+	// INT4, FLOAT8, DECIMAL(10,2) ...
+	lower = strings.TrimRight(strings.SplitN(lower, "(", 2)[0], "0123456789 ")
+	for _, p := range numericTypePrefixes {
+		if lower == p || strings.HasPrefix(lower, p+" ") {
+			return true
+		}
+	}
+	return false
+}
+
+func isBooleanType(colType string) bool {
+	switch strings.ToLower(colType) {
+	case "bool", "boolean":
+		return true
+	}
+	return false
+}
+
+func isJSONType(colType string) bool {
+	switch strings.ToLower(colType) {
+	case "json", "jsonb":
+		return true
+	}
+	return false
+}
+
+// parseBool accepts the boolean spellings the supported drivers produce.
+func parseBool(cell string) (value, ok bool) {
+	switch strings.ToLower(cell) {
+	case "true", "t", "1":
+		return true, true
+	case "false", "f", "0":
+		return false, true
+	}
+	return false, false
+}
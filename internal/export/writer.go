@@ -0,0 +1,298 @@
+package export
+
+import (
+	"bufio"
+	"encoding/csv"
+	"fmt"
+	"html"
+	"io"
+	"strings"
+	"time"
+)
+
+// Format identifies a file export format.
+type Format string
+
+const (
+	CSV       Format = "csv"
+	TSV       Format = "tsv"
+	JSON      Format = "json"
+	JSONLines Format = "jsonl"
+	Markdown  Format = "md"
+	HTML      Format = "html"
+	SQL       Format = "sql"
+	XLSX      Format = "xlsx"
+)
+
+// Formats lists every supported file format in menu order.
+var Formats = []Format{CSV, TSV, JSON, JSONLines, Markdown, HTML, SQL, XLSX}
+
+// Extension returns the file extension (without dot) for the format.
+func (f Format) Extension() string {
+	return string(f)
+}
+
+// Label returns a human-readable name for the format.
+func (f Format) Label() string {
+	switch f {
+	case CSV:
+		return "CSV"
+	case TSV:
+		return "TSV"
+	case JSON:
+		return "JSON"
+	case JSONLines:
+		return "JSON Lines"
+	case Markdown:
+		return "Markdown"
+	case HTML:
+		return "HTML table"
+	case SQL:
+		return "SQL (INSERT/UPSERT)"
+	case XLSX:
+		return "Excel (XLSX)"
+	default:
+		return string(f)
+	}
+}
+
+// Options carries format-specific settings. The zero value is usable.
+type Options struct {
+	// Shared by SQL and JSON output
+	ColumnTypes []string // database type names, used to type SQL literals and JSON values
+	// Nulls flags the NULL cells of the rows given to Write, SaveFile and
+	// FormatJSON (see db.QueryResult.Nulls). Without it, cells reading
+	// "NULL" are NULL.
+	Nulls [][]bool
+
+	// JSON output
+	JSONArrays bool // emit a header array followed by row arrays instead of objects
+
+	// SQL output
+	Table      string              // target table, optionally "schema.table" (default "result")
+	QuoteIdent func(string) string // identifier quoting (default ANSI double quotes)
+	Dialect    string              // "sqlite", "mysql" or "postgres"; controls literal escaping and upsert syntax
+	Upsert     bool                // append ON CONFLICT / ON DUPLICATE KEY UPDATE
+	KeyColumns []string            // conflict target for Upsert (Postgres/SQLite)
+}
+
+// RowWriter streams a table to an underlying writer one row at a time.
+// WriteHeader must be called exactly once before any WriteRow, and Close
+// must be called to flush trailing output. Close does not close the
+// underlying writer. nulls flags the NULL cells of row; when it is nil,
+// cells reading "NULL" are NULL.
+type RowWriter interface {
+	WriteHeader(headers []string) error
+	WriteRow(row []string, nulls []bool) error
+	Close() error
+}
+
+// NewRowWriter returns a RowWriter that encodes rows in the given format.
+func NewRowWriter(w io.Writer, f Format, opts Options) (RowWriter, error) {
+	switch f {
+	case CSV:
+		return &csvRowWriter{w: csv.NewWriter(w)}, nil
+	case TSV:
+		cw := csv.NewWriter(w)
+		cw.Comma = '\t'
+		return &csvRowWriter{w: cw}, nil
+	case JSON:
+		return &jsonRowWriter{w: bufio.NewWriter(w), opts: opts}, nil
+	case JSONLines:
+		return &jsonRowWriter{w: bufio.NewWriter(w), opts: opts, lines: true}, nil
+	case Markdown:
+		return &markdownRowWriter{w: bufio.NewWriter(w)}, nil
+	case HTML:
+		return &htmlRowWriter{w: bufio.NewWriter(w)}, nil
+	case SQL:
+		return &sqlRowWriter{w: bufio.NewWriter(w), opts: opts}, nil
+	case XLSX:
+		return newXLSXRowWriter(w), nil
+	default:
+		return nil, fmt.Errorf("unsupported export format %q", f)
+	}
+}
+
+// Write encodes headers and rows to w in the given format.
+func Write(w io.Writer, f Format, headers []string, rows [][]string, opts Options) error {
+	rw, err := NewRowWriter(w, f, opts)
+	if err != nil {
+		return err
+	}
+	if err := rw.WriteHeader(headers); err != nil {
+		return err
+	}
+	for i, row := range rows {
+		if err := rw.WriteRow(row, rowNulls(opts.Nulls, i)); err != nil {
+			return err
+		}
+	}
+	return rw.Close()
+}
+
+// SaveFile writes headers and rows to path in the given format. Like a
+// streamed export, it goes through a temporary file, so that an existing
+// file at path is only replaced once the new one is complete.
+func SaveFile(path string, f Format, headers []string, rows [][]string, opts Options) error {
+	s, err := NewFileSink(path, f, opts)
+	if err != nil {
+		return err
+	}
+	err = s.Header(headers, opts.ColumnTypes)
+	for i, row := range rows {
+		if err != nil {
+			break
+		}
+		err = s.Row(row, rowNulls(opts.Nulls, i))
+	}
+	if err == nil {
+		err = s.Close()
+	}
+	if err != nil {
+		s.Abort()
+		return err
+	}
+	return nil
+}
+
+// DefaultFilename returns a timestamped file name for the given format,
+// e.g. "result_20240102_150405.000.csv".
+func DefaultFilename(f Format, now time.Time) string {
+	return fmt.Sprintf("result_%s.%s", now.Format("20060102_150405.000"), f.Extension())
+}
+
+// rowNulls returns the NULL flags of row i, or nil when there are none.
+func rowNulls(nulls [][]bool, i int) []bool {
+	if i < len(nulls) {
+		return nulls[i]
+	}
+	return nil
+}
+
+// isNull reports whether cell i of a row is NULL: as flagged by nulls, or
+// by reading "NULL" when there are no flags.
+func isNull(nulls []bool, i int, cell string) bool {
+	if nulls == nil {
+		return cell == "NULL"
+	}
+	return i < len(nulls) && nulls[i]
+}
+
+// padRow returns row extended (or trimmed) to exactly n cells.
+func padRow(row []string, n int) []string {
+	if len(row) == n {
+		return row
+	}
+	out := make([]string, n)
+	copy(out, row)
+	return out
+}
+
+type csvRowWriter struct {
+	w *csv.Writer
+}
+
+func (c *csvRowWriter) WriteHeader(headers []string) error {
+	if err := c.w.Write(headers); err != nil {
+		return fmt.Errorf("writing header: %w", err)
+	}
+	return nil
+}
+
+func (c *csvRowWriter) WriteRow(row []string, _ []bool) error {
+	if err := c.w.Write(row); err != nil {
+		return fmt.Errorf("writing row: %w", err)
+	}
+	return nil
+}
+
+func (c *csvRowWriter) Close() error {
+	c.w.Flush()
+	return c.w.Error()
+}
+
+type markdownRowWriter struct {
+	w     *bufio.Writer
+	ncols int
+}
+
+func escapeMarkdownCell(s string) string {
+	s = strings.ReplaceAll(s, "\r\n", " ")
+	s = strings.ReplaceAll(s, "\n", " ")
+	s = strings.ReplaceAll(s, "\r", " ")
+	return strings.ReplaceAll(s, "|", "\\|")
+}
+
+func (md *markdownRowWriter) WriteHeader(headers []string) error {
+	md.ncols = len(headers)
+	var b strings.Builder
+	b.WriteByte('|')
+	for _, h := range headers {
+		b.WriteByte(' ')
+		b.WriteString(escapeMarkdownCell(h))
+		b.WriteString(" |")
+	}
+	b.WriteString("\n|")
+	for range headers {
+		b.WriteString(" --- |")
+	}
+	b.WriteByte('\n')
+	_, err := md.w.WriteString(b.String())
+	return err
+}
+
+func (md *markdownRowWriter) WriteRow(row []string, _ []bool) error {
+	var b strings.Builder
+	b.WriteByte('|')
+	for i := 0; i < md.ncols; i++ {
+		b.WriteByte(' ')
+		if i < len(row) {
+			b.WriteString(escapeMarkdownCell(row[i]))
+		}
+		b.WriteString(" |")
+	}
+	b.WriteByte('\n')
+	_, err := md.w.WriteString(b.String())
+	return err
+}
+
+func (md *markdownRowWriter) Close() error {
+	return md.w.Flush()
+}
+
+type htmlRowWriter struct {
+	w     *bufio.Writer
+	ncols int
+}
+
+func (h *htmlRowWriter) WriteHeader(headers []string) error {
+	h.ncols = len(headers)
+	var b strings.Builder
+	b.WriteString("<table>\n  <thead>\n    <tr>")
+	for _, col := range headers {
+		b.WriteString("<th>")
+		b.WriteString(html.EscapeString(col))
+		b.WriteString("</th>")
+	}
+	b.WriteString("</tr>\n  </thead>\n  <tbody>\n")
+	_, err := h.w.WriteString(b.String())
+	return err
+}
+
+func (h *htmlRowWriter) WriteRow(row []string, _ []bool) error {
+	var b strings.Builder
+	b.WriteString("    <tr>")
+	for _, cell := range padRow(row, h.ncols) {
+		b.WriteString("<td>")
+		b.WriteString(html.EscapeString(cell))
+		b.WriteString("</td>")
+	}
+	b.WriteString("</tr>\n")
+	_, err := h.w.WriteString(b.String())
+	return err
+}
+
+func (h *htmlRowWriter) Close() error {
+	h.w.WriteString("  </tbody>\n</table>\n")
+	return h.w.Flush()
+}
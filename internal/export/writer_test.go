@@ -0,0 +1,217 @@
+package export
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/json"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestWrite_Formats(t *testing.T) {
+	headers := []string{"id", "name"}
+	rows := [][]string{{"1", "O'Brien"}, {"2", "NULL"}, {"3", `""`}}
+
+	tests := []struct {
+		format Format
+		want   string
+	}{
+		{
+			format: CSV,
+			want:   "id,name\n1,O'Brien\n2,NULL\n3,\"\"\"\"\"\"\n",
+		},
+		{
+			format: TSV,
+			want:   "id\tname\n1\tO'Brien\n2\tNULL\n3\t\"\"\"\"\"\"\n",
+		},
+		{
+			format: JSONLines,
+			want:   "{\"id\":\"1\",\"name\":\"O'Brien\"}\n{\"id\":\"2\",\"name\":null}\n{\"id\":\"3\",\"name\":\"\"}\n",
+		},
+		{
+			format: Markdown,
+			want:   "| id | name |\n| --- | --- |\n| 1 | O'Brien |\n| 2 | NULL |\n| 3 | \"\" |\n",
+		},
+		{
+			format: SQL,
+			want: "INSERT INTO \"result\" (\"id\", \"name\") VALUES ('1', 'O''Brien');\n" +
+				"INSERT INTO \"result\" (\"id\", \"name\") VALUES ('2', NULL);\n" +
+				"INSERT INTO \"result\" (\"id\", \"name\") VALUES ('3', '');\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(string(tt.format), func(t *testing.T) {
+			var buf bytes.Buffer
+			if err := Write(&buf, tt.format, headers, rows, Options{}); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if got := buf.String(); got != tt.want {
+				t.Errorf("got:\n%s\nwant:\n%s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestWrite_JSONKeepsColumnOrder(t *testing.T) {
+	var buf bytes.Buffer
+	err := Write(&buf, JSON, []string{"z", "a", "z"}, [][]string{{"1", "2", "3"}}, Options{})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	got := buf.String()
+	if !strings.Contains(got, `{"z_1":"1","a":"2","z_2":"3"}`) {
+		t.Errorf("keys not in column order: %s", got)
+	}
+	var records []map[string]string
+	if err := json.Unmarshal(buf.Bytes(), &records); err != nil {
+		t.Fatalf("invalid JSON: %v\n%s", err, got)
+	}
+}
+
+func TestWrite_JSONEmpty(t *testing.T) {
+	var buf bytes.Buffer
+	if err := Write(&buf, JSON, []string{"a"}, nil, Options{}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if got := buf.String(); got != "[]\n" {
+		t.Errorf("got %q, want %q", got, "[]\n")
+	}
+}
+
+func TestWrite_HTMLEscapes(t *testing.T) {
+	var buf bytes.Buffer
+	err := Write(&buf, HTML, []string{"<h>"}, [][]string{{"a & b"}}, Options{})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	got := buf.String()
+	for _, want := range []string{"<th>&lt;h&gt;</th>", "<td>a &amp; b</td>", "</table>"} {
+		if !strings.Contains(got, want) {
+			t.Errorf("missing %q in:\n%s", want, got)
+		}
+	}
+}
+
+func TestWrite_SQLOptions(t *testing.T) {
+	var buf bytes.Buffer
+	opts := Options{
+		Table:      "users",
+		QuoteIdent: func(s string) string { return "`" + s + "`" },
+	}
+	if err := Write(&buf, SQL, []string{"id"}, [][]string{{"7"}}, opts); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	want := "INSERT INTO `users` (`id`) VALUES ('7');\n"
+	if got := buf.String(); got != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+}
+
+func TestWrite_XLSX(t *testing.T) {
+	var buf bytes.Buffer
+	rows := [][]string{{"1", "a<b"}, {"007", "NULL"}}
+	if err := Write(&buf, XLSX, []string{"id", "name"}, rows, Options{}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	zr, err := zip.NewReader(bytes.NewReader(buf.Bytes()), int64(buf.Len()))
+	if err != nil {
+		t.Fatalf("not a zip: %v", err)
+	}
+	var sheet string
+	names := map[string]bool{}
+	for _, f := range zr.File {
+		names[f.Name] = true
+		if f.Name == "xl/worksheets/sheet1.xml" {
+			rc, err := f.Open()
+			if err != nil {
+				t.Fatal(err)
+			}
+			b, _ := io.ReadAll(rc)
+			rc.Close()
+			sheet = string(b)
+		}
+	}
+	for _, n := range []string{"[Content_Types].xml", "_rels/.rels", "xl/workbook.xml", "xl/_rels/workbook.xml.rels", "xl/worksheets/sheet1.xml"} {
+		if !names[n] {
+			t.Errorf("missing part %s", n)
+		}
+	}
+	for _, want := range []string{
+		`<c r="A2"><v>1</v></c>`,
+		`a&lt;b`,
+		`<c r="A3" t="inlineStr"><is><t xml:space="preserve">007</t></is></c>`,
+	} {
+		if !strings.Contains(sheet, want) {
+			t.Errorf("sheet missing %q:\n%s", want, sheet)
+		}
+	}
+	if strings.Contains(sheet, `r="B3"`) {
+		t.Errorf("NULL cell should be left empty:\n%s", sheet)
+	}
+}
+
+func TestXLSXColumnName(t *testing.T) {
+	tests := map[int]string{0: "A", 25: "Z", 26: "AA", 27: "AB", 701: "ZZ", 702: "AAA"}
+	for idx, want := range tests {
+		if got := xlsxColumnName(idx); got != want {
+			t.Errorf("xlsxColumnName(%d) = %q, want %q", idx, got, want)
+		}
+	}
+}
+
+func TestIsPlainNumber(t *testing.T) {
+	tests := map[string]bool{
+		"1": true, "-2.5": true, "0.5": true, "0": true,
+		"007": false, "1e5": false, "NaN": false, "abc": false, "": false,
+		"1234567890123456": false,
+	}
+	for in, want := range tests {
+		if got := isPlainNumber(in); got != want {
+			t.Errorf("isPlainNumber(%q) = %v, want %v", in, got, want)
+		}
+	}
+}
+
+func TestSaveFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "out.tsv")
+	if err := SaveFile(path, TSV, testHeaders, testRows, Options{}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	content, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.HasPrefix(string(content), "id\tname\temail\n") {
+		t.Errorf("unexpected content: %q", content)
+	}
+
+	// A failed save leaves the existing file as it was.
+	if err := SaveFile(path, Format("bogus"), testHeaders, testRows, Options{}); err == nil {
+		t.Fatal("expected an error for an unknown format")
+	}
+	if again, _ := os.ReadFile(path); string(again) != string(content) {
+		t.Errorf("existing file changed: %q", again)
+	}
+	if entries, _ := os.ReadDir(filepath.Dir(path)); len(entries) != 1 {
+		t.Errorf("expected no temporary file left, got %v", entries)
+	}
+}
+
+func TestDefaultFilename(t *testing.T) {
+	now := time.Date(2024, 1, 2, 15, 4, 5, 0, time.UTC)
+	if got := DefaultFilename(XLSX, now); got != "result_20240102_150405.000.xlsx" {
+		t.Errorf("got %q", got)
+	}
+}
+
+func TestNewRowWriter_UnknownFormat(t *testing.T) {
+	if _, err := NewRowWriter(io.Discard, Format("pdf"), Options{}); err == nil {
+		t.Error("expected error for unknown format")
+	}
+}
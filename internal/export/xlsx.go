@@ -0,0 +1,162 @@
+package export
+
+import (
+	"archive/zip"
+	"bufio"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+)
+
+// Minimal SpreadsheetML package parts. Cells are written as inline strings
+// (or plain numbers), so no shared-strings table or styles part is needed.
+const (
+	xlsxContentTypes = `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<Types xmlns="http://schemas.openxmlformats.org/package/2006/content-types">
+<Default Extension="rels" ContentType="application/vnd.openxmlformats-package.relationships+xml"/>
+<Default Extension="xml" ContentType="application/xml"/>
+<Override PartName="/xl/workbook.xml" ContentType="application/vnd.openxmlformats-officedocument.spreadsheetml.sheet.main+xml"/>
+<Override PartName="/xl/worksheets/sheet1.xml" ContentType="application/vnd.openxmlformats-officedocument.spreadsheetml.worksheet+xml"/>
+</Types>`
+	xlsxRootRels = `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships">
+<Relationship Id="rId1" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/officeDocument" Target="xl/workbook.xml"/>
+</Relationships>`
+	xlsxWorkbook = `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<workbook xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main" xmlns:r="http://schemas.openxmlformats.org/officeDocument/2006/relationships">
+<sheets><sheet name="result" sheetId="1" r:id="rId1"/></sheets>
+</workbook>`
+	xlsxWorkbookRels = `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships">
+<Relationship Id="rId1" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/worksheet" Target="worksheets/sheet1.xml"/>
+</Relationships>`
+	xlsxSheetHead = `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<worksheet xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main"><sheetData>`
+	xlsxSheetTail = `</sheetData></worksheet>`
+)
+
+// xlsxRowWriter streams rows into the single worksheet of a minimal XLSX
+// package. The static parts are written up front so the sheet can be the
+// last (and only growing) zip entry.
+type xlsxRowWriter struct {
+	zw    *zip.Writer
+	sheet *bufio.Writer
+	row   int
+	ncols int
+	err   error
+}
+
+func newXLSXRowWriter(w io.Writer) *xlsxRowWriter {
+	x := &xlsxRowWriter{zw: zip.NewWriter(w)}
+	parts := []struct{ name, body string }{
+		{"[Content_Types].xml", xlsxContentTypes},
+		{"_rels/.rels", xlsxRootRels},
+		{"xl/workbook.xml", xlsxWorkbook},
+		{"xl/_rels/workbook.xml.rels", xlsxWorkbookRels},
+	}
+	for _, p := range parts {
+		f, err := x.zw.Create(p.name)
+		if err != nil {
+			x.err = fmt.Errorf("writing %s: %w", p.name, err)
+			return x
+		}
+		if _, err := io.WriteString(f, p.body); err != nil {
+			x.err = fmt.Errorf("writing %s: %w", p.name, err)
+			return x
+		}
+	}
+	f, err := x.zw.Create("xl/worksheets/sheet1.xml")
+	if err != nil {
+		x.err = fmt.Errorf("writing sheet: %w", err)
+		return x
+	}
+	x.sheet = bufio.NewWriter(f)
+	x.sheet.WriteString(xlsxSheetHead)
+	return x
+}
+
+func (x *xlsxRowWriter) WriteHeader(headers []string) error {
+	x.ncols = len(headers)
+	return x.writeCells(headers, nil, false)
+}
+
+func (x *xlsxRowWriter) WriteRow(row []string, nulls []bool) error {
+	return x.writeCells(padRow(row, x.ncols), nulls, true)
+}
+
+func (x *xlsxRowWriter) writeCells(cells []string, nulls []bool, detectNumbers bool) error {
+	if x.err != nil {
+		return x.err
+	}
+	x.row++
+	var b strings.Builder
+	fmt.Fprintf(&b, `<row r="%d">`, x.row)
+	for i, cell := range cells {
+		ref := xlsxColumnName(i) + strconv.Itoa(x.row)
+		switch {
+		case detectNumbers && isNull(nulls, i, cell):
+			// NULL is left as an empty cell.
+			continue
+		case detectNumbers && cell == `""`:
+			fmt.Fprintf(&b, `<c r="%s" t="inlineStr"><is><t></t></is></c>`, ref)
+		case detectNumbers && isPlainNumber(cell):
+			fmt.Fprintf(&b, `<c r="%s"><v>%s</v></c>`, ref, cell)
+		default:
+			fmt.Fprintf(&b, `<c r="%s" t="inlineStr"><is><t xml:space="preserve">`, ref)
+			if err := xml.EscapeText(&b, []byte(cell)); err != nil {
+				return err
+			}
+			b.WriteString(`</t></is></c>`)
+		}
+	}
+	b.WriteString(`</row>`)
+	_, err := x.sheet.WriteString(b.String())
+	return err
+}
+
+func (x *xlsxRowWriter) Close() error {
+	if x.err != nil {
+		x.zw.Close()
+		return x.err
+	}
+	x.sheet.WriteString(xlsxSheetTail)
+	if err := x.sheet.Flush(); err != nil {
+		x.zw.Close()
+		return err
+	}
+	return x.zw.Close()
+}
+
+// xlsxColumnName converts a zero-based column index to a spreadsheet
+// column name (0 → A, 25 → Z, 26 → AA).
+func xlsxColumnName(idx int) string {
+	name := ""
+	for idx >= 0 {
+		name = string(rune('A'+idx%26)) + name
+		idx = idx/26 - 1
+	}
+	return name
+}
+
+// isPlainNumber reports whether s is a decimal number that spreadsheets can
+// store without losing information. Values with leading zeros (zip codes,
+// IDs) and integers longer than 15 digits are kept as text.
+func isPlainNumber(s string) bool {
+	if s == "" {
+		return false
+	}
+	if _, err := strconv.ParseFloat(s, 64); err != nil {
+		return false
+	}
+	digits := strings.TrimPrefix(s, "-")
+	if strings.ContainsAny(digits, "eEinIN+") {
+		return false
+	}
+	if len(digits) > 1 && digits[0] == '0' && digits[1] != '.' {
+		return false
+	}
+	intPart, _, _ := strings.Cut(digits, ".")
+	return len(intPart) <= 15
+}
@@ -10,8 +10,10 @@ import (
 )
 
 type Profile struct {
-	Name string `yaml:"name"`
-	DSN  string `yaml:"dsn"`
+	Name     string `yaml:"name"`
+	DSN      string `yaml:"dsn"`
+	ReadOnly bool   `yaml:"read_only,omitempty"` // disables cell editing on this connection
+	TimeZone string `yaml:"time_zone,omitempty"` // overrides the configured display time zone
 }
 
 func configDir() (string, error) {
@@ -0,0 +1,143 @@
+// Package schemacache keeps a snapshot of a database's tables and columns
+// on disk, so that completion works right after startup without reading
+// the schema again.
+package schemacache
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/kwrkb/asql/internal/db"
+	"github.com/kwrkb/asql/internal/fsutil"
+)
+
+// version is bumped when the file format changes; older files are ignored.
+const version = 1
+
+// Snapshot is the schema of one database as read at Fetched.
+type Snapshot struct {
+	Version int                        `json:"version"`
+	Fetched time.Time                  `json:"fetched"`
+	Tables  []string                   `json:"tables"`
+	Columns map[string][]db.ColumnInfo `json:"columns"` // by table name
+	// Partial is set when reading the columns stopped early, so that some
+	// tables have none in Columns.
+	Partial bool `json:"partial,omitempty"`
+}
+
+func cacheDir() (string, error) {
+	if d := os.Getenv("XDG_CACHE_HOME"); d != "" {
+		return d, nil
+	}
+	return os.UserCacheDir()
+}
+
+// snapshotPath names the snapshot of dsn by its hash, so that credentials
+// in the DSN never reach the file system.
+func snapshotPath(dsn string) (string, error) {
+	dir, err := cacheDir()
+	if err != nil {
+		return "", fmt.Errorf("finding user cache dir: %w", err)
+	}
+	sum := sha256.Sum256([]byte(dsn))
+	return filepath.Join(dir, "asql", "schema", hex.EncodeToString(sum[:16])+".json"), nil
+}
+
+// Load reads the snapshot saved for dsn. ok is false when there is none,
+// or it was written by another version of asql.
+func Load(dsn string) (snap Snapshot, ok bool, err error) {
+	path, err := snapshotPath(dsn)
+	if err != nil {
+		return Snapshot{}, false, err
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return Snapshot{}, false, nil
+		}
+		return Snapshot{}, false, fmt.Errorf("reading schema cache: %w", err)
+	}
+	if err := json.Unmarshal(data, &snap); err != nil {
+		return Snapshot{}, false, fmt.Errorf("parsing schema cache: %w", err)
+	}
+	if snap.Version != version {
+		return Snapshot{}, false, nil
+	}
+	return snap, true, nil
+}
+
+// Save writes snap as the snapshot of dsn.
+func Save(dsn string, snap Snapshot) error {
+	path, err := snapshotPath(dsn)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o700); err != nil {
+		return fmt.Errorf("creating cache dir: %w", err)
+	}
+	snap.Version = version
+	data, err := json.Marshal(snap)
+	if err != nil {
+		return fmt.Errorf("marshaling schema cache: %w", err)
+	}
+	if err := fsutil.AtomicWrite(path, data, 0o600); err != nil {
+		return fmt.Errorf("writing schema cache: %w", err)
+	}
+	return nil
+}
+
+// Fetch reads the tables of adapter and then their columns.
+func Fetch(ctx context.Context, adapter db.DBAdapter) (Snapshot, error) {
+	snap, err := FetchTables(ctx, adapter)
+	if err != nil {
+		return Snapshot{}, err
+	}
+	return FetchColumns(ctx, adapter, snap)
+}
+
+// FetchTables reads the tables of adapter into a snapshot without columns,
+// which FetchColumns adds.
+func FetchTables(ctx context.Context, adapter db.DBAdapter) (Snapshot, error) {
+	tables, err := adapter.Tables(ctx)
+	if err != nil {
+		return Snapshot{}, err
+	}
+	return Snapshot{Version: version, Fetched: time.Now(), Tables: tables, Partial: true}, nil
+}
+
+// FetchColumns reads the columns of every table of snap in one query. If
+// that fails, the tables are read one by one, and a table whose columns
+// cannot be read is left out. When ctx ends first, the snapshot keeps the
+// columns read so far, is marked Partial and comes back with ctx's error.
+func FetchColumns(ctx context.Context, adapter db.DBAdapter, snap Snapshot) (Snapshot, error) {
+	snap.Columns = make(map[string][]db.ColumnInfo, len(snap.Tables))
+	snap.Partial = true
+	all, err := adapter.AllColumnDetails(ctx)
+	if err == nil {
+		for _, t := range snap.Tables {
+			if cols, ok := all[t]; ok {
+				snap.Columns[t] = cols
+			}
+		}
+		snap.Partial = false
+		return snap, nil
+	}
+	for _, t := range snap.Tables {
+		cols, err := adapter.ColumnDetails(ctx, t)
+		if err != nil {
+			if ctx.Err() != nil {
+				return snap, ctx.Err()
+			}
+			continue
+		}
+		snap.Columns[t] = cols
+	}
+	snap.Partial = false
+	return snap, nil
+}
@@ -0,0 +1,207 @@
+package ui
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+
+	"github.com/kwrkb/asql/internal/db"
+)
+
+// joinGranularities are the buckets J can join on.
+var joinGranularities = []timeGranularity{granDay, granWeek, granMonth}
+
+// dateColumn reports whether column col of result holds dates, by type or
+// by its first value.
+func dateColumn(result db.QueryResult, col int) bool {
+	if col >= len(result.Columns) {
+		return false
+	}
+	return (col < len(result.ColumnTypes) && detectDateColumn(result.ColumnTypes[col])) || looksLikeDate(result.Rows, col)
+}
+
+// startBucketJoin asks for the granularity to join the pinned and active
+// results on, using the date column under each pane's cursor.
+func (m model) startBucketJoin() (tea.Model, tea.Cmd) {
+	if m.pinned == nil {
+		m.setStatus("Pin a result with c first, then join it with the active one", true)
+		return m, nil
+	}
+	left, right := m.pinned.colCursor, m.colCursor
+	if !dateColumn(m.pinned.result, left) {
+		m.setStatus("Put the pinned cursor on a date column", true)
+		return m, nil
+	}
+	if !dateColumn(m.lastResult, right) {
+		m.setStatus("Put the active cursor on a date column", true)
+		return m, nil
+	}
+	m.joinSt = joinState{leftCol: left, rightCol: right}
+	m.mode = bucketJoinMode
+	m.setStatus(fmt.Sprintf("Join pinned %s with active %s", sanitize(m.pinned.result.Columns[left]), sanitize(m.lastResult.Columns[right])), false)
+	return m, nil
+}
+
+func (m model) updateBucketJoin(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	n := len(joinGranularities)
+	switch msg.Type {
+	case tea.KeyEsc:
+		m.mode = normalMode
+		m.setStatus("Normal mode", false)
+	case tea.KeyEnter:
+		m.mode = normalMode
+		m.applyBucketJoin(joinGranularities[m.joinSt.cursor])
+	case tea.KeyDown:
+		moveCursor(&m.joinSt.cursor, n, 1)
+	case tea.KeyUp:
+		moveCursor(&m.joinSt.cursor, n, -1)
+	case tea.KeyRunes:
+		if msg.Alt {
+			break
+		}
+		switch string(msg.Runes) {
+		case "j":
+			moveCursor(&m.joinSt.cursor, n, 1)
+		case "k":
+			moveCursor(&m.joinSt.cursor, n, -1)
+		case "q":
+			m.mode = normalMode
+			m.setStatus("Normal mode", false)
+		}
+	}
+	return m, nil
+}
+
+// applyBucketJoin replaces the active result with the bucket join. Like a
+// group-by, u brings the active result back.
+func (m *model) applyBucketJoin(gran timeGranularity) {
+	joined, err := bucketJoin(m.pinned.result, m.joinSt.leftCol, m.lastResult, m.joinSt.rightCol, gran)
+	if err != nil {
+		m.setStatus(err.Error(), true)
+		return
+	}
+	if m.pivotBase == nil {
+		m.pivotBase = &pivotBase{result: m.lastResult, query: m.lastQuery}
+	}
+	m.comparePane = 1
+	m.showDerivedResult(joined, "")
+	m.setStatus(joined.Message+" (u:restore)", false)
+}
+
+// bucketJoin counts the rows of left and right per bucket of their date
+// columns and joins the counts on the bucket. Every bucket between the
+// first and the last date of either side is listed, so a day that one side
+// lacks shows up with a count of 0. A truncated side is refused, as its
+// counts would stop at the scan limit.
+func bucketJoin(left db.QueryResult, lcol int, right db.QueryResult, rcol int, gran timeGranularity) (db.QueryResult, error) {
+	for _, side := range []struct {
+		name   string
+		result db.QueryResult
+	}{{"pinned", left}, {"active", right}} {
+		if side.result.Truncated {
+			return db.QueryResult{}, fmt.Errorf("the %s result is truncated at %d rows, so its counts would be wrong; narrow the query or count with GROUP BY in SQL", side.name, len(side.result.Rows))
+		}
+	}
+	var first, last time.Time
+	seen := false
+	count := func(rows [][]string, col int) map[string]int {
+		counts := make(map[string]int)
+		for _, row := range rows {
+			if col >= len(row) || row[col] == "NULL" {
+				continue
+			}
+			t, ok := parseDate(row[col])
+			if !ok {
+				continue
+			}
+			t = truncateTime(t, gran)
+			if !seen || t.Before(first) {
+				first = t
+			}
+			if !seen || t.After(last) {
+				last = t
+			}
+			seen = true
+			counts[bucketKey(t, gran)]++
+		}
+		return counts
+	}
+	lc, rc := count(left.Rows, lcol), count(right.Rows, rcol)
+	if !seen {
+		return db.QueryResult{}, fmt.Errorf("no dates to join on")
+	}
+
+	name := granularityName(gran)
+	out := db.QueryResult{
+		Columns:     []string{name, "left_count", "right_count", "delta"},
+		ColumnTypes: []string{"TEXT", "INTEGER", "INTEGER", "INTEGER"},
+	}
+	differ := 0
+	for t := first; !t.After(last); t = nextBucket(t, gran) {
+		if len(out.Rows) >= maxChartBuckets {
+			return db.QueryResult{}, fmt.Errorf("more than %d buckets by %s; pick a coarser granularity", maxChartBuckets, name)
+		}
+		key := bucketKey(t, gran)
+		l, r := lc[key], rc[key]
+		if l != r {
+			differ++
+		}
+		out.Rows = append(out.Rows, []string{key, strconv.Itoa(l), strconv.Itoa(r), strconv.Itoa(r - l)})
+	}
+	out.Message = fmt.Sprintf("%d %s bucket(s), %d differ", len(out.Rows), name, differ)
+	return out, nil
+}
+
+func (m model) renderWithBucketJoinOverlay(background string) string {
+	modalWidth := calcModalWidth(m.width, 50)
+	innerWidth := max(modalWidth-6, 1)
+
+	titleStyle := lipgloss.NewStyle().
+		Bold(true).
+		Foreground(accentColor).
+		MarginBottom(1)
+
+	boxStyle := lipgloss.NewStyle().
+		Border(lipgloss.RoundedBorder()).
+		BorderForeground(accentColor).
+		Padding(1, 2).
+		Width(modalWidth).
+		Background(panelBackground)
+
+	itemStyle := lipgloss.NewStyle().
+		Foreground(textColor).
+		Background(panelBackground).
+		Width(innerWidth).
+		Padding(0, 1)
+
+	selectedStyle := lipgloss.NewStyle().
+		Foreground(panelBackground).
+		Background(accentColor).
+		Bold(true).
+		Width(innerWidth).
+		Padding(0, 1)
+
+	var items strings.Builder
+	for i, gran := range joinGranularities {
+		label := "by " + granularityName(gran)
+		if i == m.joinSt.cursor {
+			items.WriteString(selectedStyle.Render(label))
+		} else {
+			items.WriteString(itemStyle.Render(label))
+		}
+		if i < len(joinGranularities)-1 {
+			items.WriteByte('\n')
+		}
+	}
+
+	title := fmt.Sprintf("Join pinned %s with active %s",
+		sanitize(m.pinned.result.Columns[m.joinSt.leftCol]), sanitize(m.lastResult.Columns[m.joinSt.rightCol]))
+	footer := "\n" + lipgloss.NewStyle().Foreground(mutedTextColor).Background(panelBackground).Render("Enter:join Esc:cancel")
+	modal := boxStyle.Render(titleStyle.Render(truncateRunes(title, innerWidth)) + "\n" + items.String() + footer)
+
+	return overlayModal(m.width, background, modal)
+}
@@ -0,0 +1,102 @@
+package ui
+
+import (
+	"slices"
+	"strings"
+	"testing"
+
+	tea "github.com/charmbracelet/bubbletea"
+
+	"github.com/kwrkb/asql/internal/db"
+)
+
+func TestBucketJoin(t *testing.T) {
+	left := db.QueryResult{
+		Columns: []string{"created_at"},
+		Rows:    [][]string{{"2024-01-01 10:00:00"}, {"2024-01-01 23:59:59"}, {"2024-01-03"}, {"NULL"}},
+	}
+	right := db.QueryResult{
+		Columns: []string{"id", "day"},
+		Rows:    [][]string{{"1", "2024-01-01"}, {"2", "2024-01-01"}, {"3", "2024-01-04"}},
+	}
+
+	got, err := bucketJoin(left, 0, right, 1, granDay)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []string{"day", "left_count", "right_count", "delta"}; !slices.Equal(got.Columns, want) {
+		t.Errorf("columns = %v", got.Columns)
+	}
+	want := [][]string{
+		{"2024-01-01", "2", "2", "0"},
+		{"2024-01-02", "0", "0", "0"},
+		{"2024-01-03", "1", "0", "-1"},
+		{"2024-01-04", "0", "1", "1"},
+	}
+	if !slices.EqualFunc(got.Rows, want, slices.Equal) {
+		t.Errorf("rows = %v, want %v", got.Rows, want)
+	}
+	if got.Message != "4 day bucket(s), 2 differ" {
+		t.Errorf("message = %q", got.Message)
+	}
+
+	months, _ := bucketJoin(left, 0, right, 1, granMonth)
+	if len(months.Rows) != 1 || !slices.Equal(months.Rows[0], []string{"2024-01", "3", "3", "0"}) {
+		t.Errorf("monthly rows = %v", months.Rows)
+	}
+
+	if _, err := bucketJoin(db.QueryResult{Rows: [][]string{{"x"}}}, 0, db.QueryResult{}, 0, granDay); err == nil {
+		t.Error("expected an error without dates")
+	}
+
+	right.Truncated = true
+	if _, err := bucketJoin(left, 0, right, 1, granDay); err == nil || !strings.Contains(err.Error(), "active result is truncated at 3 rows") {
+		t.Errorf("expected a truncated side to be refused, got %v", err)
+	}
+}
+
+func TestBucketJoinMode(t *testing.T) {
+	m := newTestModel()
+	m.showDerivedResult(db.QueryResult{
+		Columns: []string{"day", "n"},
+		Rows:    [][]string{{"2024-01-01", "1"}, {"2024-01-08", "2"}},
+	}, "")
+
+	next, _ := m.updateNormal(runeMsg("J"))
+	if next.(model).mode == bucketJoinMode {
+		t.Fatal("expected J to need a pinned result")
+	}
+
+	m.pinned = m.pinCurrentResult()
+	m.showDerivedResult(db.QueryResult{
+		Columns: []string{"id", "at"},
+		Rows:    [][]string{{"1", "2024-01-02T09:00:00+09:00"}},
+	}, "")
+	next, _ = m.updateNormal(runeMsg("J"))
+	if next.(model).mode == bucketJoinMode {
+		t.Fatal("expected the active cursor to need a date column")
+	}
+
+	m.colCursor = 1
+	next, _ = m.updateNormal(runeMsg("J"))
+	rm := next.(model)
+	if rm.mode != bucketJoinMode || rm.joinSt.leftCol != 0 || rm.joinSt.rightCol != 1 {
+		t.Fatalf("expected the granularity picker, got mode %q %+v", rm.mode, rm.joinSt)
+	}
+	next, _ = rm.updateBucketJoin(runeMsg("j")) // week
+	rm = next.(model)
+	next, _ = rm.updateBucketJoin(tea.KeyMsg{Type: tea.KeyEnter})
+	rm = next.(model)
+	want := [][]string{{"2024-W01", "1", "1", "0"}, {"2024-W02", "1", "0", "-1"}}
+	if rm.mode != normalMode || !slices.EqualFunc(rm.lastResult.Rows, want, slices.Equal) {
+		t.Fatalf("expected the weekly join, got %v", rm.lastResult.Rows)
+	}
+	if rm.pinned == nil || rm.lastQuery != "" {
+		t.Error("expected the pinned result to stay and the join to be read-only")
+	}
+
+	rm.restorePivot()
+	if len(rm.lastResult.Columns) != 2 || rm.lastResult.Columns[1] != "at" {
+		t.Errorf("expected u to restore the active result, got %v", rm.lastResult.Columns)
+	}
+}
@@ -0,0 +1,349 @@
+package ui
+
+import (
+	"math"
+	"strings"
+
+	"github.com/charmbracelet/lipgloss"
+)
+
+// chartKind selects how renderChart draws its points.
+type chartKind int
+
+const (
+	chartBar chartKind = iota
+	chartLine
+)
+
+// chartPoint is one position on the X axis of a chart.
+type chartPoint struct {
+	Label   string
+	Value   float64
+	Missing bool // no data here: drawn as a gap marker
+}
+
+// fitPoints merges adjacent points so that at most width remain. Merged
+// values are summed, or averaged when average is set; a merged point is
+// missing only when all of its points are.
+func fitPoints(points []chartPoint, width int, average bool) []chartPoint {
+	if width <= 0 || len(points) <= width {
+		return points
+	}
+	stride := (len(points) + width - 1) / width
+	out := make([]chartPoint, 0, width)
+	for i := 0; i < len(points); i += stride {
+		group := points[i:min(i+stride, len(points))]
+		p := chartPoint{Label: group[0].Label, Missing: true}
+		n := 0
+		for _, g := range group {
+			if g.Missing {
+				continue
+			}
+			p.Missing = false
+			p.Value += g.Value
+			n++
+		}
+		if average && n > 0 {
+			p.Value /= float64(n)
+		}
+		out = append(out, p)
+	}
+	return out
+}
+
+// chartCell is one character of the plot area.
+type chartCell struct {
+	r       rune
+	missing bool
+}
+
+// renderChart draws points as bars or a line in a width×height box,
+// including the Y axis labels on the left and X labels underneath. Points
+// are merged to fit the width (see fitPoints).
+func renderChart(points []chartPoint, kind chartKind, average bool, width, height int) string {
+	height = max(height, 2)
+	lo, hi, seen := math.Inf(1), math.Inf(-1), false
+	for _, p := range points {
+		if !p.Missing {
+			lo, hi, seen = min(lo, p.Value), max(hi, p.Value), true
+		}
+	}
+	if !seen {
+		lo, hi = 0, 1
+	}
+	if kind == chartBar {
+		lo = min(lo, 0)
+	}
+	if hi == lo {
+		hi = lo + 1
+	}
+
+	yLabels, gutter := yAxisLabels(lo, hi, height)
+	plotW := max(width-gutter-1, 1)
+	points = fitPoints(points, plotW, average)
+	colW := 1
+	if len(points) > 0 {
+		colW = max(min(plotW/len(points), 4), 1)
+	}
+
+	grid := make([][]chartCell, height)
+	for r := range grid {
+		grid[r] = make([]chartCell, plotW)
+		for c := range grid[r] {
+			grid[r][c].r = ' '
+		}
+	}
+	prevRow := -1
+	for i, p := range points {
+		x := i * colW
+		if x >= plotW {
+			break
+		}
+		barW := colW
+		if colW > 1 {
+			barW = colW - 1 // leave a space between bars
+		}
+		if p.Missing {
+			grid[height-1][x] = chartCell{r: '·', missing: true}
+			prevRow = -1
+			continue
+		}
+		frac := (p.Value - lo) / (hi - lo)
+		switch kind {
+		case chartLine:
+			row := height - 1 - int(math.Round(frac*float64(height-1)))
+			if prevRow >= 0 {
+				for r := min(prevRow, row) + 1; r < max(prevRow, row); r++ {
+					grid[r][x].r = '│'
+				}
+			}
+			for dx := 0; dx < barW && x+dx < plotW; dx++ {
+				grid[row][x+dx].r = '•'
+			}
+			prevRow = row
+		default:
+			eighths := int(math.Round(frac * float64(height*8)))
+			for r := 0; r < height; r++ {
+				level := eighths - r*8
+				var ch rune
+				switch {
+				case level >= 8:
+					ch = '█'
+				case level > 0:
+					ch = sparkBars[level-1]
+				default:
+					continue
+				}
+				for dx := 0; dx < barW && x+dx < plotW; dx++ {
+					grid[height-1-r][x+dx].r = ch
+				}
+			}
+		}
+	}
+
+	axisStyle := lipgloss.NewStyle().Foreground(mutedTextColor)
+	plotStyle := lipgloss.NewStyle().Foreground(accentColor)
+	gapStyle := lipgloss.NewStyle().Foreground(errorColor)
+
+	var b strings.Builder
+	for r, cells := range grid {
+		label, ok := yLabels[r]
+		tick := "│"
+		if ok {
+			tick = "┤"
+		}
+		b.WriteString(axisStyle.Render(strings.Repeat(" ", gutter-len(label)) + label + tick))
+		// Render runs of cells sharing a style together.
+		for start := 0; start < len(cells); {
+			end := start + 1
+			for end < len(cells) && cells[end].missing == cells[start].missing {
+				end++
+			}
+			var run strings.Builder
+			for _, c := range cells[start:end] {
+				run.WriteRune(c.r)
+			}
+			if cells[start].missing {
+				b.WriteString(gapStyle.Render(run.String()))
+			} else {
+				b.WriteString(plotStyle.Render(run.String()))
+			}
+			start = end
+		}
+		b.WriteByte('\n')
+	}
+	b.WriteString(axisStyle.Render(strings.Repeat(" ", gutter) + "└" + strings.Repeat("─", plotW)))
+	b.WriteByte('\n')
+	b.WriteString(axisStyle.Render(strings.Repeat(" ", gutter+1) + xAxisLabels(points, colW, plotW)))
+	return b.String()
+}
+
+// yAxisLabels labels the top, bottom and, when there is room, middle rows
+// of a plot spanning lo..hi, and returns the width of the widest label.
+func yAxisLabels(lo, hi float64, height int) (map[int]string, int) {
+	labels := map[int]string{
+		0:          formatAggregate(hi),
+		height - 1: formatAggregate(lo),
+	}
+	if height >= 5 {
+		labels[(height-1)/2] = formatAggregate(lo + (hi-lo)*float64(height-1-(height-1)/2)/float64(height-1))
+	}
+	gutter := 0
+	for _, l := range labels {
+		gutter = max(gutter, len(l))
+	}
+	return labels, gutter
+}
+
+// xAxisLabels labels the first, middle and last points, each starting
+// under its point, as far as they fit without overlapping.
+func xAxisLabels(points []chartPoint, colW, width int) string {
+	if len(points) == 0 {
+		return ""
+	}
+	line := []rune(strings.Repeat(" ", width))
+	next := 0 // first free position
+	for _, i := range []int{0, len(points) / 2, len(points) - 1} {
+		if i < 0 || (i > 0 && i*colW < next) {
+			continue
+		}
+		label := []rune(sanitize(points[i].Label))
+		pos := min(i*colW, width-len(label))
+		if pos < next || pos < 0 {
+			continue
+		}
+		copy(line[pos:], label)
+		next = pos + len(label) + 1
+	}
+	return strings.TrimRight(string(line), " ")
+}
+
+// brailleDots maps a dot's row (0-3) and column (0-1) within a braille
+// character to its bit.
+var brailleDots = [4][2]rune{{0x01, 0x08}, {0x02, 0x10}, {0x04, 0x20}, {0x40, 0x80}}
+
+// renderScatter plots the pairs (xs[i], ys[i]) with braille dots, two
+// columns and four rows of dots per character, with axis labels like
+// renderChart.
+func renderScatter(xs, ys []float64, width, height int) string {
+	height = max(height, 2)
+	xlo, xhi := minMax(xs)
+	ylo, yhi := minMax(ys)
+	yLabels, gutter := yAxisLabels(ylo, yhi, height)
+	plotW := max(width-gutter-1, 1)
+
+	grid := make([][]rune, height)
+	for r := range grid {
+		grid[r] = make([]rune, plotW)
+	}
+	for i := range xs {
+		px := int(math.Round((xs[i] - xlo) / (xhi - xlo) * float64(plotW*2-1)))
+		py := int(math.Round((yhi - ys[i]) / (yhi - ylo) * float64(height*4-1)))
+		grid[py/4][px/2] |= brailleDots[py%4][px%2]
+	}
+
+	axisStyle := lipgloss.NewStyle().Foreground(mutedTextColor)
+	plotStyle := lipgloss.NewStyle().Foreground(accentColor)
+
+	var b strings.Builder
+	for r, dots := range grid {
+		label, ok := yLabels[r]
+		tick := "│"
+		if ok {
+			tick = "┤"
+		}
+		b.WriteString(axisStyle.Render(strings.Repeat(" ", gutter-len(label)) + label + tick))
+		line := make([]rune, len(dots))
+		for c, d := range dots {
+			line[c] = ' '
+			if d != 0 {
+				line[c] = 0x2800 + d
+			}
+		}
+		b.WriteString(plotStyle.Render(string(line)))
+		b.WriteByte('\n')
+	}
+	b.WriteString(axisStyle.Render(strings.Repeat(" ", gutter) + "└" + strings.Repeat("─", plotW)))
+	b.WriteByte('\n')
+	ticks := []chartPoint{{Label: formatAggregate(xlo)}, {Label: formatAggregate((xlo + xhi) / 2)}, {Label: formatAggregate(xhi)}}
+	b.WriteString(axisStyle.Render(strings.Repeat(" ", gutter+1) + xAxisLabels(ticks, plotW/2, plotW)))
+	return b.String()
+}
+
+// minMax returns the range of vals, widened to one unit when it is empty
+// so that it can be divided by.
+func minMax(vals []float64) (float64, float64) {
+	if len(vals) == 0 {
+		return 0, 1
+	}
+	lo, hi := vals[0], vals[0]
+	for _, v := range vals {
+		lo, hi = min(lo, v), max(hi, v)
+	}
+	if lo == hi {
+		hi = lo + 1
+	}
+	return lo, hi
+}
+
+// hBarEighths are the partial blocks of a horizontal bar, 1/8 to 7/8.
+var hBarEighths = []rune{'▏', '▎', '▍', '▌', '▋', '▊', '▉'}
+
+// renderHBars draws one labelled horizontal bar per point, starting at
+// points[offset], as many as fit in height. Bars are scaled to the largest
+// absolute value of all points so that scrolling keeps the scale; negative
+// values and missing points are drawn in the error color.
+func renderHBars(points []chartPoint, width, height, offset int) string {
+	labelW, valueW := 1, 1
+	span := 0.0
+	for _, p := range points {
+		labelW = max(labelW, lipgloss.Width(sanitize(p.Label)))
+		if p.Missing {
+			valueW = max(valueW, len("NULL"))
+			continue
+		}
+		valueW = max(valueW, len(formatAggregate(p.Value)))
+		span = max(span, math.Abs(p.Value))
+	}
+	labelW = min(labelW, max(width/3, 1))
+	barW := max(width-labelW-valueW-2, 1)
+	if span == 0 {
+		span = 1
+	}
+
+	labelStyle := lipgloss.NewStyle().Foreground(textColor)
+	plotStyle := lipgloss.NewStyle().Foreground(accentColor)
+	negStyle := lipgloss.NewStyle().Foreground(errorColor)
+	valueStyle := lipgloss.NewStyle().Foreground(mutedTextColor)
+
+	end := min(offset+height, len(points))
+	var b strings.Builder
+	for i := offset; i < end; i++ {
+		p := points[i]
+		label := truncateRunes(sanitize(p.Label), labelW)
+		b.WriteString(labelStyle.Render(label + strings.Repeat(" ", labelW-lipgloss.Width(label))))
+		b.WriteByte(' ')
+
+		value := "NULL"
+		bar, style := "·", negStyle
+		if !p.Missing {
+			value = formatAggregate(p.Value)
+			eighths := int(math.Round(math.Abs(p.Value) / span * float64(barW*8)))
+			bar = strings.Repeat("█", eighths/8)
+			if eighths%8 > 0 {
+				bar += string(hBarEighths[eighths%8-1])
+			}
+			style = plotStyle
+			if p.Value < 0 {
+				style = negStyle
+			}
+		}
+		b.WriteString(style.Render(bar))
+		b.WriteString(strings.Repeat(" ", barW-lipgloss.Width(bar)+1))
+		b.WriteString(valueStyle.Render(strings.Repeat(" ", valueW-len(value)) + value))
+		if i < end-1 {
+			b.WriteByte('\n')
+		}
+	}
+	return b.String()
+}
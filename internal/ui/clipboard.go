@@ -0,0 +1,22 @@
+package ui
+
+import (
+	"fmt"
+
+	"github.com/kwrkb/asql/internal/clipboard"
+)
+
+// copyText copies text to the clipboard and reports the result in the
+// status bar as "<done> (<where it went>)". It returns false on failure.
+func (m *model) copyText(text, done string) bool {
+	if m.clip == nil {
+		m.clip = clipboard.New(clipboard.Auto, "")
+	}
+	backend, err := m.clip.Copy(text)
+	if err != nil {
+		m.setStatus(fmt.Sprintf("Clipboard failed: %v", err), true)
+		return false
+	}
+	m.setStatus(fmt.Sprintf("%s (%s)", done, m.clip.Describe(backend)), false)
+	return true
+}
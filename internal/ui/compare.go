@@ -7,7 +7,6 @@ import (
 	"github.com/charmbracelet/lipgloss"
 
 	"github.com/kwrkb/asql/internal/db"
-	"github.com/kwrkb/asql/internal/db/dbutil"
 )
 
 const minWidthForCompare = 80
@@ -223,7 +222,12 @@ func (m *model) togglePinnedSort() {
 		p.sortCol = p.colCursor
 		p.sortDir = sortAsc
 	}
-	// Re-sort and rebuild displayRows
+	p.resort()
+	p.table.GotoTop()
+}
+
+// resort rebuilds displayRows from the result in the current sort order.
+func (p *pinnedPane) resort() {
 	sorted := sortedRows(p.result.Rows, p.sortCol, p.sortDir)
 	p.displayRows = make([]table.Row, 0, len(sorted))
 	for _, row := range sorted {
@@ -234,7 +238,6 @@ func (m *model) togglePinnedSort() {
 		sentinel[0] = "(no rows)"
 		p.displayRows = []table.Row{sentinel}
 	}
-	p.table.GotoTop()
 	p.viewportDirty = true
 }
 
@@ -257,8 +260,7 @@ func (m *model) syncPinnedTable(paneWidth, paneHeight int) {
 	columns := make([]table.Column, 0, visEnd-visStart)
 	for i := visStart; i < visEnd; i++ {
 		header := sanitize(p.result.Columns[i])
-		if i < len(p.result.ColumnTypes) && p.result.ColumnTypes[i] != "" {
-			shortType := dbutil.ShortenTypeName(sanitize(p.result.ColumnTypes[i]))
+		if shortType := m.columnTypeLabel(p.result, i); shortType != "" {
 			header = header + " " + typeStyle.Render(shortType)
 		}
 		if i == p.sortCol && p.sortDir != sortNone {
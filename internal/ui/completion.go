@@ -3,11 +3,15 @@ package ui
 import (
 	"context"
 	"fmt"
+	"slices"
 	"strings"
 	"time"
+	"unicode"
 
 	tea "github.com/charmbracelet/bubbletea"
 	"github.com/charmbracelet/lipgloss"
+
+	"github.com/kwrkb/asql/internal/db"
 )
 
 type completionContext int
@@ -16,8 +20,54 @@ const (
 	contextUnknown completionContext = iota
 	contextTable
 	contextColumn
+	contextClause // after a table reference: an alias or the next clause
 )
 
+// completionItem is one entry of the completion popup.
+type completionItem struct {
+	text   string
+	detail string // shown beside text: a column's type and keys, or what the name is
+}
+
+// nameItems turns names into completion items sharing one detail.
+func nameItems(names []string, detail string) []completionItem {
+	items := make([]completionItem, len(names))
+	for i, n := range names {
+		items[i] = completionItem{text: n, detail: detail}
+	}
+	return items
+}
+
+// columnItems turns table columns into completion items described by
+// columnDetail.
+func columnItems(cols []db.ColumnInfo) []completionItem {
+	items := make([]completionItem, len(cols))
+	for i, c := range cols {
+		items[i] = completionItem{text: c.Name, detail: columnDetail(c)}
+	}
+	return items
+}
+
+// columnDetail describes a column as "type [PK] [NULL|NOT NULL] [FK→ref]".
+func columnDetail(c db.ColumnInfo) string {
+	parts := []string{strings.ToLower(c.Type)}
+	if c.Type == "" {
+		parts[0] = "any"
+	}
+	switch {
+	case c.PrimaryKey:
+		parts = append(parts, "PK")
+	case c.Nullable:
+		parts = append(parts, "NULL")
+	default:
+		parts = append(parts, "NOT NULL")
+	}
+	if c.References != "" {
+		parts = append(parts, "FK→"+c.References)
+	}
+	return strings.Join(parts, " ")
+}
+
 // wordAtCursor extracts the prefix being typed at the cursor position.
 // Returns the prefix and its start position within the full text.
 func wordAtCursor(text string, cursorRow int, charOffset int) (prefix string, startPos int) {
@@ -59,141 +109,153 @@ func isIdentRune(r rune) bool {
 	return (r >= 'a' && r <= 'z') || (r >= 'A' && r <= 'Z') || (r >= '0' && r <= '9') || r == '_'
 }
 
-// detectContext determines whether cursor is in a table-name or column-name context
-// by scanning the text before startPos for SQL keywords.
-func detectContext(text string, startPos int) completionContext {
-	before := text[:startPos]
-	// Find the last significant keyword token
-	kw := lastKeyword(before)
-	switch kw {
-	case "from", "join", "into", "update", "table":
-		return contextTable
-	case "select", "where", "and", "or", "set", "by", "on", "having":
-		return contextColumn
-	default:
-		return contextUnknown
-	}
-}
-
-// lastKeyword returns the last SQL keyword found before the cursor position,
-// skipping whitespace, commas, and identifiers.
-func lastKeyword(text string) string {
-	sqlKeywords := map[string]bool{
-		"from": true, "join": true, "into": true, "update": true, "table": true,
-		"select": true, "where": true, "and": true, "or": true, "set": true,
-		"by": true, "on": true, "having": true, "inner": true, "left": true,
-		"right": true, "outer": true, "cross": true, "order": true, "group": true,
-	}
-
-	i := len(text) - 1
-	// Skip trailing whitespace
-	for i >= 0 && (text[i] == ' ' || text[i] == '\t' || text[i] == '\n' || text[i] == '\r' || text[i] == ',') {
-		i--
-	}
+// Scores used to rank completions.
+const (
+	scoreStart       = 8  // the first character of the candidate matches
+	scoreBoundary    = 6  // a character starting a word inside the candidate matches
+	scoreConsecutive = 4  // a character right after the previous match matches
+	scoreExact       = 10 // the whole candidate matches
+	maxGapPenalty    = 3  // cap on the penalty for characters skipped between matches
+	recentBonus      = 10 // bonus for the last accepted item, one less per later use
+)
 
-	// Walk backward through tokens
-	for i >= 0 {
-		// Extract word ending at i
-		end := i + 1
-		for i >= 0 && isIdentRune(rune(text[i])) {
-			i--
-		}
-		start := i + 1
-		if start < end {
-			word := strings.ToLower(text[start:end])
-			if sqlKeywords[word] {
-				// "ORDER BY" / "GROUP BY" → return "by"
-				// "LEFT JOIN" → return "join"
-				return word
-			}
-		}
-		// Skip whitespace/commas/dots between tokens
-		for i >= 0 && (text[i] == ' ' || text[i] == '\t' || text[i] == '\n' || text[i] == '\r' || text[i] == ',' || text[i] == '.') {
-			i--
-		}
-		// If we hit a non-identifier, non-space character, stop
-		if i >= 0 && !isIdentRune(rune(text[i])) {
-			break
+// fuzzyScore reports whether the characters of pattern appear in candidate
+// in order, ignoring case, and how well: matches at the start, at word
+// starts ("cr" in "order_created_at" or "orderCreatedAt") and in runs score
+// higher, skipped characters lower. The best alignment is used.
+func fuzzyScore(candidate, pattern string) (int, bool) {
+	if pattern == "" {
+		return 0, true
+	}
+	orig := []rune(candidate)
+	text := make([]rune, len(orig))
+	for i, r := range orig {
+		text[i] = unicode.ToLower(r)
+	}
+	pat := []rune(strings.ToLower(pattern))
+	if len(pat) > len(text) {
+		return 0, false
+	}
+	bonus := func(i int) int {
+		switch {
+		case i == 0:
+			return scoreStart
+		case !unicode.IsLetter(orig[i-1]) && !unicode.IsDigit(orig[i-1]),
+			unicode.IsLower(orig[i-1]) && unicode.IsUpper(orig[i]),
+			unicode.IsLetter(orig[i-1]) && unicode.IsDigit(orig[i]):
+			return scoreBoundary
 		}
+		return 0
 	}
-	return ""
-}
 
-// detectTableFromContext tries to find the relevant table name for column completion.
-// Handles "tablename." prefix and "FROM tablename" patterns.
-func detectTableFromContext(text string, prefix string, tables []string) string {
-	// Check for "tablename." dot-prefix pattern
-	if dotIdx := strings.LastIndex(prefix, "."); dotIdx >= 0 {
-		tablePart := prefix[:dotIdx]
-		for _, t := range tables {
-			if strings.EqualFold(t, tablePart) {
-				return t
-			}
+	// best[i] is the best score of the pattern so far with its last
+	// character matched at text[i].
+	const none = -1 << 30
+	best := make([]int, len(text))
+	for i := range text {
+		best[i] = none
+		if text[i] == pat[0] {
+			best[i] = bonus(i) - min(i, maxGapPenalty)
 		}
-		return ""
-	}
-
-	// Look for FROM/JOIN clause table name
-	lower := strings.ToLower(text)
-	tableSet := make(map[string]string, len(tables)) // lowercase -> original
-	for _, t := range tables {
-		tableSet[strings.ToLower(t)] = t
 	}
-
-	// Find all FROM/JOIN occurrences and extract table names; return the last match
-	var lastMatch string
-	for _, kw := range []string{"from ", "join "} {
-		idx := 0
-		for {
-			pos := strings.Index(lower[idx:], kw)
-			if pos < 0 {
-				break
-			}
-			pos += idx + len(kw)
-			// Skip whitespace
-			for pos < len(lower) && (lower[pos] == ' ' || lower[pos] == '\t' || lower[pos] == '\n') {
-				pos++
+	for _, p := range pat[1:] {
+		next := make([]int, len(text))
+		for i := range text {
+			next[i] = none
+			if text[i] != p {
+				continue
 			}
-			// Extract table name
-			end := pos
-			for end < len(lower) && isIdentRune(rune(lower[end])) {
-				end++
-			}
-			if end > pos {
-				candidate := lower[pos:end]
-				if orig, ok := tableSet[candidate]; ok {
-					lastMatch = orig
+			for j := range i {
+				if best[j] == none {
+					continue
+				}
+				step := scoreConsecutive
+				if gap := i - j - 1; gap > 0 {
+					step = -min(gap, maxGapPenalty)
 				}
+				next[i] = max(next[i], best[j]+step+bonus(i))
 			}
-			idx = end
 		}
+		best = next
 	}
-	return lastMatch
+
+	score := slices.Max(best)
+	if score == none {
+		return 0, false
+	}
+	if len(pat) == len(text) {
+		score += scoreExact
+	}
+	return score, true
 }
 
-// filterByPrefix returns items that start with the given prefix (case-insensitive).
-func filterByPrefix(items []string, prefix string) []string {
-	if prefix == "" {
-		return items
+// rankCompletions keeps the items that fuzzily match pattern, best first.
+// Recently accepted items rank higher; ties keep the order of items.
+func (m *model) rankCompletions(items []completionItem, pattern string) []completionItem {
+	type scored struct {
+		item  completionItem
+		score int
 	}
-	lower := strings.ToLower(prefix)
-	var result []string
+	var ranked []scored
 	for _, item := range items {
-		if strings.HasPrefix(strings.ToLower(item), lower) {
-			result = append(result, item)
+		score, ok := fuzzyScore(item.text, pattern)
+		if !ok {
+			continue
 		}
+		if last, ok := m.completion.recent[strings.ToLower(item.text)]; ok {
+			score += max(recentBonus-(m.completion.useSeq-last), 0)
+		}
+		ranked = append(ranked, scored{item, score})
+	}
+	slices.SortStableFunc(ranked, func(a, b scored) int { return b.score - a.score })
+	result := make([]completionItem, len(ranked))
+	for i, r := range ranked {
+		result[i] = r.item
 	}
 	return result
 }
 
+// recordCompletionUse remembers that text was accepted, for ranking.
+// Items used more than recentBonus completions ago are forgotten.
+func (m *model) recordCompletionUse(text string) {
+	if m.completion.recent == nil {
+		m.completion.recent = make(map[string]int)
+	}
+	m.completion.useSeq++
+	m.completion.recent[strings.ToLower(text)] = m.completion.useSeq
+	for k, last := range m.completion.recent {
+		if m.completion.useSeq-last >= recentBonus {
+			delete(m.completion.recent, k)
+		}
+	}
+}
+
 // triggerCompletion collects completion candidates based on cursor context.
 // Returns a tea.Cmd if an async column fetch is needed, nil otherwise.
 func (m *model) triggerCompletion() tea.Cmd {
+	return m.complete(false)
+}
+
+// completeAfterDot opens the popup for the columns of the table, alias, CTE
+// or subquery just typed before a ".". Anything else is left alone.
+func (m *model) completeAfterDot() tea.Cmd {
 	text := m.textarea.Value()
-	row := m.textarea.Line()
-	charOffset := m.textarea.LineInfo().CharOffset
+	prefix, startPos := wordAtCursor(text, m.textarea.Line(), m.textarea.cursorColumn())
+	qualifier, ok := strings.CutSuffix(prefix, ".")
+	if !ok || qualifier == "" || strings.Contains(qualifier, ".") {
+		return nil
+	}
+	if _, ok := analyzeSQL(text, startPos, m.textarea.dialect).resolve(qualifier); !ok && m.knownTable(qualifier) == "" {
+		return nil
+	}
+	return m.complete(true)
+}
 
-	prefix, startPos := wordAtCursor(text, row, charOffset)
+// complete fills the popup with the candidates at the cursor. Unless auto
+// is set, a single candidate is inserted right away.
+func (m *model) complete(auto bool) tea.Cmd {
+	text := m.textarea.Value()
+	prefix, startPos := wordAtCursor(text, m.textarea.Line(), m.textarea.cursorColumn())
 
 	// Strip table prefix for column filtering (e.g., "users.na" → filter "na")
 	filterPrefix := prefix
@@ -201,39 +263,12 @@ func (m *model) triggerCompletion() tea.Cmd {
 		filterPrefix = prefix[dotIdx+1:]
 	}
 
-	ctx := detectContext(text, startPos)
-
-	var candidates []string
-	var fetchCmd tea.Cmd
-	switch ctx {
-	case contextTable:
-		candidates = filterByPrefix(m.sidebar.tables, filterPrefix)
-	case contextColumn:
-		tableName := detectTableFromContext(text, prefix, m.sidebar.tables)
-		if tableName != "" {
-			cols, cmd := m.getOrFetchColumns(tableName)
-			if cmd != nil {
-				m.completion.pendingPrefix = prefix
-				return cmd
-			}
-			candidates = filterByPrefix(cols, filterPrefix)
-		} else {
-			// Gather columns from all known tables
-			var cmd tea.Cmd
-			candidates, cmd = m.allColumns(filterPrefix)
-			fetchCmd = cmd
-		}
-	default:
-		// Unknown context: offer both tables and columns
-		candidates = filterByPrefix(m.sidebar.tables, filterPrefix)
-		colCandidates, cmd := m.allColumns(filterPrefix)
-		fetchCmd = cmd
-		candidates = append(candidates, colCandidates...)
-		candidates = dedup(candidates)
-	}
+	scope := analyzeSQL(text, startPos, m.textarea.dialect)
+	candidates, fetchCmd := m.completionCandidates(scope, prefix, filterPrefix)
 
 	if fetchCmd != nil {
 		m.completion.pendingPrefix = prefix
+		m.completion.pendingAuto = auto
 		return fetchCmd
 	}
 	m.completion.pendingPrefix = ""
@@ -242,9 +277,9 @@ func (m *model) triggerCompletion() tea.Cmd {
 		return nil
 	}
 
-	if len(candidates) == 1 {
+	if len(candidates) == 1 && !auto {
 		// Single candidate: insert immediately
-		m.insertCompletion(candidates[0], prefix)
+		m.insertCompletion(candidates[0].text, prefix)
 		return nil
 	}
 
@@ -255,29 +290,137 @@ func (m *model) triggerCompletion() tea.Cmd {
 	return nil
 }
 
+// completionCandidates lists what may complete prefix in scope, best match
+// first. It returns a Cmd instead when columns of a table have to be
+// fetched first.
+func (m *model) completionCandidates(scope sqlScope, prefix, filter string) ([]completionItem, tea.Cmd) {
+	dbType := ""
+	if adapter := m.activeDB(); adapter != nil {
+		dbType = adapter.Type()
+	}
+
+	// "qualifier.col": columns of the table, alias, CTE or subquery.
+	if dotIdx := strings.LastIndex(prefix, "."); dotIdx >= 0 {
+		qualifier := prefix[:dotIdx]
+		if ref, ok := scope.resolve(qualifier); ok {
+			if ref.derived {
+				return m.rankCompletions(nameItems(ref.cols, ""), filter), nil
+			}
+			qualifier = ref.name
+		}
+		table := m.knownTable(qualifier)
+		if table == "" {
+			return nil, nil
+		}
+		cols, cmd := m.getOrFetchColumns(table)
+		return m.rankCompletions(columnItems(cols), filter), cmd
+	}
+
+	var candidates []completionItem
+	switch scope.ctx {
+	case contextTable:
+		candidates = nameItems(m.sidebar.tables, "table")
+		for _, c := range scope.ctes {
+			candidates = append(candidates, completionItem{text: c.name, detail: "cte"})
+		}
+	case contextColumn:
+		cols, cmd := m.scopeColumns(scope)
+		if cmd != nil {
+			return nil, cmd
+		}
+		candidates = cols
+		if scope.insertList {
+			break
+		}
+		candidates = append(candidates, nameItems(scope.outputs, "select item")...)
+		for _, r := range scope.refs {
+			switch {
+			case r.alias == "":
+			case r.name == "":
+				candidates = append(candidates, completionItem{text: r.alias, detail: "subquery"})
+			default:
+				candidates = append(candidates, completionItem{text: r.alias, detail: "alias of " + r.name})
+			}
+		}
+		candidates = append(candidates, nameItems(sqlFunctions(dbType), "function")...)
+	case contextClause:
+		candidates = nameItems(keywordCandidates(dbType, filter), "keyword")
+	default:
+		// Unknown context: offer tables, columns and keywords
+		candidates = nameItems(m.sidebar.tables, "table")
+		colCandidates, cmd := m.allColumns()
+		if cmd != nil {
+			return nil, cmd
+		}
+		candidates = append(candidates, colCandidates...)
+		candidates = append(candidates, nameItems(keywordCandidates(dbType, filter), "keyword")...)
+	}
+	return m.rankCompletions(dedup(candidates), filter), nil
+}
+
+// scopeColumns returns the columns of the tables in scope, or of all known
+// tables when the statement names none that are known.
+func (m *model) scopeColumns(scope sqlScope) ([]completionItem, tea.Cmd) {
+	var cols []completionItem
+	found := false
+	for _, r := range scope.refs {
+		if r.derived {
+			cols = append(cols, nameItems(r.cols, "")...)
+			found = true
+			continue
+		}
+		table := m.knownTable(r.name)
+		if table == "" {
+			continue
+		}
+		tableCols, cmd := m.getOrFetchColumns(table)
+		if cmd != nil {
+			return nil, cmd
+		}
+		cols = append(cols, columnItems(tableCols)...)
+		found = true
+	}
+	if !found {
+		return m.allColumns()
+	}
+	return cols, nil
+}
+
+// knownTable returns the sidebar table that name refers to, or "".
+func (m *model) knownTable(name string) string {
+	for _, t := range m.sidebar.tables {
+		if strings.EqualFold(t, name) {
+			return t
+		}
+	}
+	for _, t := range m.sidebar.tables {
+		if nameMatches(t, name) {
+			return t
+		}
+	}
+	return ""
+}
+
 // acceptCompletion inserts the currently selected completion candidate.
 func (m *model) acceptCompletion() {
 	if !m.completion.active || len(m.completion.items) == 0 {
 		return
 	}
 	selected := m.completion.items[m.completion.cursor]
-	m.insertCompletion(selected, m.completion.prefix)
+	m.insertCompletion(selected.text, m.completion.prefix)
 	m.closeCompletion()
 }
 
-// insertCompletion replaces the prefix with the selected item.
+// insertCompletion replaces the typed word with the selected item. As
+// matching is fuzzy, the word is replaced rather than completed.
 func (m *model) insertCompletion(selected string, prefix string) {
 	// For "tablename.col" prefix, only replace after the dot
-	suffix := selected
+	typed := prefix
 	if dotIdx := strings.LastIndex(prefix, "."); dotIdx >= 0 {
-		afterDot := prefix[dotIdx+1:]
-		if len(afterDot) <= len(suffix) {
-			suffix = suffix[len(afterDot):]
-		}
-	} else if len(prefix) <= len(suffix) {
-		suffix = suffix[len(prefix):]
+		typed = prefix[dotIdx+1:]
 	}
-	m.textarea.InsertString(suffix)
+	m.textarea.replaceBeforeCursor(len(typed), selected)
+	m.recordCompletionUse(selected)
 }
 
 // closeCompletion hides the completion popup.
@@ -290,12 +433,11 @@ func (m *model) closeCompletion() {
 
 // getOrFetchColumns returns cached columns synchronously, or fires an async
 // Cmd to fetch them. When a Cmd is returned, columns will arrive via columnsLoadedMsg.
-func (m *model) getOrFetchColumns(tableName string) ([]string, tea.Cmd) {
+func (m *model) getOrFetchColumns(tableName string) ([]db.ColumnInfo, tea.Cmd) {
 	if m.completion.colCache == nil {
-		m.completion.colCache = make(map[string][]string)
+		m.completion.colCache = make(map[string][]db.ColumnInfo)
 	}
 	if cols, ok := m.completion.colCache[tableName]; ok {
-		m.colCacheTouch(tableName)
 		return cols, nil
 	}
 	// Fetch asynchronously to avoid blocking the UI
@@ -304,42 +446,32 @@ func (m *model) getOrFetchColumns(tableName string) ([]string, tea.Cmd) {
 	return nil, func() tea.Msg {
 		ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
 		defer cancel()
-		cols, err := adapter.Columns(ctx, tableName)
+		cols, err := adapter.ColumnDetails(ctx, tableName)
 		return columnsLoadedMsg{table: tableName, columns: cols, err: err, connGen: gen}
 	}
 }
 
-// colCacheTouch moves tableName to the end of the LRU order.
-func (m *model) colCacheTouch(tableName string) {
-	for i, name := range m.completion.colOrder {
-		if name == tableName {
-			m.completion.colOrder = append(m.completion.colOrder[:i], m.completion.colOrder[i+1:]...)
-			break
-		}
-	}
-	m.completion.colOrder = append(m.completion.colOrder, tableName)
-}
-
-// allColumns gathers columns from all known tables, filtered by prefix.
+// allColumns gathers columns from all known tables.
 // Returns a cmd if any table's columns need async fetching.
-func (m *model) allColumns(prefix string) ([]string, tea.Cmd) {
-	var all []string
+func (m *model) allColumns() ([]completionItem, tea.Cmd) {
+	var all []completionItem
 	for _, t := range m.sidebar.tables {
 		cols, cmd := m.getOrFetchColumns(t)
 		if cmd != nil {
 			// Start fetching; re-trigger will happen on columnsLoadedMsg
 			return nil, cmd
 		}
-		all = append(all, filterByPrefix(cols, prefix)...)
+		all = append(all, columnItems(cols)...)
 	}
 	return dedup(all), nil
 }
 
-func dedup(items []string) []string {
+// dedup drops items whose text repeats an earlier one, ignoring case.
+func dedup(items []completionItem) []completionItem {
 	seen := make(map[string]bool, len(items))
-	result := make([]string, 0, len(items))
+	result := make([]completionItem, 0, len(items))
 	for _, item := range items {
-		lower := strings.ToLower(item)
+		lower := strings.ToLower(item.text)
 		if !seen[lower] {
 			seen[lower] = true
 			result = append(result, item)
@@ -356,24 +488,32 @@ func (m model) renderCompletionPopup() string {
 		return ""
 	}
 
-	popupWidth := 30
+	// Details line up in a column after the longest name.
+	textWidth, detailWidth := 0, 0
 	for _, item := range m.completion.items {
-		w := lipgloss.Width(item) + 4
-		if w > popupWidth {
-			popupWidth = w
-		}
+		textWidth = max(textWidth, lipgloss.Width(sanitize(item.text)))
+		detailWidth = max(detailWidth, lipgloss.Width(sanitize(item.detail)))
 	}
-	if popupWidth > 50 {
-		popupWidth = 50
+	popupWidth := textWidth + 6 // border and padding
+	if detailWidth > 0 {
+		popupWidth += detailWidth + 2
+	}
+	popupWidth = max(popupWidth, 30)
+	if popupWidth > 70 {
+		popupWidth = 70
 	}
 	popupWidth = min(popupWidth, max(m.fullContentWidth()-4, 1))
 	popupInnerWidth := max(popupWidth-4, 1)
+	textWidth = min(textWidth, max(popupInnerWidth-2-detailWidth-2, popupInnerWidth/2))
 
 	itemStyle := lipgloss.NewStyle().
 		Foreground(textColor).
 		Background(panelBackground).
 		Width(popupInnerWidth).
 		Padding(0, 1)
+	detailStyle := lipgloss.NewStyle().
+		Foreground(mutedTextColor).
+		Background(panelBackground)
 
 	selectedStyle := lipgloss.NewStyle().
 		Foreground(panelBackground).
@@ -381,6 +521,9 @@ func (m model) renderCompletionPopup() string {
 		Bold(true).
 		Width(popupInnerWidth).
 		Padding(0, 1)
+	selectedDetailStyle := lipgloss.NewStyle().
+		Foreground(panelBackground).
+		Background(accentColor)
 
 	// Calculate scroll offset
 	scrollOffset := 0
@@ -391,17 +534,17 @@ func (m model) renderCompletionPopup() string {
 
 	var lines []string
 	for i := scrollOffset; i < end; i++ {
-		label := sanitize(m.completion.items[i])
-		runes := []rune(label)
-		maxLen := popupWidth - 6
-		if maxLen > 0 && len(runes) > maxLen {
-			label = string(runes[:maxLen]) + "…"
-		}
+		item := m.completion.items[i]
+		label := truncateRunes(sanitize(item.text), textWidth)
+		style, dStyle := itemStyle, detailStyle
 		if i == m.completion.cursor {
-			lines = append(lines, selectedStyle.Render(label))
-		} else {
-			lines = append(lines, itemStyle.Render(label))
+			style, dStyle = selectedStyle, selectedDetailStyle
+		}
+		if room := popupInnerWidth - 2 - textWidth - 2; item.detail != "" && room > 0 {
+			pad := max(textWidth-lipgloss.Width(label), 0) + 2
+			label += strings.Repeat(" ", pad) + dStyle.Render(truncateRunes(sanitize(item.detail), room))
 		}
+		lines = append(lines, style.Render(label))
 	}
 
 	if len(m.completion.items) > maxCompletionVisible {
@@ -1,7 +1,12 @@
 package ui
 
 import (
+	"slices"
+	"strings"
 	"testing"
+
+	"github.com/kwrkb/asql/internal/db"
+	"github.com/kwrkb/asql/internal/db/dbutil"
 )
 
 func TestWordAtCursor(t *testing.T) {
@@ -39,7 +44,7 @@ func TestWordAtCursor(t *testing.T) {
 	}
 }
 
-func TestDetectContext(t *testing.T) {
+func TestAnalyzeSQLContext(t *testing.T) {
 	tests := []struct {
 		name     string
 		text     string
@@ -48,8 +53,11 @@ func TestDetectContext(t *testing.T) {
 	}{
 		{"after FROM", "SELECT * FROM ", 14, contextTable},
 		{"after JOIN", "SELECT * FROM t JOIN ", 21, contextTable},
+		{"after LEFT JOIN", "SELECT * FROM t LEFT OUTER JOIN ", 32, contextTable},
+		{"after comma in FROM", "SELECT * FROM a, ", 17, contextTable},
 		{"after INTO", "INSERT INTO ", 12, contextTable},
 		{"after UPDATE", "UPDATE ", 7, contextTable},
+		{"after a table", "SELECT * FROM users u ", 22, contextClause},
 		{"after SELECT", "SELECT ", 7, contextColumn},
 		{"after WHERE", "SELECT * FROM t WHERE ", 22, contextColumn},
 		{"after AND", "WHERE a = 1 AND ", 16, contextColumn},
@@ -57,74 +65,233 @@ func TestDetectContext(t *testing.T) {
 		{"after SET", "UPDATE t SET ", 13, contextColumn},
 		{"after BY", "ORDER BY ", 9, contextColumn},
 		{"after ON", "JOIN t2 ON ", 11, contextColumn},
+		{"in a call", "SELECT count(", 13, contextColumn},
 		{"unknown context", "VALUES (", 8, contextUnknown},
 		{"empty text", "", 0, contextUnknown},
 		{"after comma in SELECT", "SELECT a, ", 10, contextColumn},
+		{"FROM in a string", "SELECT 'from ' ", 15, contextColumn},
+		{"next statement", "SELECT * FROM t; SELECT * FROM ", 31, contextTable},
+		{"subquery start", "SELECT * FROM (", 15, contextUnknown},
+		{"subquery FROM", "SELECT * FROM (SELECT a FROM ", 29, contextTable},
+		{"after subquery", "SELECT * FROM (SELECT a FROM t) s WHERE ", 40, contextColumn},
 	}
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			got := detectContext(tt.text, tt.startPos)
+			got := analyzeSQL(tt.text, tt.startPos, dbutil.Dialect{}).ctx
 			if got != tt.want {
-				t.Errorf("detectContext(%q, %d) = %d, want %d", tt.text, tt.startPos, got, tt.want)
+				t.Errorf("analyzeSQL(%q, %d).ctx = %d, want %d", tt.text, tt.startPos, got, tt.want)
 			}
 		})
 	}
 }
 
-func TestFilterByPrefix(t *testing.T) {
-	items := []string{"users", "user_roles", "products", "orders"}
+func TestAnalyzeSQLScope(t *testing.T) {
+	// The cursor is at the "|".
+	scope := func(text string) sqlScope {
+		pos := strings.Index(text, "|")
+		return analyzeSQL(strings.Replace(text, "|", "", 1), pos, dbutil.DialectFor("postgres"))
+	}
 
-	tests := []struct {
-		name   string
-		prefix string
-		want   int
-	}{
-		{"match two", "user", 2},
-		{"match one", "prod", 1},
-		{"no match", "xyz", 0},
-		{"empty prefix", "", 4},
-		{"case insensitive", "USER", 2},
+	s := scope(`SELECT | FROM orders o JOIN "Users" AS u ON u.id = o.user_id`)
+	if r, ok := s.resolve("o"); !ok || r.name != "orders" {
+		t.Errorf("o resolves to %+v, %v", r, ok)
+	}
+	if r, ok := s.resolve("U"); !ok || r.name != "Users" {
+		t.Errorf("u resolves to %+v, %v", r, ok)
+	}
+	if r, ok := s.resolve("orders"); ok {
+		t.Errorf("an aliased table should be known by its alias only, got %+v", r)
 	}
 
-	for _, tt := range tests {
-		t.Run(tt.name, func(t *testing.T) {
-			got := filterByPrefix(items, tt.prefix)
-			if len(got) != tt.want {
-				t.Errorf("filterByPrefix(%v, %q) returned %d items, want %d", items, tt.prefix, len(got), tt.want)
-			}
-		})
+	s = scope("WITH recent (id, total) AS (SELECT 1, 2), top AS (SELECT user_id, sum(total) AS s FROM recent GROUP BY user_id) SELECT r.| FROM recent r, top")
+	if r, ok := s.resolve("r"); !ok || !slices.Equal(r.cols, []string{"id", "total"}) {
+		t.Errorf("r resolves to %+v, %v", r, ok)
+	}
+	if r, ok := s.resolve("top"); !ok || !slices.Equal(r.cols, []string{"user_id", "s"}) {
+		t.Errorf("top resolves to %+v, %v", r, ok)
+	}
+
+	s = scope("SELECT x.| FROM (SELECT id, name AS n, count(*) FROM users) x")
+	if r, ok := s.resolve("x"); !ok || !slices.Equal(r.cols, []string{"id", "n"}) {
+		t.Errorf("x resolves to %+v, %v", r, ok)
+	}
+
+	s = scope("SELECT id, total * 2 AS doubled FROM orders ORDER BY |")
+	if !slices.Equal(s.outputs, []string{"id", "doubled"}) {
+		t.Errorf("outputs = %v", s.outputs)
+	}
+
+	// A correlated subquery sees the tables of the outer query.
+	s = scope("SELECT * FROM users u WHERE EXISTS (SELECT 1 FROM orders o WHERE o.user_id = |)")
+	if _, ok := s.resolve("u"); !ok {
+		t.Errorf("expected the outer alias in scope, got %+v", s.refs)
+	}
+
+	s = scope("INSERT INTO public.orders (id, |) VALUES (1, 2)")
+	if !s.insertList || len(s.refs) != 1 || s.refs[0].name != "public.orders" {
+		t.Errorf("expected the INSERT column list of public.orders, got %+v", s)
+	}
+}
+
+func TestCompletionCandidates(t *testing.T) {
+	m := newTestModel()
+	m.sidebar.tables = []string{"orders", "users"}
+	m.completion.colCache = map[string][]db.ColumnInfo{
+		"orders": {{Name: "id"}, {Name: "user_id"}, {Name: "total"}},
+		"users":  {{Name: "id"}, {Name: "name"}},
+	}
+	complete := func(text string) []string {
+		m.textarea.SetValue(text)
+		m.closeCompletion()
+		m.triggerCompletion()
+		return itemTexts(m.completion.items)
+	}
+
+	if got := complete("SELECT * FROM orders o WHERE o."); !slices.Equal(got, []string{"id", "user_id", "total"}) {
+		t.Errorf("o. = %v", got)
+	}
+	if got := complete("SELECT * FROM orders o JOIN users u ON u.id = o.u"); len(got) != 0 || !strings.HasSuffix(m.textarea.Value(), "o.user_id") {
+		t.Errorf("expected the only match to be inserted, got %v and %q", got, m.textarea.Value())
+	}
+	if got := complete("SELECT total AS t FROM orders ORDER BY t"); !slices.Contains(got, "total") || !slices.Contains(got, "t") {
+		t.Errorf("ORDER BY t = %v", got)
+	}
+	if got := complete("SELECT * FROM users u gro"); len(got) != 0 || !strings.HasSuffix(m.textarea.Value(), "u group by") {
+		t.Errorf("expected the keyword in the typed case, got %v and %q", got, m.textarea.Value())
+	}
+	if got := complete("SELECT co"); !slices.Contains(got, "count") || !slices.Contains(got, "coalesce") {
+		t.Errorf("expected functions, got %v", got)
+	}
+	if got := complete("INSERT INTO users ("); !slices.Equal(got, []string{"id", "name"}) {
+		t.Errorf("INSERT column list = %v", got)
 	}
 }
 
-func TestDetectTableFromContext(t *testing.T) {
-	tables := []string{"users", "orders", "products"}
+func itemTexts(items []completionItem) []string {
+	texts := make([]string, len(items))
+	for i, item := range items {
+		texts[i] = item.text
+	}
+	return texts
+}
 
+func TestFuzzyScore(t *testing.T) {
 	tests := []struct {
-		name   string
-		text   string
-		prefix string
-		want   string
+		candidate, pattern string
+		ok                 bool
 	}{
-		{"dot prefix", "SELECT users.", "users.na", "users"},
-		{"FROM clause", "SELECT * FROM users WHERE ", "", "users"},
-		{"JOIN clause", "SELECT * FROM orders JOIN users ON ", "", "users"},
-		{"unknown table", "SELECT foo.", "foo.bar", ""},
-		{"no context", "SELECT ", "", ""},
+		{"order_created_at", "ordcr", true},
+		{"orderCreatedAt", "oca", true},
+		{"users", "USER", true},
+		{"users", "", true},
+		{"users", "sru", false},
+		{"id", "idx", false},
 	}
-
 	for _, tt := range tests {
-		t.Run(tt.name, func(t *testing.T) {
-			got := detectTableFromContext(tt.text, tt.prefix, tables)
-			if got != tt.want {
-				t.Errorf("detectTableFromContext(%q, %q, ...) = %q, want %q", tt.text, tt.prefix, got, tt.want)
-			}
-		})
+		if _, ok := fuzzyScore(tt.candidate, tt.pattern); ok != tt.ok {
+			t.Errorf("fuzzyScore(%q, %q) matched = %v, want %v", tt.candidate, tt.pattern, ok, tt.ok)
+		}
+	}
+
+	// Better matches score higher.
+	better := [][3]string{
+		// pattern, better, worse
+		{"ca", "created_at", "location"}, // word starts beat a run inside a word
+		{"ord", "orders", "coordinates"}, // a prefix beats a match inside
+		{"id", "id", "idle"},             // an exact match beats a prefix
+		{"uid", "user_id", "build"},      // word starts beat a run in the middle
+		{"ordcr", "order_created_at", "ordinal_currency_rate"},
+	}
+	for _, b := range better {
+		hi, _ := fuzzyScore(b[1], b[0])
+		lo, _ := fuzzyScore(b[2], b[0])
+		if hi <= lo {
+			t.Errorf("%q: %q scores %d, %q scores %d", b[0], b[1], hi, b[2], lo)
+		}
+	}
+}
+
+func TestRankCompletions(t *testing.T) {
+	m := newTestModel()
+	items := nameItems([]string{"ordinal", "order_created_at", "orders", "customer"}, "")
+
+	if got := itemTexts(m.rankCompletions(items, "ordcr")); !slices.Equal(got, []string{"order_created_at"}) {
+		t.Errorf("ordcr = %v", got)
+	}
+	if got := itemTexts(m.rankCompletions(items, "")); !slices.Equal(got, []string{"ordinal", "order_created_at", "orders", "customer"}) {
+		t.Errorf("an empty pattern should keep the order, got %v", got)
+	}
+
+	// A recently used item moves up among equal matches.
+	m.recordCompletionUse("orders")
+	if got := itemTexts(m.rankCompletions(items, "or")); got[0] != "orders" {
+		t.Errorf("expected the recently used item first, got %v", got)
+	}
+	for range recentBonus {
+		m.recordCompletionUse("customer")
+	}
+	if _, ok := m.completion.recent["orders"]; ok {
+		t.Errorf("expected old uses to be forgotten, got %v", m.completion.recent)
+	}
+}
+
+func TestCompletionPopupDetails(t *testing.T) {
+	m := newTestModel()
+	m.sidebar.tables = []string{"orders"}
+	m.completion.colCache = map[string][]db.ColumnInfo{
+		"orders": {
+			{Name: "id", Type: "INTEGER", PrimaryKey: true},
+			{Name: "user_id", Type: "INTEGER", Nullable: true, References: "users.id"},
+			{Name: "note", Type: "TEXT"},
+		},
+	}
+	m.textarea.SetValue("SELECT * FROM orders o WHERE o.")
+	m.triggerCompletion()
+	popup := m.renderCompletionPopup()
+	for _, want := range []string{"integer PK", "integer NULL FK→users.id", "text NOT NULL"} {
+		if !strings.Contains(popup, want) {
+			t.Errorf("popup lacks %q:\n%s", want, popup)
+		}
+	}
+}
+
+func TestCompleteAfterDot(t *testing.T) {
+	m := newInsertModel()
+	m.sidebar.tables = []string{"orders"}
+	m.completion.colCache = map[string][]db.ColumnInfo{"orders": {{Name: "id"}}}
+
+	// Typing "." after an alias opens the popup, even for one column.
+	m.textarea.SetValue("SELECT * FROM orders o WHERE o")
+	result, _ := m.updateInsert(runeMsg("."))
+	if c := result.(model).completion; !c.active || !slices.Equal(itemTexts(c.items), []string{"id"}) {
+		t.Fatalf("expected a popup with id, got %+v", c)
+	}
+
+	// Other dots, as in numbers, do not.
+	m.textarea.SetValue("SELECT 1")
+	result, _ = m.updateInsert(runeMsg("."))
+	if result.(model).completion.active {
+		t.Error("expected no popup after a number")
+	}
+}
+
+func TestInsertCompletionReplacesWord(t *testing.T) {
+	m := newTestModel()
+	m.textarea.SetValue("SELECT o.ordcr\nFROM orders o")
+	m.textarea.CursorUp()
+	m.textarea.CursorEnd()
+	m.insertCompletion("order_created_at", "o.ordcr")
+	if got := m.textarea.Value(); got != "SELECT o.order_created_at\nFROM orders o" {
+		t.Errorf("value = %q", got)
+	}
+	if m.textarea.Line() != 0 || m.textarea.cursorColumn() != len("SELECT o.order_created_at") {
+		t.Errorf("cursor at line %d column %d", m.textarea.Line(), m.textarea.cursorColumn())
 	}
 }
 
 func TestDedup(t *testing.T) {
-	input := []string{"id", "name", "ID", "email", "Name"}
+	input := nameItems([]string{"id", "name", "ID", "email", "Name"}, "")
 	got := dedup(input)
 	if len(got) != 3 {
 		t.Errorf("dedup returned %d items, want 3: %v", len(got), got)
@@ -0,0 +1,52 @@
+package ui
+
+import "strings"
+
+// completionKeywords are offered where a clause or an expression may start.
+var completionKeywords = []string{
+	"SELECT", "FROM", "WHERE", "JOIN", "LEFT JOIN", "INNER JOIN", "CROSS JOIN", "ON", "USING",
+	"GROUP BY", "ORDER BY", "HAVING", "LIMIT", "OFFSET", "AS", "DISTINCT", "UNION", "UNION ALL",
+	"INSERT INTO", "VALUES", "UPDATE", "SET", "DELETE FROM", "WITH", "AND", "OR", "NOT", "IN",
+	"IS NULL", "IS NOT NULL", "BETWEEN", "LIKE", "EXISTS", "CASE", "WHEN", "THEN", "ELSE", "END",
+	"ASC", "DESC",
+}
+
+// dialectKeywords are the keywords only some databases have.
+var dialectKeywords = map[string][]string{
+	"postgres": {"ILIKE", "RETURNING", "LATERAL", "ON CONFLICT", "FULL JOIN", "FETCH FIRST"},
+	"mysql":    {"REPLACE INTO", "ON DUPLICATE KEY UPDATE", "STRAIGHT_JOIN", "REGEXP"},
+	"sqlite":   {"RETURNING", "ON CONFLICT", "GLOB", "PRAGMA", "INSERT OR REPLACE INTO"},
+}
+
+// completionFunctions are the functions every supported database has.
+var completionFunctions = []string{
+	"count", "sum", "avg", "min", "max", "coalesce", "nullif", "cast", "lower", "upper",
+	"length", "trim", "round", "abs", "replace",
+}
+
+// dialectFunctions are the common functions of each database.
+var dialectFunctions = map[string][]string{
+	"postgres": {"now", "date_trunc", "extract", "to_char", "string_agg", "array_agg", "json_agg",
+		"jsonb_build_object", "generate_series", "greatest", "least", "concat", "substring", "age"},
+	"mysql": {"now", "date_format", "str_to_date", "datediff", "concat", "group_concat", "ifnull",
+		"if", "json_extract", "greatest", "least", "substring"},
+	"sqlite": {"date", "datetime", "strftime", "julianday", "group_concat", "ifnull", "iif",
+		"json_extract", "printf", "substr", "total"},
+}
+
+// sqlFunctions returns the functions of the database type.
+func sqlFunctions(dbType string) []string {
+	return append(append([]string(nil), completionFunctions...), dialectFunctions[dbType]...)
+}
+
+// keywordCandidates returns the keywords of the database type, in lower
+// case when prefix, the word being completed, is typed in lower case.
+func keywordCandidates(dbType, prefix string) []string {
+	words := append(append([]string(nil), completionKeywords...), dialectKeywords[dbType]...)
+	if prefix != "" && prefix == strings.ToLower(prefix) {
+		for i, w := range words {
+			words[i] = strings.ToLower(w)
+		}
+	}
+	return words
+}
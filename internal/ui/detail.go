@@ -6,8 +6,6 @@ import (
 
 	tea "github.com/charmbracelet/bubbletea"
 	"github.com/charmbracelet/lipgloss"
-
-	"github.com/kwrkb/asql/internal/db/dbutil"
 )
 
 func (m model) updateDetail(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
@@ -41,6 +39,10 @@ func (m model) updateDetail(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 			m.table.MoveUp(1)
 			m.detail.fieldCursor = 0
 			m.detail.scroll = 0
+		case "v":
+			return m.openViewer()
+		case "e":
+			return m.startCellEdit(m.table.Cursor(), m.detail.fieldCursor)
 		}
 	}
 
@@ -110,8 +112,8 @@ func (m model) renderWithDetailOverlay(background string) string {
 
 		colName := sanitize(m.lastResult.Columns[i])
 		colType := ""
-		if i < len(m.lastResult.ColumnTypes) && m.lastResult.ColumnTypes[i] != "" {
-			colType = " " + dbutil.ShortenTypeName(sanitize(m.lastResult.ColumnTypes[i]))
+		if shortType := m.columnTypeLabel(m.lastResult, i); shortType != "" {
+			colType = " " + shortType
 		}
 
 		val := ""
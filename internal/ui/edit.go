@@ -0,0 +1,795 @@
+package ui
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/charmbracelet/bubbles/textinput"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+
+	"github.com/kwrkb/asql/internal/db"
+	"github.com/kwrkb/asql/internal/db/dbutil"
+	"github.com/kwrkb/asql/internal/export"
+)
+
+// editTargetMsg carries the primary key lookup started by startCellEdit or
+// startRowChange.
+type editTargetMsg struct {
+	seq     uint64
+	target  pendingEdit
+	rows    [][]string // rows the change applies to, in result column order
+	keys    []string
+	columns []string // table columns; nil when unknown
+	err     error
+}
+
+// editsAppliedMsg reports the outcome of applying the pending edits.
+type editsAppliedMsg struct {
+	seq   uint64
+	edits []pendingEdit
+	rows  map[string]map[string]editedCell // re-read rows by rowKey, by column
+	err   error
+}
+
+// editedCell is a cell of an edited row, in result cell encoding, with
+// whether it is NULL.
+type editedCell struct {
+	value string
+	null  bool
+}
+
+// activeReadOnly reports whether the active connection belongs to a profile
+// marked read_only.
+func (m *model) activeReadOnly() bool {
+	dsn := m.connMgr.ActiveDSN()
+	for _, p := range m.profileSt.items {
+		if p.DSN == dsn && p.ReadOnly {
+			return true
+		}
+	}
+	return false
+}
+
+// editableTable returns the table behind the current result, or sets an
+// error status and returns "" when the result cannot be changed.
+func (m *model) editableTable(needRows bool) string {
+	if len(m.lastResult.Columns) == 0 || (needRows && m.rowCount() == 0) {
+		m.setStatus("No rows to change", true)
+		return ""
+	}
+	if m.activeReadOnly() {
+		m.setStatus(fmt.Sprintf("Connection %s is read-only", sanitize(m.connMgr.ActiveName())), true)
+		return ""
+	}
+	tableName := dbutil.SingleTableName(m.lastQuery)
+	if tableName == "" {
+		m.setStatus("Changing rows needs a result from a single-table SELECT", true)
+	}
+	return tableName
+}
+
+// startCellEdit looks up the primary key of the result's table and, once it
+// arrives as editTargetMsg, opens the editor for the given cell.
+func (m model) startCellEdit(rowIdx, col int) (tea.Model, tea.Cmd) {
+	if rowIdx < 0 || rowIdx >= len(m.displayRows) || col >= len(m.lastResult.Columns) {
+		m.setStatus("No cell to edit", true)
+		return m, nil
+	}
+	if derivedColumn(m.lastResult, col) {
+		m.setStatus(fmt.Sprintf("%s is a derived column and cannot be edited", sanitize(m.lastResult.Columns[col])), true)
+		return m, nil
+	}
+	tableName := m.editableTable(true)
+	if tableName == "" {
+		return m, nil
+	}
+	row := slices.Clone(m.displayRows[rowIdx])
+	colType := ""
+	if col < len(m.lastResult.ColumnTypes) {
+		colType = m.lastResult.ColumnTypes[col]
+	}
+	target := pendingEdit{
+		kind:     changeUpdate,
+		table:    tableName,
+		column:   m.lastResult.Columns[col],
+		colType:  colType,
+		oldValue: row[col],
+	}
+	return m, m.lookupEditTarget(target, [][]string{row})
+}
+
+// startRowChange stages an insert or delete for the selected rows (the
+// rows of the VISUAL selection, or the row under the cursor). A blank insert ignores
+// the selection.
+func (m model) startRowChange(kind changeKind, blank bool) (tea.Model, tea.Cmd) {
+	tableName := m.editableTable(!blank)
+	if tableName == "" {
+		return m, nil
+	}
+	var rows [][]string
+	if !blank {
+		for _, r := range m.selectedRows() {
+			rows = append(rows, slices.Clone(r))
+		}
+		if len(rows) == 0 {
+			m.setStatus("No rows selected", true)
+			return m, nil
+		}
+	}
+	return m, m.lookupEditTarget(pendingEdit{kind: kind, table: tableName}, rows)
+}
+
+// lookupEditTarget returns a command that fetches the primary key (and, when
+// cheap, the column list) of target.table.
+func (m *model) lookupEditTarget(target pendingEdit, rows [][]string) tea.Cmd {
+	m.editSt.seq++
+	m.editSt.prevMode = m.mode
+	target.dsn = m.connMgr.ActiveDSN()
+	tableName := target.table
+	m.setStatus(fmt.Sprintf("Looking up primary key of %s...", sanitize(tableName)), false)
+
+	adapter := m.activeDB()
+	seq := m.editSt.seq
+	return func() tea.Msg {
+		ctx, cancel := context.WithTimeout(context.Background(), queryTimeout)
+		defer cancel()
+		keys, err := adapter.PrimaryKey(ctx, tableName)
+		if err != nil {
+			return editTargetMsg{seq: seq, err: err}
+		}
+		var columns []string
+		if schema, _ := dbutil.SplitTableName(tableName); schema == "" {
+			// Best effort: only used to skip derived or aliased columns.
+			columns, _ = adapter.Columns(ctx, tableName)
+		}
+		return editTargetMsg{seq: seq, target: target, rows: rows, keys: keys, columns: columns}
+	}
+}
+
+// handleEditTarget validates the key lookup, then opens the cell editor or
+// stages the row changes.
+func (m *model) handleEditTarget(msg editTargetMsg) tea.Cmd {
+	if msg.seq != m.editSt.seq || m.mode != m.editSt.prevMode {
+		return nil
+	}
+	if msg.err != nil {
+		m.setStatus(fmt.Sprintf("Primary key lookup failed: %v", msg.err), true)
+		return nil
+	}
+	t := msg.target
+	if len(msg.keys) == 0 {
+		m.setStatus(fmt.Sprintf("Table %s has no primary key; editing is disabled", sanitize(t.table)), true)
+		return nil
+	}
+	keyIdx := make([]int, len(msg.keys))
+	for i, k := range msg.keys {
+		keyIdx[i] = slices.Index(m.lastResult.Columns, k)
+		if keyIdx[i] < 0 {
+			m.setStatus(fmt.Sprintf("Primary key column %s is not in the result", sanitize(k)), true)
+			return nil
+		}
+	}
+	t.keyCols = msg.keys
+	t.keyTypes = make([]string, len(keyIdx))
+	for i, idx := range keyIdx {
+		if idx < len(m.lastResult.ColumnTypes) {
+			t.keyTypes[i] = m.lastResult.ColumnTypes[idx]
+		}
+	}
+	keyVals := func(row []string) []string {
+		vals := make([]string, len(keyIdx))
+		for i, idx := range keyIdx {
+			if idx < len(row) {
+				vals[i] = row[idx]
+			}
+		}
+		return vals
+	}
+
+	switch t.kind {
+	case changeInsert:
+		return m.stageInserts(t, msg.rows, keyVals, msg.columns)
+	case changeDelete:
+		staged := 0
+		for _, row := range msg.rows {
+			d := t
+			d.keyVals = keyVals(row)
+			if slices.ContainsFunc(m.editSt.pending, func(p pendingEdit) bool {
+				return p.kind == changeDelete && p.dsn == d.dsn && p.rowKey() == d.rowKey()
+			}) {
+				continue
+			}
+			m.editSt.pending = append(m.editSt.pending, d)
+			staged++
+		}
+		return m.showStagedRows(fmt.Sprintf("Staged %d DELETE(s)", staged))
+	}
+
+	if slices.Contains(msg.keys, t.column) {
+		m.setStatus("Primary key columns cannot be edited", true)
+		return nil
+	}
+	if len(msg.columns) > 0 && !slices.Contains(msg.columns, t.column) {
+		m.setStatus(fmt.Sprintf("%s is not a column of %s", sanitize(t.column), sanitize(t.table)), true)
+		return nil
+	}
+	t.keyVals = keyVals(msg.rows[0])
+
+	switch t.oldValue {
+	case "NULL":
+		t.null = true
+	case `""`:
+	default:
+		t.value = t.oldValue
+	}
+	m.editSt.target = t
+	m.editSt.input.SetValue(t.value)
+	m.editSt.input.CursorEnd()
+	m.editSt.input.Focus()
+	m.mode = editMode
+	m.setStatus(fmt.Sprintf("Editing %s.%s", sanitize(t.table), sanitize(t.column)), false)
+	return textinput.Blink
+}
+
+// stageInserts stages one INSERT per row, copying every result column except
+// the primary key, derived columns and, when the table's columns are known,
+// aliased ones. The copy is made by the database from the row with the same
+// key, so values the grid only shows as text (binary, dates, times in the
+// display zone) are copied exactly. With no rows it stages a single row of
+// defaults.
+func (m *model) stageInserts(t pendingEdit, rows [][]string, keyVals func([]string) []string, tableCols []string) tea.Cmd {
+	if len(rows) == 0 {
+		m.editSt.pending = append(m.editSt.pending, t)
+		return m.showStagedRows("Staged blank INSERT")
+	}
+	var cols []string
+	for i, c := range m.lastResult.Columns {
+		if slices.Contains(t.keyCols, c) || derivedColumn(m.lastResult, i) || (len(tableCols) > 0 && !slices.Contains(tableCols, c)) {
+			continue
+		}
+		cols = append(cols, c)
+	}
+	if len(cols) == 0 {
+		m.setStatus("No columns to copy besides the primary key", true)
+		return nil
+	}
+	for _, row := range rows {
+		ins := t
+		ins.keyVals = keyVals(row)
+		ins.insertCols = cols
+		m.editSt.pending = append(m.editSt.pending, ins)
+	}
+	return m.showStagedRows(fmt.Sprintf("Staged %d duplicate INSERT(s)", len(rows)))
+}
+
+// showStagedRows opens the pending list on the newest changes so the
+// generated SQL is reviewed before anything runs.
+func (m *model) showStagedRows(status string) tea.Cmd {
+	m.clearVisual()
+	m.editSt.cursor = len(m.editSt.pending) - 1
+	m.mode = pendingMode
+	m.setStatus(fmt.Sprintf("%s — review and press a to apply", status), false)
+	return nil
+}
+
+func (m model) updateEdit(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	t := &m.editSt.target
+	switch msg.Type {
+	case tea.KeyEsc:
+		m.editSt.input.Blur()
+		m.mode = m.editSt.prevMode
+		m.setStatus("Edit cancelled", false)
+		return m, nil
+	case tea.KeyCtrlN:
+		t.null = !t.null
+		return m, nil
+	case tea.KeyEnter:
+		t.value = m.editSt.input.Value()
+		if t.cell() == t.oldValue {
+			m.editSt.input.Blur()
+			m.mode = m.editSt.prevMode
+			m.setStatus("Value unchanged", false)
+			return m, nil
+		}
+		m.stageEdit(*t)
+		m.editSt.input.Blur()
+		m.mode = m.editSt.prevMode
+		m.setStatus(fmt.Sprintf("Staged %s.%s (%d pending) — W to review", sanitize(t.table), sanitize(t.column), len(m.editSt.pending)), false)
+		return m, nil
+	}
+	if t.null && msg.Type == tea.KeyRunes {
+		t.null = false
+	}
+	var cmd tea.Cmd
+	m.editSt.input, cmd = m.editSt.input.Update(msg)
+	return m, cmd
+}
+
+// stageEdit adds e to the pending list, replacing an earlier edit of the
+// same cell.
+func (m *model) stageEdit(e pendingEdit) {
+	for i, p := range m.editSt.pending {
+		if p.kind == changeUpdate && p.sameCell(e) {
+			e.oldValue = p.oldValue
+			m.editSt.pending[i] = e
+			return
+		}
+	}
+	m.editSt.pending = append(m.editSt.pending, e)
+}
+
+// openPendingEdits shows the staged changes (PENDING mode).
+func (m model) openPendingEdits() (tea.Model, tea.Cmd) {
+	if len(m.editSt.pending) == 0 {
+		m.setStatus("No pending changes", false)
+		return m, nil
+	}
+	m.editSt.cursor = min(m.editSt.cursor, len(m.editSt.pending)-1)
+	m.mode = pendingMode
+	m.setStatus(fmt.Sprintf("%d pending change(s)", len(m.editSt.pending)), false)
+	return m, nil
+}
+
+func (m model) updatePending(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	if m.editSt.applying {
+		return m, nil
+	}
+	switch msg.Type {
+	case tea.KeyEsc:
+		m.mode = normalMode
+		m.setStatus("Normal mode", false)
+	case tea.KeyDown:
+		moveCursor(&m.editSt.cursor, len(m.editSt.pending), 1)
+	case tea.KeyUp:
+		moveCursor(&m.editSt.cursor, len(m.editSt.pending), -1)
+	case tea.KeyEnter:
+		return m.applyPendingEdits()
+	case tea.KeyRunes:
+		if msg.Alt {
+			break
+		}
+		switch string(msg.Runes) {
+		case "q":
+			m.mode = normalMode
+			m.setStatus("Normal mode", false)
+		case "j":
+			moveCursor(&m.editSt.cursor, len(m.editSt.pending), 1)
+		case "k":
+			moveCursor(&m.editSt.cursor, len(m.editSt.pending), -1)
+		case "a":
+			return m.applyPendingEdits()
+		case "d":
+			if m.editSt.cursor < len(m.editSt.pending) {
+				m.editSt.pending = slices.Delete(m.editSt.pending, m.editSt.cursor, m.editSt.cursor+1)
+				m.editSt.cursor = min(m.editSt.cursor, max(len(m.editSt.pending)-1, 0))
+				m.setStatus(fmt.Sprintf("Dropped change (%d pending)", len(m.editSt.pending)), false)
+				if len(m.editSt.pending) == 0 {
+					m.mode = normalMode
+				}
+			}
+		case "X":
+			n := len(m.editSt.pending)
+			m.editSt.pending = nil
+			m.editSt.cursor = 0
+			m.mode = normalMode
+			m.setStatus(fmt.Sprintf("Discarded %d change(s)", n), false)
+		}
+	}
+	return m, nil
+}
+
+// applyPendingEdits runs every pending change in one transaction, then
+// re-reads the updated rows.
+func (m model) applyPendingEdits() (tea.Model, tea.Cmd) {
+	if m.activeReadOnly() {
+		m.setStatus(fmt.Sprintf("Connection %s is read-only", sanitize(m.connMgr.ActiveName())), true)
+		return m, nil
+	}
+	dsn := m.connMgr.ActiveDSN()
+	for _, e := range m.editSt.pending {
+		if e.dsn != dsn {
+			m.setStatus("Pending changes were made on another connection; switch back or discard them", true)
+			return m, nil
+		}
+	}
+
+	adapter := m.activeDB()
+	loc := m.displayLocation()
+	edits := slices.Clone(m.editSt.pending)
+	stmts := make([]db.Statement, len(edits))
+	for i, e := range edits {
+		stmts[i] = e.statement(adapter.QuoteIdentifier, adapter.Type())
+	}
+	m.editSt.seq++
+	m.editSt.applying = true
+	m.setStatus(fmt.Sprintf("Applying %d change(s)...", len(edits)), false)
+
+	seq := m.editSt.seq
+	return m, func() tea.Msg {
+		ctx, cancel := context.WithTimeout(context.Background(), queryTimeout)
+		defer cancel()
+		if err := adapter.Exec(ctx, stmts); err != nil {
+			return editsAppliedMsg{seq: seq, edits: edits, err: err}
+		}
+		// Re-read each edited row so triggers and defaults show up. A failed
+		// re-read is not an error: the local copy gets the typed values.
+		rows := make(map[string]map[string]editedCell)
+		for _, e := range edits {
+			if e.kind != changeUpdate {
+				continue
+			}
+			key := e.rowKey()
+			if _, ok := rows[key]; ok {
+				continue
+			}
+			res, err := adapter.Query(ctx, e.selectRowQuery(adapter.QuoteIdentifier, adapter.Type()))
+			if err != nil || len(res.Rows) != 1 {
+				continue
+			}
+			res = zoneResult(res, adapter.Type(), loc)
+			cells := make(map[string]editedCell, len(res.Columns))
+			for i, c := range res.Columns {
+				if i < len(res.Rows[0]) {
+					cell := editedCell{value: res.Rows[0][i], null: res.Rows[0][i] == "NULL"}
+					if len(res.Nulls) == 1 && i < len(res.Nulls[0]) {
+						cell.null = res.Nulls[0][i]
+					}
+					cells[c] = cell
+				}
+			}
+			rows[key] = cells
+		}
+		return editsAppliedMsg{seq: seq, edits: edits, rows: rows}
+	}
+}
+
+// handleEditsApplied updates the result grid after a successful apply, or
+// keeps the pending list when the transaction was rolled back. Inserted and
+// deleted rows are picked up by re-running the query.
+func (m *model) handleEditsApplied(msg editsAppliedMsg) tea.Cmd {
+	if msg.seq != m.editSt.seq {
+		return nil
+	}
+	m.editSt.applying = false
+	if msg.err != nil {
+		m.setStatus(fmt.Sprintf("Apply failed, rolled back: %v", msg.err), true)
+		return nil
+	}
+	m.editSt.pending = nil
+	m.editSt.cursor = 0
+	if m.mode == pendingMode {
+		m.mode = normalMode
+	}
+	status := fmt.Sprintf("Applied %d change(s)", len(msg.edits))
+	for _, e := range msg.edits {
+		if e.kind != changeUpdate {
+			m.setStatus(status+" — re-running query", false)
+			return m.rerunLastQuery()
+		}
+	}
+	m.refreshEditedRows(msg.edits, msg.rows)
+	m.setStatus(status, false)
+	return nil
+}
+
+// rerunLastQuery executes the query behind the current result again without
+// adding it to the history.
+func (m *model) rerunLastQuery() tea.Cmd {
+	if m.queryCancel != nil {
+		m.queryCancel()
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	m.querySeq++
+	m.queryCancel = cancel
+	return executeQueryCmd(ctx, m.activeDB(), m.lastQuery, m.querySeq)
+}
+
+// refreshEditedRows copies re-read (or, failing that, typed) values and
+// their NULL flags into the rows of the current result that match the
+// edited keys.
+func (m *model) refreshEditedRows(edits []pendingEdit, fresh map[string]map[string]editedCell) {
+	if dbutil.SingleTableName(m.lastQuery) == "" {
+		return
+	}
+	cols := m.lastResult.Columns
+	changed := false
+	for _, e := range edits {
+		if dbutil.SingleTableName(m.lastQuery) != e.table {
+			continue
+		}
+		cells := fresh[e.rowKey()]
+		if cells == nil {
+			cells = map[string]editedCell{e.column: {value: e.cell(), null: e.null}}
+		}
+		for r, row := range m.lastResult.Rows {
+			if !e.matchesRow(cols, row) {
+				continue
+			}
+			updated := slices.Clone(row)
+			var nulls []bool
+			if r < len(m.lastResult.Nulls) {
+				nulls = padNulls(m.lastResult.Nulls[r], len(updated))
+			}
+			for i, c := range cols {
+				if v, ok := cells[c]; ok && i < len(updated) {
+					updated[i] = v.value
+					if nulls != nil {
+						nulls[i] = v.null
+					}
+				}
+			}
+			m.lastResult.Rows[r] = updated
+			if nulls != nil {
+				m.lastResult.Nulls[r] = nulls
+			}
+			changed = true
+		}
+	}
+	if !changed {
+		return
+	}
+	cursor := m.table.Cursor()
+	m.applySortedResult()
+	m.table.SetCursor(cursor)
+	m.viewportDirty = true
+	m.syncViewport()
+	m.syncCompareTables()
+}
+
+// padNulls returns a copy of nulls with at least n flags.
+func padNulls(nulls []bool, n int) []bool {
+	out := make([]bool, max(len(nulls), n))
+	copy(out, nulls)
+	return out
+}
+
+// cell returns the new value in result cell encoding.
+func (e pendingEdit) cell() string {
+	switch {
+	case e.null:
+		return "NULL"
+	case e.value == "":
+		return `""`
+	default:
+		return e.value
+	}
+}
+
+func (e pendingEdit) sameCell(o pendingEdit) bool {
+	return e.dsn == o.dsn && e.table == o.table && e.column == o.column && slices.Equal(e.keyVals, o.keyVals)
+}
+
+func (e pendingEdit) rowKey() string {
+	return e.table + "\x00" + strings.Join(e.keyVals, "\x00")
+}
+
+// matchesRow reports whether row (with the given column names) has the
+// edit's primary key values.
+func (e pendingEdit) matchesRow(cols []string, row []string) bool {
+	for i, k := range e.keyCols {
+		idx := slices.Index(cols, k)
+		if idx < 0 || idx >= len(row) || row[idx] != e.keyVals[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// keyArg converts a primary key cell to a bind argument.
+func keyArg(cell string) any {
+	if cell == `""` {
+		return ""
+	}
+	return cell
+}
+
+// quoteTableName quotes each part of a possibly schema-qualified name.
+func quoteTableName(name string, quote func(string) string) string {
+	schema, table := dbutil.SplitTableName(name)
+	if schema == "" {
+		return quote(table)
+	}
+	return quote(schema) + "." + quote(table)
+}
+
+// statement builds the parameterized single-row statement for e.
+func (e pendingEdit) statement(quote func(string) string, dbType string) db.Statement {
+	if e.kind == changeInsert {
+		return e.insertStatement(quote, dbType)
+	}
+	var b strings.Builder
+	var args []any
+	switch e.kind {
+	case changeDelete:
+		b.WriteString("DELETE FROM " + quoteTableName(e.table, quote) + " WHERE ")
+	default:
+		fmt.Fprintf(&b, "UPDATE %s SET %s = %s WHERE ", quoteTableName(e.table, quote), quote(e.column), dbutil.BindVar(dbType, 1))
+		var value any = e.value
+		if e.null {
+			value = nil
+		}
+		args = append(args, value)
+	}
+	where, args := e.bindKey(quote, dbType, args)
+	b.WriteString(where)
+	return db.Statement{SQL: b.String(), Args: args, ExpectRows: 1}
+}
+
+// bindKey renders the primary key condition with bind variables numbered
+// after args, and returns args with the key values appended.
+func (e pendingEdit) bindKey(quote func(string) string, dbType string, args []any) (string, []any) {
+	parts := make([]string, len(e.keyCols))
+	for i, k := range e.keyCols {
+		parts[i] = quote(k) + " = " + dbutil.BindVar(dbType, len(args)+1)
+		args = append(args, keyArg(e.keyVals[i]))
+	}
+	return strings.Join(parts, " AND "), args
+}
+
+// copyColumns returns the quoted column list of a duplicated row.
+func (e pendingEdit) copyColumns(quote func(string) string) string {
+	cols := make([]string, len(e.insertCols))
+	for i, c := range e.insertCols {
+		cols[i] = quote(c)
+	}
+	return strings.Join(cols, ", ")
+}
+
+// insertStatement builds the INSERT for a duplicated or blank row. A
+// duplicate copies the source row inside the database.
+func (e pendingEdit) insertStatement(quote func(string) string, dbType string) db.Statement {
+	table := quoteTableName(e.table, quote)
+	if len(e.insertCols) == 0 {
+		return db.Statement{SQL: "INSERT INTO " + table + defaultValuesClause(dbType), ExpectRows: 1}
+	}
+	cols := e.copyColumns(quote)
+	where, args := e.bindKey(quote, dbType, nil)
+	return db.Statement{
+		SQL:        fmt.Sprintf("INSERT INTO %s (%s) SELECT %s FROM %s WHERE %s", table, cols, cols, table, where),
+		Args:       args,
+		ExpectRows: 1,
+	}
+}
+
+// defaultValuesClause inserts a row made only of column defaults.
+func defaultValuesClause(dbType string) string {
+	if dbType == "mysql" {
+		return " () VALUES ()"
+	}
+	return " DEFAULT VALUES"
+}
+
+// whereKey renders the primary key condition with literal values.
+func (e pendingEdit) whereKey(quote func(string) string, dbType string) string {
+	parts := make([]string, len(e.keyCols))
+	for i, k := range e.keyCols {
+		parts[i] = quote(k) + " = " + export.SQLLiteral(e.keyVals[i], e.keyTypes[i], dbType)
+	}
+	return strings.Join(parts, " AND ")
+}
+
+// preview renders the statement with literal values for review.
+func (e pendingEdit) preview(quote func(string) string, dbType string) string {
+	table := quoteTableName(e.table, quote)
+	switch e.kind {
+	case changeInsert:
+		if len(e.insertCols) == 0 {
+			return "INSERT INTO " + table + defaultValuesClause(dbType) + ";"
+		}
+		cols := e.copyColumns(quote)
+		return fmt.Sprintf("INSERT INTO %s (%s) SELECT %s FROM %s WHERE %s;", table, cols, cols, table, e.whereKey(quote, dbType))
+	case changeDelete:
+		return fmt.Sprintf("DELETE FROM %s WHERE %s;", table, e.whereKey(quote, dbType))
+	}
+	value := "NULL"
+	if !e.null {
+		switch e.value {
+		case "NULL", `""`:
+			// Typed text that collides with the cell markers stays a string.
+			value = "'" + e.value + "'"
+		default:
+			value = export.SQLLiteral(e.cell(), e.colType, dbType)
+		}
+	}
+	return fmt.Sprintf("UPDATE %s SET %s = %s WHERE %s;", table, quote(e.column), value, e.whereKey(quote, dbType))
+}
+
+// selectRowQuery re-reads the edited row after the update.
+func (e pendingEdit) selectRowQuery(quote func(string) string, dbType string) string {
+	return fmt.Sprintf("SELECT * FROM %s WHERE %s", quoteTableName(e.table, quote), e.whereKey(quote, dbType))
+}
+
+func (m model) renderWithEditOverlay(background string) string {
+	modalWidth := calcModalWidth(m.width, 72)
+	innerWidth := max(modalWidth-6, 1)
+	t := m.editSt.target
+	adapter := m.activeDB()
+
+	titleStyle := lipgloss.NewStyle().Bold(true).Foreground(accentColor).MarginBottom(1)
+	labelStyle := lipgloss.NewStyle().Foreground(mutedTextColor).Background(panelBackground)
+	valueStyle := lipgloss.NewStyle().Foreground(textColor).Background(panelBackground).Inline(true).MaxWidth(max(innerWidth-5, 1))
+	sqlStyle := lipgloss.NewStyle().Foreground(keywordColor).Background(panelBackground).Width(innerWidth)
+
+	keys := make([]string, len(t.keyCols))
+	for i, k := range t.keyCols {
+		keys[i] = k + "=" + t.keyVals[i]
+	}
+
+	var b strings.Builder
+	b.WriteString(labelStyle.Render("Row: "))
+	b.WriteString(valueStyle.Render(sanitize(strings.Join(keys, ", "))))
+	b.WriteString("\n")
+	b.WriteString(labelStyle.Render("Old: "))
+	b.WriteString(valueStyle.Render(sanitize(t.oldValue)))
+	b.WriteString("\n\n")
+	if t.null {
+		b.WriteString(labelStyle.Render("New: "))
+		b.WriteString(lipgloss.NewStyle().Foreground(keywordColor).Background(panelBackground).Render("NULL"))
+	} else {
+		b.WriteString(m.editSt.input.View())
+	}
+	b.WriteString("\n\n")
+	preview := t
+	preview.value = m.editSt.input.Value()
+	b.WriteString(sqlStyle.Render(sanitize(preview.preview(adapter.QuoteIdentifier, adapter.Type()))))
+	b.WriteString("\n\n")
+	b.WriteString(labelStyle.Render("Enter:stage C-n:NULL Esc:cancel"))
+
+	title := titleStyle.Render(fmt.Sprintf("Edit %s.%s", sanitize(t.table), sanitize(t.column)))
+	modal := lipgloss.NewStyle().
+		Border(lipgloss.RoundedBorder()).
+		BorderForeground(accentColor).
+		Padding(1, 2).
+		Width(modalWidth).
+		Background(panelBackground).
+		Render(title + "\n" + b.String())
+	return overlayModal(m.width, background, modal)
+}
+
+func (m model) renderWithPendingOverlay(background string) string {
+	modalWidth := calcModalWidth(m.width, 90)
+	innerWidth := max(modalWidth-6, 1)
+	adapter := m.activeDB()
+
+	titleStyle := lipgloss.NewStyle().Bold(true).Foreground(accentColor).MarginBottom(1)
+	itemStyle := lipgloss.NewStyle().Foreground(textColor).Background(panelBackground).Width(innerWidth).Padding(0, 1)
+	selectedStyle := lipgloss.NewStyle().Foreground(panelBackground).Background(accentColor).Bold(true).Width(innerWidth).Padding(0, 1)
+	mutedStyle := lipgloss.NewStyle().Foreground(mutedTextColor).Background(panelBackground)
+
+	maxVisible := max(min(m.height-10, len(m.editSt.pending)), 1)
+	start := 0
+	if m.editSt.cursor >= maxVisible {
+		start = m.editSt.cursor - maxVisible + 1
+	}
+	end := min(start+maxVisible, len(m.editSt.pending))
+
+	var b strings.Builder
+	for i := start; i < end; i++ {
+		line := sanitize(m.editSt.pending[i].preview(adapter.QuoteIdentifier, adapter.Type()))
+		if i == m.editSt.cursor {
+			b.WriteString(selectedStyle.Inline(true).MaxWidth(innerWidth).Render(line))
+		} else {
+			b.WriteString(itemStyle.Inline(true).MaxWidth(innerWidth).Render(line))
+		}
+		b.WriteByte('\n')
+	}
+	b.WriteByte('\n')
+	if m.editSt.applying {
+		b.WriteString(mutedStyle.Render("Applying..."))
+	} else {
+		b.WriteString(mutedStyle.Render("a/Enter:apply all (one transaction) d:drop X:discard all Esc:close"))
+	}
+
+	title := titleStyle.Render(fmt.Sprintf("Pending Changes (%d)", len(m.editSt.pending)))
+	modal := lipgloss.NewStyle().
+		Border(lipgloss.RoundedBorder()).
+		BorderForeground(accentColor).
+		Padding(1, 2).
+		Width(modalWidth).
+		Background(panelBackground).
+		Render(title + "\n" + b.String())
+	return overlayModal(m.width, background, modal)
+}
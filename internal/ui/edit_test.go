@@ -0,0 +1,371 @@
+package ui
+
+import (
+	"context"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/charmbracelet/bubbles/textinput"
+	tea "github.com/charmbracelet/bubbletea"
+
+	"github.com/kwrkb/asql/internal/db/sqlite"
+	"github.com/kwrkb/asql/internal/profile"
+)
+
+// newEditModel returns a model showing "SELECT id, flag, note FROM items"
+// from a fresh in-memory SQLite database.
+func newEditModel(t *testing.T) (*model, *sqlite.Adapter) {
+	t.Helper()
+	adapter, err := sqlite.Open(":memory:")
+	if err != nil {
+		t.Fatalf("sqlite.Open: %v", err)
+	}
+	t.Cleanup(func() { adapter.Close() })
+
+	ctx := context.Background()
+	for _, q := range []string{
+		"CREATE TABLE items (id INTEGER PRIMARY KEY, flag TEXT, note TEXT)",
+		"INSERT INTO items VALUES (1, 'bad', 'x'), (2, 'ok', NULL)",
+	} {
+		if _, err := adapter.Query(ctx, q); err != nil {
+			t.Fatalf("%s: %v", q, err)
+		}
+	}
+
+	m := newTestModel()
+	m.connMgr = newConnManager("test", ":memory:", adapter)
+	m.editSt.input = textinput.New()
+	m.lastQuery = "SELECT id, flag, note FROM items ORDER BY id"
+	res, err := adapter.Query(ctx, m.lastQuery)
+	if err != nil {
+		t.Fatal(err)
+	}
+	m.lastResult = res
+	m.applyResult(res)
+	m.mode = normalMode
+	return m, adapter
+}
+
+// runEditCmd executes cmd and feeds its message back into the model.
+func runEditCmd(t *testing.T, m model, cmd tea.Cmd) model {
+	t.Helper()
+	if cmd == nil {
+		t.Fatalf("expected a command (status %q)", m.statusText)
+	}
+	next, _ := m.Update(cmd())
+	return next.(model)
+}
+
+func TestPendingEdit_StatementAndPreview(t *testing.T) {
+	quote := func(s string) string { return `"` + s + `"` }
+	e := pendingEdit{
+		table:    "public.items",
+		column:   "flag",
+		colType:  "TEXT",
+		keyCols:  []string{"id", "region"},
+		keyVals:  []string{"7", "eu"},
+		keyTypes: []string{"INTEGER", "TEXT"},
+		value:    "it's",
+	}
+	st := e.statement(quote, "postgres")
+	if want := `UPDATE "public"."items" SET "flag" = $1 WHERE "id" = $2 AND "region" = $3`; st.SQL != want {
+		t.Errorf("SQL = %q, want %q", st.SQL, want)
+	}
+	if len(st.Args) != 3 || st.Args[0] != "it's" || st.Args[1] != "7" || st.ExpectRows != 1 {
+		t.Errorf("unexpected args %v / expect %d", st.Args, st.ExpectRows)
+	}
+	if got, want := e.preview(quote, "postgres"), `UPDATE "public"."items" SET "flag" = 'it''s' WHERE "id" = 7 AND "region" = 'eu';`; got != want {
+		t.Errorf("preview = %q, want %q", got, want)
+	}
+
+	e.null = true
+	if st := e.statement(quote, "sqlite"); st.Args[0] != nil || !strings.Contains(st.SQL, "= ?") {
+		t.Errorf("NULL edit: %q %v", st.SQL, st.Args)
+	}
+	if got := e.preview(quote, "sqlite"); !strings.Contains(got, `SET "flag" = NULL`) {
+		t.Errorf("NULL preview = %q", got)
+	}
+}
+
+func TestCellEdit_StageAndApply(t *testing.T) {
+	m, adapter := newEditModel(t)
+	m.colCursor = 1 // flag
+
+	next, cmd := m.updateNormal(runeMsg("E"))
+	rm := runEditCmd(t, next.(model), cmd)
+	if rm.mode != editMode {
+		t.Fatalf("expected editMode, got %q (%s)", rm.mode, rm.statusText)
+	}
+	if rm.editSt.input.Value() != "bad" {
+		t.Errorf("expected prefilled value, got %q", rm.editSt.input.Value())
+	}
+
+	rm.editSt.input.SetValue("good")
+	next, _ = rm.updateEdit(tea.KeyMsg{Type: tea.KeyEnter})
+	rm = next.(model)
+	if rm.mode != normalMode || len(rm.editSt.pending) != 1 {
+		t.Fatalf("expected one staged edit, mode=%q pending=%d", rm.mode, len(rm.editSt.pending))
+	}
+
+	// Second row's note: NULL -> "n", edited from DETAIL.
+	rm.table.MoveDown(1)
+	rm.mode = detailMode
+	rm.detail.fieldCursor = 2
+	next, cmd = rm.updateDetail(runeMsg("e"))
+	rm = runEditCmd(t, next.(model), cmd)
+	if !rm.editSt.target.null {
+		t.Error("expected NULL cell to start as NULL")
+	}
+	next, _ = rm.updateEdit(runeMsg("n"))
+	rm = next.(model)
+	next, _ = rm.updateEdit(tea.KeyMsg{Type: tea.KeyEnter})
+	rm = next.(model)
+	if rm.mode != detailMode || len(rm.editSt.pending) != 2 {
+		t.Fatalf("expected return to DETAIL with 2 pending, got %q/%d", rm.mode, len(rm.editSt.pending))
+	}
+
+	rm.mode = normalMode
+	next, _ = rm.updateNormal(runeMsg("W"))
+	rm = next.(model)
+	if rm.mode != pendingMode {
+		t.Fatalf("expected pendingMode, got %q", rm.mode)
+	}
+	next, cmd = rm.updatePending(runeMsg("a"))
+	rm = runEditCmd(t, next.(model), cmd)
+	if rm.statusError || len(rm.editSt.pending) != 0 || rm.mode != normalMode {
+		t.Fatalf("apply failed: %s", rm.statusText)
+	}
+
+	res, err := adapter.Query(context.Background(), "SELECT flag, note FROM items ORDER BY id")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := res.Rows; got[0][0] != "good" || got[1][1] != "n" {
+		t.Errorf("database not updated: %v", got)
+	}
+	if got := rm.lastResult.Rows; got[0][1] != "good" || got[1][2] != "n" {
+		t.Errorf("result rows not refreshed: %v", got)
+	}
+	if rm.lastResult.Nulls[1][2] {
+		t.Error("expected the re-read row to clear the NULL flag")
+	}
+	if rm.table.Cursor() != 1 {
+		t.Errorf("expected cursor to stay on row 2, got %d", rm.table.Cursor())
+	}
+}
+
+func TestRefreshEditedRowsKeepsNullFlags(t *testing.T) {
+	m, _ := newEditModel(t)
+	// Without a re-read row, the typed value and its NULL flag are used.
+	e := pendingEdit{kind: changeUpdate, table: "items", keyCols: []string{"id"}, keyVals: []string{"1"}, column: "note", null: true}
+	m.refreshEditedRows([]pendingEdit{e}, nil)
+	if m.lastResult.Rows[0][2] != "NULL" || !m.lastResult.Nulls[0][2] {
+		t.Errorf("row = %v, nulls = %v", m.lastResult.Rows[0], m.lastResult.Nulls[0])
+	}
+
+	// A re-read text value "NULL" is not NULL.
+	fresh := map[string]map[string]editedCell{e.rowKey(): {"note": {value: "NULL"}}}
+	m.refreshEditedRows([]pendingEdit{e}, fresh)
+	if m.lastResult.Rows[0][2] != "NULL" || m.lastResult.Nulls[0][2] {
+		t.Errorf("row = %v, nulls = %v", m.lastResult.Rows[0], m.lastResult.Nulls[0])
+	}
+}
+
+func TestCellEdit_RejectsUnsafeTargets(t *testing.T) {
+	t.Run("primary key column", func(t *testing.T) {
+		m, _ := newEditModel(t)
+		m.colCursor = 0
+		next, cmd := m.updateNormal(runeMsg("E"))
+		rm := runEditCmd(t, next.(model), cmd)
+		if rm.mode != normalMode || !rm.statusError {
+			t.Errorf("expected rejection, got mode %q status %q", rm.mode, rm.statusText)
+		}
+	})
+
+	t.Run("key not in result", func(t *testing.T) {
+		m, adapter := newEditModel(t)
+		m.lastQuery = "SELECT flag FROM items"
+		res, _ := adapter.Query(context.Background(), m.lastQuery)
+		m.lastResult = res
+		m.applyResult(res)
+		next, cmd := m.updateNormal(runeMsg("E"))
+		rm := runEditCmd(t, next.(model), cmd)
+		if !strings.Contains(rm.statusText, "not in the result") {
+			t.Errorf("unexpected status %q", rm.statusText)
+		}
+	})
+
+	t.Run("join", func(t *testing.T) {
+		m, _ := newEditModel(t)
+		m.lastQuery = "SELECT * FROM items a JOIN items b ON a.id = b.id"
+		_, cmd := m.updateNormal(runeMsg("E"))
+		if cmd != nil {
+			t.Error("expected no key lookup for a join")
+		}
+	})
+
+	t.Run("read-only profile", func(t *testing.T) {
+		m, _ := newEditModel(t)
+		m.colCursor = 1
+		m.profileSt.items = []profile.Profile{{Name: "test", DSN: ":memory:", ReadOnly: true}}
+		next, cmd := m.updateNormal(runeMsg("E"))
+		rm := next.(model)
+		if cmd != nil || !strings.Contains(rm.statusText, "read-only") {
+			t.Errorf("expected read-only rejection, got %q", rm.statusText)
+		}
+	})
+}
+
+func TestCellEdit_ApplyRollsBack(t *testing.T) {
+	m, adapter := newEditModel(t)
+	m.editSt.pending = []pendingEdit{
+		{dsn: ":memory:", table: "items", column: "flag", keyCols: []string{"id"}, keyVals: []string{"1"}, keyTypes: []string{"INTEGER"}, value: "changed"},
+		{dsn: ":memory:", table: "items", column: "flag", keyCols: []string{"id"}, keyVals: []string{"99"}, keyTypes: []string{"INTEGER"}, value: "ghost"},
+	}
+	m.mode = pendingMode
+	next, cmd := m.updatePending(tea.KeyMsg{Type: tea.KeyEnter})
+	rm := runEditCmd(t, next.(model), cmd)
+	if !rm.statusError || len(rm.editSt.pending) != 2 {
+		t.Fatalf("expected failure keeping pending edits, status %q", rm.statusText)
+	}
+	res, _ := adapter.Query(context.Background(), "SELECT flag FROM items WHERE id = 1")
+	if res.Rows[0][0] != "bad" {
+		t.Errorf("expected rollback, flag = %q", res.Rows[0][0])
+	}
+}
+
+func TestPendingEdit_InsertAndDeleteStatements(t *testing.T) {
+	quote := func(s string) string { return "`" + s + "`" }
+	ins := pendingEdit{
+		kind:       changeInsert,
+		table:      "items",
+		keyCols:    []string{"id"},
+		keyVals:    []string{"7"},
+		keyTypes:   []string{"INTEGER"},
+		insertCols: []string{"flag", "note"},
+	}
+	st := ins.statement(quote, "postgres")
+	if want := "INSERT INTO `items` (`flag`, `note`) SELECT `flag`, `note` FROM `items` WHERE `id` = $1"; st.SQL != want {
+		t.Errorf("SQL = %q, want %q", st.SQL, want)
+	}
+	if len(st.Args) != 1 || st.Args[0] != "7" {
+		t.Errorf("unexpected args %v", st.Args)
+	}
+	if got, want := ins.preview(quote, "mysql"), "INSERT INTO `items` (`flag`, `note`) SELECT `flag`, `note` FROM `items` WHERE `id` = 7;"; got != want {
+		t.Errorf("preview = %q, want %q", got, want)
+	}
+
+	blank := pendingEdit{kind: changeInsert, table: "items"}
+	if got := blank.statement(quote, "mysql").SQL; got != "INSERT INTO `items` () VALUES ()" {
+		t.Errorf("blank mysql insert = %q", got)
+	}
+	if got := blank.preview(quote, "postgres"); got != "INSERT INTO `items` DEFAULT VALUES;" {
+		t.Errorf("blank preview = %q", got)
+	}
+
+	del := pendingEdit{kind: changeDelete, table: "items", keyCols: []string{"id"}, keyVals: []string{"3"}, keyTypes: []string{"INTEGER"}}
+	st = del.statement(quote, "postgres")
+	if st.SQL != "DELETE FROM `items` WHERE `id` = $1" || len(st.Args) != 1 || st.Args[0] != "3" {
+		t.Errorf("delete = %q %v", st.SQL, st.Args)
+	}
+}
+
+// runUntilIdle feeds cmd's messages back into the model until no command
+// is left.
+func runUntilIdle(t *testing.T, m model, cmd tea.Cmd) model {
+	t.Helper()
+	for cmd != nil {
+		var next tea.Model
+		next, cmd = m.Update(cmd())
+		m = next.(model)
+	}
+	return m
+}
+
+func TestRowChange_DuplicateDeleteAndInsert(t *testing.T) {
+	m, adapter := newEditModel(t)
+
+	// Select both rows, stage them for deletion, and drop the second.
+	next, _ := m.updateNormal(runeMsg("V"))
+	rm := next.(model)
+	next, _ = rm.updateNormal(runeMsg("j"))
+	rm = next.(model)
+	next, cmd := rm.updateNormal(runeMsg("X"))
+	rm = runEditCmd(t, next.(model), cmd)
+	if rm.mode != pendingMode || len(rm.editSt.pending) != 2 || rm.visual.active {
+		t.Fatalf("expected 2 staged deletes, mode=%q pending=%d (%s)", rm.mode, len(rm.editSt.pending), rm.statusText)
+	}
+	next, _ = rm.updatePending(runeMsg("d"))
+	rm = next.(model)
+
+	// Duplicate the second row, and add a blank row.
+	rm.mode = normalMode
+	rm.table.SetCursor(1)
+	next, cmd = rm.updateNormal(runeMsg("D"))
+	rm = runEditCmd(t, next.(model), cmd)
+	rm.mode = normalMode
+	next, cmd = rm.updateNormal(runeMsg("o"))
+	rm = runEditCmd(t, next.(model), cmd)
+	if len(rm.editSt.pending) != 3 {
+		t.Fatalf("expected 3 pending changes, got %d", len(rm.editSt.pending))
+	}
+	quote := adapter.QuoteIdentifier
+	if got := rm.editSt.pending[1].preview(quote, "sqlite"); got != `INSERT INTO "items" ("flag", "note") SELECT "flag", "note" FROM "items" WHERE "id" = 2;` {
+		t.Errorf("duplicate preview = %q", got)
+	}
+
+	next, cmd = rm.updatePending(runeMsg("a"))
+	rm = runUntilIdle(t, next.(model), cmd)
+	if rm.statusError {
+		t.Fatalf("apply failed: %s", rm.statusText)
+	}
+
+	want := [][]string{{"2", "ok", "NULL"}, {"3", "ok", "NULL"}, {"4", "NULL", "NULL"}}
+	res, err := adapter.Query(context.Background(), "SELECT id, flag, note FROM items ORDER BY id")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !slices.EqualFunc(res.Rows, want, slices.Equal) {
+		t.Errorf("database rows = %v, want %v", res.Rows, want)
+	}
+	if !slices.EqualFunc(rm.lastResult.Rows, want, slices.Equal) {
+		t.Errorf("query not re-run, rows = %v", rm.lastResult.Rows)
+	}
+}
+
+func TestRowChange_DuplicateCopiesStoredValues(t *testing.T) {
+	m, adapter := newEditModel(t)
+	ctx := context.Background()
+	for _, q := range []string{
+		"CREATE TABLE blobs (id INTEGER PRIMARY KEY, data BLOB)",
+		"INSERT INTO blobs VALUES (1, x'00ff10')",
+	} {
+		if _, err := adapter.Query(ctx, q); err != nil {
+			t.Fatalf("%s: %v", q, err)
+		}
+	}
+	m.lastQuery = "SELECT id, data FROM blobs"
+	res, err := adapter.Query(ctx, m.lastQuery)
+	if err != nil {
+		t.Fatal(err)
+	}
+	m.lastResult = res
+	m.applyResult(res)
+
+	next, cmd := m.updateNormal(runeMsg("D"))
+	rm := runEditCmd(t, next.(model), cmd)
+	next, cmd = rm.updatePending(runeMsg("a"))
+	rm = runUntilIdle(t, next.(model), cmd)
+	if rm.statusError {
+		t.Fatalf("apply failed: %s", rm.statusText)
+	}
+	res, err = adapter.Query(ctx, "SELECT id, hex(data) FROM blobs ORDER BY id")
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := [][]string{{"1", "00FF10"}, {"2", "00FF10"}}
+	if !slices.EqualFunc(res.Rows, want, slices.Equal) {
+		t.Errorf("rows = %v, want %v", res.Rows, want)
+	}
+}
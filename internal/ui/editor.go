@@ -0,0 +1,374 @@
+package ui
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/charmbracelet/bubbles/textarea"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+
+	"github.com/kwrkb/asql/internal/db/dbutil"
+)
+
+// sqlEditor is the query editor. The embedded textarea does the editing;
+// View draws the text itself so that it can be highlighted, which textarea
+// has no hooks for.
+type sqlEditor struct {
+	textarea.Model
+	dialect dbutil.Dialect
+	scroll  int // first visual row on screen
+}
+
+// hlClass is how one character of the editor is highlighted.
+type hlClass int
+
+const (
+	hlText hlClass = iota
+	hlKeyword
+	hlString
+	hlNumber
+	hlComment
+	hlIdent
+	hlParam
+	hlUnterminated // literal or comment that never ends
+	hlBracket      // the bracket at the cursor and its partner
+	hlBadBracket   // a bracket at the cursor without a partner
+)
+
+var (
+	numberColor = lipgloss.Color("#C084FC")
+	paramColor  = lipgloss.Color("#F472B6")
+	errorBg     = lipgloss.Color("#7F1D1D")
+)
+
+// hlStyle applies class c on top of the line style base.
+func hlStyle(base lipgloss.Style, c hlClass) lipgloss.Style {
+	switch c {
+	case hlKeyword:
+		return base.Foreground(keywordColor).Bold(true)
+	case hlString:
+		return base.Foreground(successColor)
+	case hlNumber:
+		return base.Foreground(numberColor)
+	case hlComment:
+		return base.Foreground(mutedTextColor).Italic(true)
+	case hlIdent:
+		return base.Foreground(accentColor)
+	case hlParam:
+		return base.Foreground(paramColor)
+	case hlUnterminated:
+		return base.Foreground(textColor).Background(errorBg)
+	case hlBracket:
+		return base.Foreground(accentColor).Background(panelBorder).Bold(true)
+	case hlBadBracket:
+		return base.Foreground(errorColor).Background(errorBg).Bold(true)
+	}
+	return base
+}
+
+// tokenClasses maps token kinds to their highlight.
+var tokenClasses = map[dbutil.TokenKind]hlClass{
+	dbutil.TokenKeyword:     hlKeyword,
+	dbutil.TokenString:      hlString,
+	dbutil.TokenDollarBody:  hlString,
+	dbutil.TokenNumber:      hlNumber,
+	dbutil.TokenComment:     hlComment,
+	dbutil.TokenQuotedIdent: hlIdent,
+	dbutil.TokenParam:       hlParam,
+}
+
+var closingBracket = map[byte]byte{'(': ')', '[': ']', '{': '}'}
+
+// highlightClasses returns the class of every rune of text. cursor is the
+// rune offset of the cursor: a bracket under or just before it is shown
+// with its partner.
+func highlightClasses(text string, dialect dbutil.Dialect, cursor int) []hlClass {
+	classes := make([]hlClass, 0, utf8.RuneCountInString(text))
+	partner := make(map[int]int) // rune offset of a bracket -> its partner, -1 if none
+	type bracket struct {
+		pos   int
+		close byte
+	}
+	var open []bracket
+	for _, tok := range dbutil.Tokenize(text, dialect) {
+		c := tokenClasses[tok.Kind]
+		if tok.Unterminated {
+			c = hlUnterminated
+		}
+		if tok.Kind == dbutil.TokenPunct && tok.End-tok.Start == 1 {
+			switch text[tok.Start] {
+			case '(', '[', '{':
+				open = append(open, bracket{len(classes), closingBracket[text[tok.Start]]})
+				partner[len(classes)] = -1
+			case ')', ']', '}':
+				pos := len(classes)
+				partner[pos] = -1
+				if k := len(open) - 1; k >= 0 && open[k].close == text[tok.Start] {
+					partner[pos], partner[open[k].pos] = open[k].pos, pos
+					open = open[:k]
+				}
+			}
+		}
+		for range tok.Text(text) {
+			classes = append(classes, c)
+		}
+	}
+
+	for _, at := range []int{cursor, cursor - 1} {
+		p, ok := partner[at]
+		if !ok {
+			continue
+		}
+		if p < 0 {
+			classes[at] = hlBadBracket
+		} else {
+			classes[at], classes[p] = hlBracket, hlBracket
+		}
+		break
+	}
+	return classes
+}
+
+// formatQuery pretty-prints the editor buffer with dbutil.Format.
+func (m *model) formatQuery() {
+	query := m.textarea.Value()
+	if strings.TrimSpace(query) == "" {
+		return
+	}
+	formatted, err := dbutil.Format(query, m.textarea.dialect)
+	if err != nil {
+		m.setStatus("Cannot format: "+err.Error(), true)
+		return
+	}
+	m.textarea.SetValue(formatted)
+	m.setStatus("Query formatted", false)
+}
+
+// Update passes msg to the textarea and keeps the cursor on screen.
+func (e sqlEditor) Update(msg tea.Msg) (sqlEditor, tea.Cmd) {
+	var cmd tea.Cmd
+	e.Model, cmd = e.Model.Update(msg)
+	e.keepCursorVisible()
+	return e, cmd
+}
+
+func (e *sqlEditor) SetValue(s string) {
+	e.Model.SetValue(s)
+	e.keepCursorVisible()
+}
+
+func (e *sqlEditor) InsertString(s string) {
+	e.Model.InsertString(s)
+	e.keepCursorVisible()
+}
+
+func (e *sqlEditor) SetWidth(w int) {
+	e.Model.SetWidth(w)
+	e.keepCursorVisible()
+}
+
+func (e *sqlEditor) SetHeight(h int) {
+	e.Model.SetHeight(h)
+	e.keepCursorVisible()
+}
+
+// cursorColumn returns the byte offset of the cursor in its line. Unlike
+// LineInfo().CharOffset it does not restart on soft-wrapped rows.
+func (e sqlEditor) cursorColumn() int {
+	lines := strings.Split(e.Value(), "\n")
+	if e.Line() >= len(lines) {
+		return 0
+	}
+	info := e.LineInfo()
+	runes := []rune(lines[e.Line()])
+	return len(string(runes[:min(info.StartColumn+info.ColumnOffset, len(runes))]))
+}
+
+// replaceBeforeCursor replaces the n bytes before the cursor, which must be
+// on the cursor's line, with s and leaves the cursor after s.
+func (e *sqlEditor) replaceBeforeCursor(n int, s string) {
+	row, col := e.Line(), e.cursorColumn()
+	lines := strings.Split(e.Value(), "\n")
+	if row >= len(lines) || n > col {
+		return
+	}
+	head := lines[row][:col-n] + s
+	lines[row] = head + lines[row][col:]
+	e.Model.SetValue(strings.Join(lines, "\n"))
+	for e.Line() > row {
+		e.CursorUp()
+	}
+	e.SetCursor(utf8.RuneCountInString(head))
+	e.keepCursorVisible()
+}
+
+// keepCursorVisible scrolls as little as possible to show the cursor row.
+func (e *sqlEditor) keepCursorVisible() {
+	e.scroll = e.firstRow()
+}
+
+// firstRow is the first visual row to show: the saved scroll position,
+// moved just enough to keep the cursor on screen.
+func (e sqlEditor) firstRow() int {
+	height := max(e.Height(), 1)
+	lines := strings.Split(e.Value(), "\n")
+	total, cursorRow := 0, 0
+	for l, line := range lines {
+		if l == e.Line() {
+			cursorRow = total + e.LineInfo().RowOffset
+		}
+		total += len(wrapRunes([]rune(line), e.Width()))
+	}
+	first := min(e.scroll, max(total-height, 0))
+	if cursorRow < first {
+		first = cursorRow
+	}
+	if cursorRow >= first+height {
+		first = cursorRow - height + 1
+	}
+	return max(first, 0)
+}
+
+// View renders the text like textarea does, with syntax highlighting.
+func (e sqlEditor) View() string {
+	if e.Value() == "" {
+		return e.Model.View() // placeholder
+	}
+	styles := e.BlurredStyle
+	if e.Focused() {
+		styles = e.FocusedStyle
+	}
+
+	lines := strings.Split(e.Value(), "\n")
+	row, info := e.Line(), e.LineInfo()
+	cursor := 0
+	for _, line := range lines[:row] {
+		cursor += utf8.RuneCountInString(line) + 1
+	}
+	cursor += info.StartColumn + info.ColumnOffset
+	classes := highlightClasses(e.Value(), e.dialect, cursor)
+
+	width := e.Width()
+	digits := len(strconv.Itoa(e.MaxHeight))
+	gutter := 0
+	if e.ShowLineNumbers {
+		gutter = digits + 2
+	}
+	prompt := styles.Prompt.Render(e.Prompt)
+	var rows []string
+	pos := 0 // rune offset of the current line
+	for l, line := range lines {
+		lineStyle, numberStyle := styles.Text, styles.LineNumber
+		if l == row {
+			lineStyle, numberStyle = styles.CursorLine, styles.CursorLineNumber
+		}
+		runes := []rune(line)
+		offset := pos
+		for w, wrapped := range wrapRunes(runes, width) {
+			var b strings.Builder
+			b.WriteString(lineStyle.Render(prompt))
+			if e.ShowLineNumbers {
+				n := " "
+				if w == 0 {
+					n = strconv.Itoa(l + 1)
+				}
+				b.WriteString(lineStyle.Render(numberStyle.Render(fmt.Sprintf(" %*s ", digits, n))))
+			}
+			at := -1
+			if l == row && w == info.RowOffset && e.Focused() && !e.Cursor.Blink {
+				at = info.ColumnOffset
+			}
+			b.WriteString(e.renderRun(wrapped, classes, offset, pos+len(runes), at, lineStyle))
+			if pad := width - lipgloss.Width(string(wrapped)); pad > 0 {
+				b.WriteString(lineStyle.Render(strings.Repeat(" ", pad)))
+			}
+			rows = append(rows, b.String())
+			offset += len(wrapped)
+		}
+		pos += len(runes) + 1
+	}
+	for len(rows) < e.Height() {
+		rows = append(rows, prompt+styles.EndOfBuffer.Render(strings.Repeat(" ", width+gutter)))
+	}
+
+	first := e.firstRow()
+	rows = rows[first:min(first+max(e.Height(), 1), len(rows))]
+	return styles.Base.Render(strings.Join(rows, "\n"))
+}
+
+// renderRun renders one wrapped row starting at rune offset offset of the
+// text; runes at or past end are wrap padding. at is the cursor column in
+// the row, or -1.
+func (e sqlEditor) renderRun(runes []rune, classes []hlClass, offset, end, at int, lineStyle lipgloss.Style) string {
+	class := func(i int) hlClass {
+		if offset+i < end && offset+i < len(classes) {
+			return classes[offset+i]
+		}
+		return hlText
+	}
+	var b strings.Builder
+	for i := 0; i < len(runes); {
+		if i == at {
+			b.WriteString(e.Cursor.Style.Reverse(true).Render(string(runes[i])))
+			i++
+			continue
+		}
+		j := i + 1
+		for j < len(runes) && j != at && class(j) == class(i) {
+			j++
+		}
+		b.WriteString(hlStyle(lineStyle, class(i)).Render(string(runes[i:j])))
+		i = j
+	}
+	return b.String()
+}
+
+// wrapRunes soft-wraps a line exactly like textarea does, so that the rows
+// drawn here are the rows the textarea moves its cursor through. Every
+// line ends with one extra space for the cursor to sit on.
+func wrapRunes(runes []rune, width int) [][]rune {
+	var (
+		lines  = [][]rune{{}}
+		word   []rune
+		row    int
+		spaces int
+	)
+	for _, r := range runes {
+		if unicode.IsSpace(r) {
+			spaces++
+		} else {
+			word = append(word, r)
+		}
+
+		if spaces > 0 {
+			if lipgloss.Width(string(lines[row]))+lipgloss.Width(string(word))+spaces > width {
+				row++
+				lines = append(lines, nil)
+			}
+			lines[row] = append(lines[row], word...)
+			lines[row] = append(lines[row], []rune(strings.Repeat(" ", spaces))...)
+			spaces = 0
+			word = nil
+		} else if lipgloss.Width(string(word))+lipgloss.Width(string(word[len(word)-1])) > width {
+			// A word wider than the row fills it on its own.
+			if len(lines[row]) > 0 {
+				row++
+				lines = append(lines, nil)
+			}
+			lines[row] = append(lines[row], word...)
+			word = nil
+		}
+	}
+
+	if lipgloss.Width(string(lines[row]))+lipgloss.Width(string(word))+spaces >= width {
+		lines = append(lines, nil)
+		row++
+	}
+	lines[row] = append(lines[row], word...)
+	lines[row] = append(lines[row], []rune(strings.Repeat(" ", spaces+1))...)
+	return lines
+}
@@ -0,0 +1,88 @@
+package ui
+
+import (
+	"strings"
+	"testing"
+
+	tea "github.com/charmbracelet/bubbletea"
+
+	"github.com/kwrkb/asql/internal/db/dbutil"
+)
+
+func TestHighlightClasses(t *testing.T) {
+	text := "SELECT f(a, 'x)') -- (\nFROM t WHERE b = 'open"
+	classes := highlightClasses(text, dbutil.Dialect{}, 0)
+	runes := []rune(text)
+	if len(classes) != len(runes) {
+		t.Fatalf("got %d classes for %d runes", len(classes), len(runes))
+	}
+	at := func(sub string) hlClass { return classes[len([]rune(text[:strings.Index(text, sub)]))] }
+	for sub, want := range map[string]hlClass{"SELECT": hlKeyword, "f(": hlText, "'x)'": hlString, "-- (": hlComment, "FROM": hlKeyword, "'open": hlUnterminated} {
+		if got := at(sub); got != want {
+			t.Errorf("class of %q = %d, want %d", sub, got, want)
+		}
+	}
+
+	// The cursor just after "(" marks it and the ")" that closes it, not
+	// the one inside the string or the comment.
+	open := strings.Index(text, "(")
+	classes = highlightClasses(text, dbutil.Dialect{}, open+1)
+	closeAt := strings.Index(text, "')") + 1
+	if classes[open] != hlBracket || classes[closeAt] != hlBracket {
+		t.Errorf("expected ( at %d and ) at %d to match, got %v", open, closeAt, classes[open:closeAt+1])
+	}
+
+	classes = highlightClasses("a)", dbutil.Dialect{}, 1)
+	if classes[1] != hlBadBracket {
+		t.Errorf("expected an unmatched ) to be flagged, got %v", classes)
+	}
+
+	// In SQLite, [ starts a quoted identifier rather than a bracket.
+	classes = highlightClasses("[a b]", dbutil.DialectFor("sqlite"), 0)
+	if classes[0] != hlIdent || classes[4] != hlIdent {
+		t.Errorf("expected a quoted identifier, got %v", classes)
+	}
+}
+
+func TestWrapRunesMatchesTextarea(t *testing.T) {
+	m := newTestModel()
+	m.textarea.SetWidth(20)
+	line := "SELECT id, name, email FROM users WHERE id = 1"
+	m.textarea.SetValue(line)
+	rows := wrapRunes([]rune(line), m.textarea.Width())
+	if info := m.textarea.LineInfo(); info.Height != len(rows) {
+		t.Fatalf("textarea wraps into %d rows, wrapRunes into %d", info.Height, len(rows))
+	}
+	var joined strings.Builder
+	for _, r := range rows {
+		joined.WriteString(string(r))
+	}
+	if got := joined.String(); got != line+" " {
+		t.Errorf("rows do not add up to the line: %q", got)
+	}
+}
+
+func TestEditorScrollsToCursor(t *testing.T) {
+	m := newTestModel()
+	m.textarea.SetWidth(40)
+	m.textarea.SetHeight(3)
+	m.textarea.Focus()
+	m.textarea.SetValue("select 1\nselect 2\nselect 3\nselect 4\nselect 5")
+
+	view := m.textarea.View()
+	if strings.Contains(view, "select 2") || !strings.Contains(view, "select 5") {
+		t.Fatalf("expected the last three lines around the cursor:\n%s", view)
+	}
+
+	// Moving up inside the window keeps it still; moving past it scrolls.
+	for range 2 {
+		m.textarea, _ = m.textarea.Update(tea.KeyMsg{Type: tea.KeyUp})
+	}
+	if m.textarea.scroll != 2 {
+		t.Errorf("scroll = %d after moving within the window, want 2", m.textarea.scroll)
+	}
+	m.textarea, _ = m.textarea.Update(tea.KeyMsg{Type: tea.KeyUp})
+	if view := m.textarea.View(); !strings.Contains(view, "select 2") || strings.Contains(view, "select 5") {
+		t.Errorf("expected the window to follow the cursor up:\n%s", view)
+	}
+}
@@ -2,12 +2,16 @@ package ui
 
 import (
 	"fmt"
+	"os"
+	"path/filepath"
 	"strings"
+	"time"
 
-	"github.com/atotto/clipboard"
+	"github.com/charmbracelet/bubbles/textinput"
 	tea "github.com/charmbracelet/bubbletea"
 	"github.com/charmbracelet/lipgloss"
 
+	"github.com/kwrkb/asql/internal/db/dbutil"
 	"github.com/kwrkb/asql/internal/export"
 )
 
@@ -15,13 +19,49 @@ var exportOptions = []string{
 	"Copy as CSV",
 	"Copy as JSON",
 	"Copy as Markdown",
-	"Save to File (CSV)",
+	"Save to File...",
 }
 
+// exportScope selects which part of the result an export covers.
+type exportScope int
+
+const (
+	scopeView           exportScope = iota // rows as currently sorted/filtered
+	scopeAll                               // raw result rows in query order
+	scopeSelected                          // VISUAL selection, or the row under the cursor
+	scopeVisibleColumns                    // current view, on-screen columns only
+	scopeFull                              // re-run the query and stream every row
+)
+
+var exportScopeLabels = []string{
+	"Current view",
+	"All rows",
+	"Selection",
+	"Visible columns",
+	"Full result (re-run query)",
+}
+
+// Save dialog fields, in Tab order. The shape field is only shown (and
+// focusable) for JSON formats, the table/mode/keys fields for SQL.
+const (
+	exportFieldPath = iota
+	exportFieldFormat
+	exportFieldScope
+	exportFieldShape
+	exportFieldTable
+	exportFieldMode
+	exportFieldKeys
+	exportFieldCount
+)
+
 func (m model) updateExport(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	if m.exportSt.saving {
+		return m.updateExportSave(msg)
+	}
+
 	switch msg.Type {
 	case tea.KeyEsc:
-		m.mode = normalMode
+		m.leaveExport()
 		m.setStatus("Normal mode", false)
 		return m, nil
 	case tea.KeyRunes:
@@ -39,6 +79,9 @@ func (m model) updateExport(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 	case tea.KeyUp:
 		moveCursor(&m.exportSt.cursor, len(exportOptions), -1)
 	case tea.KeyEnter:
+		if m.exportSt.cursor == 3 {
+			return m.openSaveDialog()
+		}
 		m.executeExport()
 		return m, nil
 	}
@@ -46,10 +89,13 @@ func (m model) updateExport(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 }
 
 func (m *model) executeExport() {
-	headers := m.lastResult.Columns
-	rows := m.lastResult.Rows
+	scope := scopeView
+	if m.visual.active {
+		scope = scopeSelected
+	}
+	headers, rows := m.exportData(scope)
 
-	defer func() { m.mode = normalMode }()
+	defer m.leaveExport()
 
 	switch m.exportSt.cursor {
 	case 0: // CSV to clipboard
@@ -58,43 +104,392 @@ func (m *model) executeExport() {
 			m.setStatus(fmt.Sprintf("Export failed: %v", err), true)
 			return
 		}
-		if err := clipboard.WriteAll(content); err != nil {
-			m.setStatus(fmt.Sprintf("Clipboard failed: %v", err), true)
-			return
-		}
-		m.setStatus("Copied as CSV to clipboard!", false)
+		m.copyText(content, "Copied as CSV")
 
 	case 1: // JSON to clipboard
-		content, err := export.FormatJSON(headers, rows)
+		opts := export.Options{ColumnTypes: m.exportColumnTypes(scope), Nulls: m.exportNulls(scope)}
+		content, err := export.FormatJSON(headers, rows, opts)
 		if err != nil {
 			m.setStatus(fmt.Sprintf("Export failed: %v", err), true)
 			return
 		}
-		if err := clipboard.WriteAll(content); err != nil {
-			m.setStatus(fmt.Sprintf("Clipboard failed: %v", err), true)
-			return
-		}
-		m.setStatus("Copied as JSON to clipboard!", false)
+		m.copyText(content, "Copied as JSON")
 
 	case 2: // Markdown to clipboard
 		content := export.FormatMarkdown(headers, rows)
-		if err := clipboard.WriteAll(content); err != nil {
-			m.setStatus(fmt.Sprintf("Clipboard failed: %v", err), true)
-			return
+		m.copyText(content, "Copied as Markdown")
+	}
+}
+
+// leaveExport returns to NORMAL mode, dropping a selection the export was
+// opened from.
+func (m *model) leaveExport() {
+	m.clearVisual()
+	m.mode = normalMode
+}
+
+// viewRows returns the result rows in display order (sorted/filtered),
+// without the "(no rows)" sentinel.
+func (m *model) viewRows() [][]string {
+	if m.rowCount() == 0 {
+		return nil
+	}
+	rows := make([][]string, len(m.displayRows))
+	for i, r := range m.displayRows {
+		rows[i] = r
+	}
+	return rows
+}
+
+// exportData returns the headers and rows covered by scope.
+func (m *model) exportData(scope exportScope) ([]string, [][]string) {
+	headers := m.lastResult.Columns
+	switch scope {
+	case scopeAll:
+		return headers, m.lastResult.Rows
+	case scopeSelected:
+		headers, rows, _ := m.selectionData()
+		return headers, rows
+	case scopeVisibleColumns:
+		start, end := m.visibleColumnRange()
+		if end <= start {
+			return headers, m.viewRows()
+		}
+		rows := m.viewRows()
+		sliced := make([][]string, len(rows))
+		for i, row := range rows {
+			sliced[i] = padCells(row, end)[start:end]
 		}
-		m.setStatus("Copied as Markdown to clipboard!", false)
+		return headers[start:end], sliced
+	default:
+		return headers, m.viewRows()
+	}
+}
 
-	case 3: // Save CSV file
-		filename, err := export.SaveCSVFile(headers, rows)
-		if err != nil {
-			m.setStatus(fmt.Sprintf("Export failed: %v", err), true)
-			return
+// exportFirstColumn returns the result column that the first header
+// returned by exportData for scope stands for.
+func (m *model) exportFirstColumn(scope exportScope) int {
+	switch scope {
+	case scopeSelected:
+		if m.visual.active && m.visual.block {
+			start, _ := m.visualCols()
+			return start
+		}
+	case scopeVisibleColumns:
+		if start, end := m.visibleColumnRange(); end > start {
+			return start
 		}
-		m.setStatus(fmt.Sprintf("Saved to %s", filename), false)
 	}
+	return 0
+}
+
+// exportNulls returns the NULL flags matching the rows returned by
+// exportData for scope, or nil when the result has none.
+func (m *model) exportNulls(scope exportScope) [][]bool {
+	if m.lastResult.Nulls == nil {
+		return nil
+	}
+	switch scope {
+	case scopeAll:
+		return m.lastResult.Nulls
+	case scopeSelected:
+		nulls := m.viewNulls(m.selectedRange())
+		if !m.visual.active || !m.visual.block {
+			return nulls
+		}
+		start, end := m.visualCols()
+		return sliceNulls(nulls, start, end)
+	case scopeVisibleColumns:
+		nulls := m.viewNulls(0, m.rowCount())
+		if start, end := m.visibleColumnRange(); end > start {
+			return sliceNulls(nulls, start, end)
+		}
+		return nulls
+	default:
+		return m.viewNulls(0, m.rowCount())
+	}
+}
+
+// viewNulls returns the NULL flags of the view rows start..end, looked up
+// by the row of lastResult each one shows.
+func (m *model) viewNulls(start, end int) [][]bool {
+	nulls := make([][]bool, 0, max(end-start, 0))
+	for i := start; i < end; i++ {
+		src := i
+		if m.displaySrc != nil {
+			src = m.displaySrc[i]
+		}
+		var row []bool
+		if src < len(m.lastResult.Nulls) {
+			row = m.lastResult.Nulls[src]
+		}
+		nulls = append(nulls, row)
+	}
+	return nulls
+}
+
+// sliceNulls limits the flags of each row to the columns start..end.
+func sliceNulls(nulls [][]bool, start, end int) [][]bool {
+	out := make([][]bool, len(nulls))
+	for i, row := range nulls {
+		out[i] = padNulls(row, end)[start:end]
+	}
+	return out
+}
+
+// exportColumnTypes returns the column type names matching the headers
+// returned by exportData for scope.
+func (m *model) exportColumnTypes(scope exportScope) []string {
+	types := m.lastResult.ColumnTypes
+	if scope == scopeSelected {
+		_, _, selected := m.selectionData()
+		return selected
+	}
+	if scope != scopeVisibleColumns {
+		return types
+	}
+	start, end := m.visibleColumnRange()
+	if end <= start {
+		return types
+	}
+	return padCells(types, end)[start:end]
+}
+
+// padCells returns row extended with empty cells to at least n entries.
+func padCells(row []string, n int) []string {
+	if len(row) >= n {
+		return row
+	}
+	out := make([]string, n)
+	copy(out, row)
+	return out
+}
+
+// exportOptionsForActive returns format options bound to the active connection.
+func (m *model) exportOptionsForActive() export.Options {
+	var opts export.Options
+	if adapter := m.activeDB(); adapter != nil {
+		opts.QuoteIdent = adapter.QuoteIdentifier
+		opts.Dialect = adapter.Type()
+	}
+	opts.ColumnTypes = m.exportColumnTypes(m.exportSt.scope)
+	opts.JSONArrays = m.exportSt.jsonArrays
+	opts.Table = strings.TrimSpace(m.exportSt.table.Value())
+	opts.Upsert = m.exportSt.upsert
+	opts.KeyColumns = splitColumnList(m.exportSt.keys.Value())
+	return opts
+}
+
+// splitColumnList parses a comma-separated column list, dropping blanks.
+func splitColumnList(s string) []string {
+	var cols []string
+	for _, c := range strings.Split(s, ",") {
+		if c = strings.TrimSpace(c); c != "" {
+			cols = append(cols, c)
+		}
+	}
+	return cols
+}
+
+// exportFieldVisible reports whether field is shown for the selected format.
+func (m *model) exportFieldVisible(field int) bool {
+	switch field {
+	case exportFieldShape:
+		f := export.Formats[m.exportSt.format]
+		return f == export.JSON || f == export.JSONLines
+	case exportFieldTable, exportFieldMode:
+		return export.Formats[m.exportSt.format] == export.SQL
+	case exportFieldKeys:
+		return export.Formats[m.exportSt.format] == export.SQL && m.exportSt.upsert
+	default:
+		return true
+	}
+}
+
+// moveExportFocus moves focus by delta, skipping hidden fields.
+func (m *model) moveExportFocus(delta int) {
+	field := m.exportSt.focus
+	for range exportFieldCount {
+		field = (field + delta + exportFieldCount) % exportFieldCount
+		if m.exportFieldVisible(field) {
+			break
+		}
+	}
+	m.setExportFocus(field)
+}
+
+func (m model) openSaveDialog() (tea.Model, tea.Cmd) {
+	m.exportSt.saving = true
+	m.exportSt.focus = exportFieldPath
+	m.exportSt.err = ""
+	m.exportSt.overwrite = ""
+	format := export.Formats[m.exportSt.format]
+	m.exportSt.path.SetValue(export.DefaultFilename(format, time.Now()))
+	m.exportSt.path.CursorEnd()
+	m.exportSt.path.Focus()
+	m.exportSt.table.SetValue(dbutil.SingleTableName(m.lastQuery))
+	m.exportSt.table.CursorEnd()
+	m.exportSt.keys.SetValue("")
+	if len(m.lastResult.Columns) > 0 {
+		m.exportSt.keys.SetValue(m.lastResult.Columns[0])
+	}
+	m.exportSt.keys.CursorEnd()
+	if m.visual.active {
+		m.exportSt.scope = scopeSelected
+	}
+	return m, textinput.Blink
+}
+
+func (m model) updateExportSave(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	if msg.Type != tea.KeyEnter {
+		// Any change to the dialog asks again before overwriting.
+		m.exportSt.overwrite = ""
+	}
+	switch msg.Type {
+	case tea.KeyEsc:
+		m.exportSt.saving = false
+		m.exportSt.path.Blur()
+		return m, nil
+	case tea.KeyEnter:
+		return m, m.saveExportFile()
+	case tea.KeyTab, tea.KeyDown:
+		m.moveExportFocus(1)
+		return m, nil
+	case tea.KeyShiftTab, tea.KeyUp:
+		m.moveExportFocus(-1)
+		return m, nil
+	}
+
+	var cmd tea.Cmd
+	switch m.exportSt.focus {
+	case exportFieldPath:
+		m.exportSt.path, cmd = m.exportSt.path.Update(msg)
+	case exportFieldTable:
+		m.exportSt.table, cmd = m.exportSt.table.Update(msg)
+	case exportFieldKeys:
+		m.exportSt.keys, cmd = m.exportSt.keys.Update(msg)
+	default:
+		switch {
+		case msg.Type == tea.KeyLeft || (msg.Type == tea.KeyRunes && !msg.Alt && string(msg.Runes) == "h"):
+			m.cycleExportField(-1)
+		case msg.Type == tea.KeyRight || (msg.Type == tea.KeyRunes && !msg.Alt && string(msg.Runes) == "l"):
+			m.cycleExportField(1)
+		}
+	}
+	return m, cmd
+}
+
+func (m *model) setExportFocus(field int) {
+	m.exportSt.focus = field
+	inputs := map[int]*textinput.Model{
+		exportFieldPath:  &m.exportSt.path,
+		exportFieldTable: &m.exportSt.table,
+		exportFieldKeys:  &m.exportSt.keys,
+	}
+	for f, in := range inputs {
+		if f == field {
+			in.Focus()
+		} else {
+			in.Blur()
+		}
+	}
+}
+
+// cycleExportField moves the focused selector by delta.
+// Changing the format also swaps the path's extension when it still
+// carries the previous format's extension.
+func (m *model) cycleExportField(delta int) {
+	switch m.exportSt.focus {
+	case exportFieldFormat:
+		n := len(export.Formats)
+		prev := export.Formats[m.exportSt.format]
+		m.exportSt.format = (m.exportSt.format + delta + n) % n
+		next := export.Formats[m.exportSt.format]
+		path := m.exportSt.path.Value()
+		if oldExt := "." + prev.Extension(); strings.HasSuffix(path, oldExt) {
+			m.exportSt.path.SetValue(strings.TrimSuffix(path, oldExt) + "." + next.Extension())
+		}
+	case exportFieldScope:
+		n := len(exportScopeLabels)
+		m.exportSt.scope = exportScope((int(m.exportSt.scope) + delta + n) % n)
+	case exportFieldShape:
+		m.exportSt.jsonArrays = !m.exportSt.jsonArrays
+	case exportFieldMode:
+		m.exportSt.upsert = !m.exportSt.upsert
+	}
+}
+
+// saveExportFile writes the dialog's selection to disk. For the full-result
+// scope it starts a streaming export and returns its command.
+func (m *model) saveExportFile() tea.Cmd {
+	path := expandHome(strings.TrimSpace(m.exportSt.path.Value()))
+	if path == "" {
+		m.exportSt.err = "File path is empty"
+		return nil
+	}
+	format := export.Formats[m.exportSt.format]
+	opts := m.exportOptionsForActive()
+	if format == export.SQL {
+		if opts.Table == "" {
+			m.exportSt.err = "Table name is empty"
+			return nil
+		}
+		if opts.Upsert && len(opts.KeyColumns) == 0 && opts.Dialect != "mysql" {
+			m.exportSt.err = "Key columns are required for UPSERT"
+			return nil
+		}
+	}
+	if fi, err := os.Stat(path); err == nil && fi.IsDir() {
+		m.exportSt.err = fmt.Sprintf("%s is a directory", path)
+		return nil
+	} else if err == nil && m.exportSt.overwrite != path {
+		m.exportSt.overwrite = path
+		m.exportSt.err = fmt.Sprintf("%s exists; press Enter again to overwrite it", path)
+		return nil
+	}
+	if m.exportSt.scope == scopeFull {
+		return m.startStreamExport(path, format, opts)
+	}
+	headers, rows := m.exportData(m.exportSt.scope)
+	opts.Nulls = m.exportNulls(m.exportSt.scope)
+	if format == export.SQL {
+		// Derived columns do not exist in the table the statements target.
+		headers, rows, opts.ColumnTypes, opts.Nulls = m.withoutDerived(m.exportFirstColumn(m.exportSt.scope), headers, rows, opts.ColumnTypes, opts.Nulls)
+		if len(headers) == 0 {
+			m.exportSt.err = "No database columns to export"
+			return nil
+		}
+	}
+	if err := export.SaveFile(path, format, headers, rows, opts); err != nil {
+		m.exportSt.err = err.Error()
+		m.setStatus(fmt.Sprintf("Export failed: %v", err), true)
+		return nil
+	}
+	m.exportSt.saving = false
+	m.exportSt.err = ""
+	m.exportSt.path.Blur()
+	m.leaveExport()
+	m.setStatus(fmt.Sprintf("Saved %d row(s) to %s (%s)", len(rows), path, format.Label()), false)
+	return nil
+}
+
+// expandHome replaces a leading "~/" with the user's home directory.
+func expandHome(path string) string {
+	if !strings.HasPrefix(path, "~/") {
+		return path
+	}
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return path
+	}
+	return filepath.Join(home, path[2:])
 }
 
 func (m model) renderWithExportOverlay(background string) string {
+	if m.exportSt.saving {
+		return m.renderWithExportSaveOverlay(background)
+	}
+
 	modalWidth := calcModalWidth(m.width, 40)
 	innerWidth := max(modalWidth-6, 1)
 
@@ -140,3 +535,74 @@ func (m model) renderWithExportOverlay(background string) string {
 
 	return overlayModal(m.width, background, modal)
 }
+
+func (m model) renderWithExportSaveOverlay(background string) string {
+	modalWidth := calcModalWidth(m.width, 60)
+
+	titleStyle := lipgloss.NewStyle().
+		Bold(true).
+		Foreground(accentColor).
+		MarginBottom(1)
+
+	boxStyle := lipgloss.NewStyle().
+		Border(lipgloss.RoundedBorder()).
+		BorderForeground(accentColor).
+		Padding(1, 2).
+		Width(modalWidth).
+		Background(panelBackground)
+
+	labelStyle := lipgloss.NewStyle().Foreground(mutedTextColor)
+	focusedLabelStyle := lipgloss.NewStyle().Foreground(accentColor).Bold(true)
+	valueStyle := lipgloss.NewStyle().Foreground(textColor)
+
+	label := func(field int, text string) string {
+		if m.exportSt.focus == field {
+			return focusedLabelStyle.Render(text)
+		}
+		return labelStyle.Render(text)
+	}
+	selector := func(field int, text string) string {
+		if m.exportSt.focus == field {
+			return valueStyle.Bold(true).Render("◂ " + text + " ▸")
+		}
+		return valueStyle.Render("  " + text)
+	}
+
+	format := export.Formats[m.exportSt.format]
+	lines := []string{
+		label(exportFieldPath, "Path:   ") + m.exportSt.path.View(),
+		label(exportFieldFormat, "Format: ") + selector(exportFieldFormat, format.Label()),
+		label(exportFieldScope, "Scope:  ") + selector(exportFieldScope, exportScopeLabels[m.exportSt.scope]),
+	}
+	if m.exportFieldVisible(exportFieldShape) {
+		shape := "Objects"
+		if m.exportSt.jsonArrays {
+			shape = "Arrays"
+		}
+		lines = append(lines, label(exportFieldShape, "Shape:  ")+selector(exportFieldShape, shape))
+	}
+	if m.exportFieldVisible(exportFieldTable) {
+		mode := "INSERT"
+		if m.exportSt.upsert {
+			mode = "UPSERT"
+		}
+		lines = append(lines,
+			label(exportFieldTable, "Table:  ")+m.exportSt.table.View(),
+			label(exportFieldMode, "Mode:   ")+selector(exportFieldMode, mode),
+		)
+	}
+	if m.exportFieldVisible(exportFieldKeys) {
+		lines = append(lines, label(exportFieldKeys, "Keys:   ")+m.exportSt.keys.View())
+	}
+	content := titleStyle.Render("Save to File") + "\n" + strings.Join(lines, "\n")
+	if m.lastResult.Truncated && m.exportSt.scope != scopeFull {
+		hintStyle := lipgloss.NewStyle().Foreground(mutedTextColor).MarginTop(1)
+		content += "\n" + hintStyle.Render("Result is truncated; choose \"Full result\" to export every row")
+	}
+	if m.exportSt.err != "" {
+		errStyle := lipgloss.NewStyle().Foreground(errorColor).MarginTop(1)
+		content += "\n" + errStyle.Render(sanitize(m.exportSt.err))
+	}
+
+	return overlayModal(m.width, background, boxStyle.Render(content))
+}
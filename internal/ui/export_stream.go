@@ -0,0 +1,133 @@
+package ui
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+
+	"github.com/kwrkb/asql/internal/db"
+	"github.com/kwrkb/asql/internal/export"
+)
+
+// streamProgressInterval is how often the status bar refreshes while a
+// full-result export is running.
+const streamProgressInterval = 200 * time.Millisecond
+
+type streamExportTickMsg struct {
+	seq uint64
+}
+
+type streamExportDoneMsg struct {
+	seq  uint64
+	rows int64
+	err  error
+}
+
+// startStreamExport re-runs the last query and streams every row into path,
+// bypassing the display row limit. Progress is reported in the status bar
+// until a streamExportDoneMsg arrives; Ctrl+C cancels.
+func (m *model) startStreamExport(path string, format export.Format, opts export.Options) tea.Cmd {
+	adapter := m.activeDB()
+	query := strings.TrimSpace(m.lastQuery)
+	switch {
+	case adapter == nil || query == "":
+		m.exportSt.err = "No query to re-run"
+		return nil
+	case m.streamSt.cancel != nil:
+		m.exportSt.err = "An export is already running"
+		return nil
+	}
+
+	sink, err := export.NewFileSink(path, format, opts)
+	if err != nil {
+		m.exportSt.err = err.Error()
+		m.setStatus(fmt.Sprintf("Export failed: %v", err), true)
+		return nil
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	m.streamSt.seq++
+	m.streamSt.cancel = cancel
+	m.streamSt.sink = sink
+	m.streamSt.path = path
+	m.streamSt.format = format
+	m.streamSt.started = time.Now()
+
+	m.exportSt.saving = false
+	m.exportSt.err = ""
+	m.blurActiveInput()
+	m.leaveExport()
+	m.setStatus(m.streamProgressText(), false)
+
+	seq := m.streamSt.seq
+	return tea.Batch(streamExportCmd(ctx, adapter, query, sink, m.displayLocation(), seq), streamExportTick(seq))
+}
+
+func streamExportCmd(ctx context.Context, adapter db.DBAdapter, query string, sink *export.FileSink, loc *time.Location, seq uint64) tea.Cmd {
+	return func() tea.Msg {
+		err := adapter.Stream(ctx, query, &zoneHandler{next: sink, dbType: adapter.Type(), loc: loc})
+		if err == nil {
+			err = sink.Close()
+		}
+		if err != nil {
+			sink.Abort()
+		}
+		return streamExportDoneMsg{seq: seq, rows: sink.Written(), err: err}
+	}
+}
+
+func streamExportTick(seq uint64) tea.Cmd {
+	return tea.Tick(streamProgressInterval, func(time.Time) tea.Msg {
+		return streamExportTickMsg{seq: seq}
+	})
+}
+
+// cancelStreamExport stops a running full-result export. The partial file
+// is removed once the stream goroutine notices the cancellation.
+func (m *model) cancelStreamExport() {
+	m.streamSt.cancel()
+	m.streamSt.cancel = nil
+	m.setStatus("Cancelling export...", false)
+}
+
+func (m *model) handleStreamExportTick(msg streamExportTickMsg) tea.Cmd {
+	if msg.seq != m.streamSt.seq || m.streamSt.cancel == nil {
+		return nil
+	}
+	m.setStatus(m.streamProgressText(), false)
+	return streamExportTick(msg.seq)
+}
+
+func (m *model) handleStreamExportDone(msg streamExportDoneMsg) {
+	if msg.seq != m.streamSt.seq {
+		return
+	}
+	if m.streamSt.cancel != nil {
+		m.streamSt.cancel()
+		m.streamSt.cancel = nil
+	}
+	m.streamSt.sink = nil
+	elapsed := time.Since(m.streamSt.started).Round(100 * time.Millisecond)
+
+	switch {
+	case errors.Is(msg.err, context.Canceled):
+		m.setStatus(fmt.Sprintf("Export cancelled after %d row(s); partial file removed", msg.rows), false)
+	case msg.err != nil:
+		m.setStatus(fmt.Sprintf("Export failed: %v", msg.err), true)
+	default:
+		m.setStatus(fmt.Sprintf("Exported %d row(s) to %s (%s) in %s", msg.rows, m.streamSt.path, m.streamSt.format.Label(), elapsed), false)
+	}
+}
+
+func (m *model) streamProgressText() string {
+	var rows int64
+	if m.streamSt.sink != nil {
+		rows = m.streamSt.sink.Written()
+	}
+	elapsed := time.Since(m.streamSt.started).Truncate(time.Second)
+	return fmt.Sprintf("Exporting to %s: %d row(s) written, %s elapsed", m.streamSt.path, rows, elapsed)
+}
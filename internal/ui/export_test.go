@@ -1,11 +1,20 @@
 package ui
 
 import (
+	"context"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
 	"testing"
+	"time"
 
+	"github.com/charmbracelet/bubbles/textinput"
 	tea "github.com/charmbracelet/bubbletea"
 
 	"github.com/kwrkb/asql/internal/db"
+	"github.com/kwrkb/asql/internal/db/sqlite"
+	"github.com/kwrkb/asql/internal/export"
 )
 
 func TestExport_NavigationJK(t *testing.T) {
@@ -93,3 +102,451 @@ func TestExport_EscReturnsToNormal(t *testing.T) {
 		t.Errorf("expected normalMode, got %q", rm.mode)
 	}
 }
+
+func newExportSaveModel() *model {
+	m := newTestModel()
+	m.mode = exportMode
+	m.exportSt.path = textinput.New()
+	m.exportSt.table = textinput.New()
+	m.exportSt.keys = textinput.New()
+	m.applyResult(db.QueryResult{
+		Columns: []string{"id", "name"},
+		Rows:    [][]string{{"2", "bob"}, {"1", "alice"}, {"3", "carol"}},
+	})
+	return m
+}
+
+func TestExport_EnterOnSaveOpensDialog(t *testing.T) {
+	m := newExportSaveModel()
+	m.exportSt.cursor = 3
+
+	result, _ := m.updateExport(tea.KeyMsg{Type: tea.KeyEnter})
+	rm := result.(model)
+	if !rm.exportSt.saving {
+		t.Fatal("expected save dialog to open")
+	}
+	if rm.mode != exportMode {
+		t.Errorf("expected to stay in exportMode, got %q", rm.mode)
+	}
+	if !strings.HasSuffix(rm.exportSt.path.Value(), ".csv") {
+		t.Errorf("expected default .csv path, got %q", rm.exportSt.path.Value())
+	}
+
+	// Esc returns to the menu, not NORMAL mode.
+	result, _ = rm.updateExport(tea.KeyMsg{Type: tea.KeyEsc})
+	rm = result.(model)
+	if rm.exportSt.saving || rm.mode != exportMode {
+		t.Errorf("expected menu after Esc, saving=%v mode=%q", rm.exportSt.saving, rm.mode)
+	}
+}
+
+func TestExport_FormatCycleSwapsExtension(t *testing.T) {
+	m := newExportSaveModel()
+	m.exportSt.cursor = 3
+	result, _ := m.updateExport(tea.KeyMsg{Type: tea.KeyEnter})
+	rm := result.(model)
+
+	result, _ = rm.updateExport(tea.KeyMsg{Type: tea.KeyTab})
+	rm = result.(model)
+	if rm.exportSt.focus != exportFieldFormat {
+		t.Fatalf("expected format field focus, got %d", rm.exportSt.focus)
+	}
+	result, _ = rm.updateExport(tea.KeyMsg{Type: tea.KeyRight})
+	rm = result.(model)
+	if export.Formats[rm.exportSt.format] != export.TSV {
+		t.Errorf("expected TSV, got %s", export.Formats[rm.exportSt.format])
+	}
+	if !strings.HasSuffix(rm.exportSt.path.Value(), ".tsv") {
+		t.Errorf("expected .tsv path, got %q", rm.exportSt.path.Value())
+	}
+
+	// Wraps backwards past the first format.
+	result, _ = rm.updateExport(runeMsg("h"))
+	rm = result.(model)
+	result, _ = rm.updateExport(runeMsg("h"))
+	rm = result.(model)
+	if export.Formats[rm.exportSt.format] != export.XLSX {
+		t.Errorf("expected XLSX after wrap, got %s", export.Formats[rm.exportSt.format])
+	}
+}
+
+func TestExport_SaveRespectsSortAndScope(t *testing.T) {
+	m := newExportSaveModel()
+	m.colCursor = 0
+	m.toggleSort() // sort by id ascending
+
+	path := filepath.Join(t.TempDir(), "out.csv")
+	m.exportSt.saving = true
+	m.exportSt.path.SetValue(path)
+	m.saveExportFile()
+
+	if m.mode != normalMode {
+		t.Errorf("expected normalMode after save, got %q", m.mode)
+	}
+	got, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := "id,name\n1,alice\n2,bob\n3,carol\n"
+	if string(got) != want {
+		t.Errorf("got:\n%s\nwant:\n%s", got, want)
+	}
+
+	t.Run("all rows keeps query order", func(t *testing.T) {
+		_, rows := m.exportData(scopeAll)
+		if rows[0][0] != "2" {
+			t.Errorf("expected raw order, got first id %q", rows[0][0])
+		}
+	})
+
+	t.Run("selected row", func(t *testing.T) {
+		m.table.SetCursor(1)
+		_, rows := m.exportData(scopeSelected)
+		if len(rows) != 1 || rows[0][0] != "2" {
+			t.Errorf("expected the cursor row (id 2), got %v", rows)
+		}
+	})
+}
+
+func TestExport_SaveErrorKeepsDialog(t *testing.T) {
+	m := newExportSaveModel()
+	m.exportSt.saving = true
+	m.exportSt.path.SetValue(filepath.Join(t.TempDir(), "missing", "out.csv"))
+	m.saveExportFile()
+
+	if !m.exportSt.saving {
+		t.Error("expected dialog to stay open on error")
+	}
+	if m.exportSt.err == "" || !m.statusError {
+		t.Error("expected error to be reported")
+	}
+}
+
+func TestExport_SaveAsksBeforeOverwriting(t *testing.T) {
+	m := newExportSaveModel()
+	path := filepath.Join(t.TempDir(), "out.csv")
+	if err := os.WriteFile(path, []byte("keep\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	m.exportSt.saving = true
+	m.exportSt.path.SetValue(path)
+
+	m.saveExportFile()
+	if got, _ := os.ReadFile(path); string(got) != "keep\n" || !m.exportSt.saving {
+		t.Fatalf("expected the file kept and the dialog open, got %q", got)
+	}
+	if !strings.Contains(m.exportSt.err, "press Enter again") {
+		t.Errorf("err = %q", m.exportSt.err)
+	}
+
+	// Editing the dialog asks again.
+	result, _ := m.updateExportSave(tea.KeyMsg{Type: tea.KeyTab})
+	rm := result.(model)
+	rm.saveExportFile()
+	if got, _ := os.ReadFile(path); string(got) != "keep\n" {
+		t.Fatalf("expected the file kept after an edit, got %q", got)
+	}
+
+	rm.saveExportFile()
+	if got, _ := os.ReadFile(path); !strings.HasPrefix(string(got), "id,name\n") || rm.exportSt.saving {
+		t.Errorf("expected the file overwritten, got %q", got)
+	}
+}
+
+func TestExport_SaveRejectsDirectory(t *testing.T) {
+	m := newExportSaveModel()
+	dir := t.TempDir()
+	m.exportSt.saving = true
+	m.exportSt.path.SetValue(dir)
+
+	for range 2 {
+		if cmd := m.saveExportFile(); cmd != nil || !m.exportSt.saving {
+			t.Fatal("expected the dialog to stay open")
+		}
+		if !strings.Contains(m.exportSt.err, "is a directory") {
+			t.Errorf("err = %q, want a directory error rather than an overwrite prompt", m.exportSt.err)
+		}
+	}
+}
+
+func TestExport_SQLFieldsOnlyForSQLFormat(t *testing.T) {
+	m := newExportSaveModel()
+	m.lastQuery = "SELECT * FROM public.users WHERE id > 0"
+	m.exportSt.cursor = 3
+	result, _ := m.updateExport(tea.KeyMsg{Type: tea.KeyEnter})
+	rm := result.(model)
+	if got := rm.exportSt.table.Value(); got != "public.users" {
+		t.Errorf("expected table prefilled from query, got %q", got)
+	}
+	if got := rm.exportSt.keys.Value(); got != "id" {
+		t.Errorf("expected first column as default key, got %q", got)
+	}
+
+	// CSV: Tab from scope wraps back to path.
+	rm.exportSt.focus = exportFieldScope
+	result, _ = rm.updateExport(tea.KeyMsg{Type: tea.KeyTab})
+	rm = result.(model)
+	if rm.exportSt.focus != exportFieldPath {
+		t.Errorf("expected SQL fields to be skipped, focus=%d", rm.exportSt.focus)
+	}
+
+	for i, f := range export.Formats {
+		if f == export.SQL {
+			rm.exportSt.format = i
+		}
+	}
+	rm.exportSt.focus = exportFieldScope
+	result, _ = rm.updateExport(tea.KeyMsg{Type: tea.KeyTab})
+	rm = result.(model)
+	if rm.exportSt.focus != exportFieldTable {
+		t.Fatalf("expected table field focus, got %d", rm.exportSt.focus)
+	}
+	result, _ = rm.updateExport(tea.KeyMsg{Type: tea.KeyTab})
+	rm = result.(model)
+	// Keys stay hidden until UPSERT is selected.
+	result, _ = rm.updateExport(tea.KeyMsg{Type: tea.KeyTab})
+	rm = result.(model)
+	if rm.exportSt.focus != exportFieldPath {
+		t.Errorf("expected keys field to be hidden for INSERT, focus=%d", rm.exportSt.focus)
+	}
+	rm.exportSt.focus = exportFieldMode
+	result, _ = rm.updateExport(tea.KeyMsg{Type: tea.KeyRight})
+	rm = result.(model)
+	if !rm.exportSt.upsert {
+		t.Fatal("expected UPSERT mode")
+	}
+	result, _ = rm.updateExport(tea.KeyMsg{Type: tea.KeyTab})
+	rm = result.(model)
+	if rm.exportSt.focus != exportFieldKeys {
+		t.Errorf("expected keys field focus, got %d", rm.exportSt.focus)
+	}
+}
+
+func TestExport_SaveSQLUpsert(t *testing.T) {
+	m := newExportSaveModel()
+	for i, f := range export.Formats {
+		if f == export.SQL {
+			m.exportSt.format = i
+		}
+	}
+	path := filepath.Join(t.TempDir(), "out.sql")
+	m.exportSt.saving = true
+	m.exportSt.path.SetValue(path)
+	m.exportSt.table.SetValue("users")
+	m.exportSt.upsert = true
+	m.exportSt.keys.SetValue("id")
+	m.exportSt.scope = scopeSelected
+	m.saveExportFile()
+
+	got, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("file not written: %v (dialog err %q)", err, m.exportSt.err)
+	}
+	want := `INSERT INTO "users" ("id", "name") VALUES ('2', 'bob') ON CONFLICT ("id") DO UPDATE SET "name" = excluded."name";` + "\n"
+	if string(got) != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+}
+
+func TestExport_SaveSQLRequiresTable(t *testing.T) {
+	m := newExportSaveModel()
+	for i, f := range export.Formats {
+		if f == export.SQL {
+			m.exportSt.format = i
+		}
+	}
+	path := filepath.Join(t.TempDir(), "out.sql")
+	m.exportSt.saving = true
+	m.exportSt.path.SetValue(path)
+	m.saveExportFile()
+
+	if !m.exportSt.saving || m.exportSt.err == "" {
+		t.Errorf("expected dialog to stay open with error, saving=%v err=%q", m.exportSt.saving, m.exportSt.err)
+	}
+	if _, err := os.Stat(path); err == nil {
+		t.Error("file should not be written without a table name")
+	}
+}
+
+func TestExport_SaveTypedJSONArrays(t *testing.T) {
+	m := newExportSaveModel()
+	m.lastResult.ColumnTypes = []string{"INTEGER", "TEXT"}
+	for i, f := range export.Formats {
+		if f == export.JSONLines {
+			m.exportSt.format = i
+		}
+	}
+	m.exportSt.focus = exportFieldScope
+	m.moveExportFocus(1)
+	if m.exportSt.focus != exportFieldShape {
+		t.Fatalf("expected shape field for JSON Lines, got %d", m.exportSt.focus)
+	}
+	m.cycleExportField(1)
+
+	path := filepath.Join(t.TempDir(), "out.jsonl")
+	m.exportSt.saving = true
+	m.exportSt.path.SetValue(path)
+	m.exportSt.scope = scopeAll
+	m.saveExportFile()
+
+	got, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("file not written: %v (dialog err %q)", err, m.exportSt.err)
+	}
+	want := "[\"id\",\"name\"]\n[2,\"bob\"]\n[1,\"alice\"]\n[3,\"carol\"]\n"
+	if string(got) != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+}
+
+func TestExport_FullResultStreamsBeyondLimit(t *testing.T) {
+	adapter, err := sqlite.Open(":memory:")
+	if err != nil {
+		t.Fatalf("sqlite.Open: %v", err)
+	}
+	t.Cleanup(func() { adapter.Close() })
+
+	m := newExportSaveModel()
+	m.connMgr = newConnManager("test", ":memory:", adapter)
+	m.lastQuery = "WITH RECURSIVE c(x) AS (SELECT 1 UNION ALL SELECT x+1 FROM c WHERE x < 10005) SELECT x FROM c"
+	m.lastResult.Truncated = true
+
+	path := filepath.Join(t.TempDir(), "full.csv")
+	m.exportSt.saving = true
+	m.exportSt.path.SetValue(path)
+	m.exportSt.scope = scopeFull
+	cmd := m.saveExportFile()
+	if cmd == nil {
+		t.Fatalf("expected stream command, dialog err %q", m.exportSt.err)
+	}
+	if m.mode != normalMode || m.streamSt.cancel == nil {
+		t.Fatalf("expected running export in normalMode, mode=%q", m.mode)
+	}
+	if got := m.statusHints(); got != "C-c:cancel export" {
+		t.Errorf("unexpected hints while exporting: %q", got)
+	}
+
+	done := streamExportCmd(context.Background(), adapter, m.lastQuery, m.streamSt.sink, time.UTC, m.streamSt.seq)()
+	m.handleStreamExportDone(done.(streamExportDoneMsg))
+	if m.streamSt.cancel != nil {
+		t.Error("expected export to be finished")
+	}
+	if !strings.Contains(m.statusText, "Exported 10005 row(s)") {
+		t.Errorf("unexpected status: %q", m.statusText)
+	}
+	content, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if lines := strings.Count(string(content), "\n"); lines != 10006 {
+		t.Errorf("expected header + 10005 rows, got %d lines", lines)
+	}
+}
+
+func TestExport_FullResultCancel(t *testing.T) {
+	adapter, err := sqlite.Open(":memory:")
+	if err != nil {
+		t.Fatalf("sqlite.Open: %v", err)
+	}
+	t.Cleanup(func() { adapter.Close() })
+
+	m := newExportSaveModel()
+	m.connMgr = newConnManager("test", ":memory:", adapter)
+	m.lastQuery = "SELECT 1"
+
+	path := filepath.Join(t.TempDir(), "full.csv")
+	m.exportSt.saving = true
+	m.exportSt.path.SetValue(path)
+	m.exportSt.scope = scopeFull
+	if cmd := m.saveExportFile(); cmd == nil {
+		t.Fatalf("expected stream command, dialog err %q", m.exportSt.err)
+	}
+	sink, seq := m.streamSt.sink, m.streamSt.seq
+
+	result, _ := m.Update(tea.KeyMsg{Type: tea.KeyCtrlC})
+	rm := result.(model)
+	if rm.streamSt.cancel != nil {
+		t.Fatal("expected Ctrl+C to cancel the export")
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	done := streamExportCmd(ctx, adapter, rm.lastQuery, sink, time.UTC, seq)()
+	rm.handleStreamExportDone(done.(streamExportDoneMsg))
+	if !strings.Contains(rm.statusText, "cancelled") {
+		t.Errorf("unexpected status: %q", rm.statusText)
+	}
+	if _, err := os.Stat(path); !os.IsNotExist(err) {
+		t.Errorf("expected partial file to be removed, stat err = %v", err)
+	}
+}
+
+func TestExport_SaveJSONKeepsTextNULL(t *testing.T) {
+	m, adapter := newEditModel(t)
+	ctx := context.Background()
+	if _, err := adapter.Query(ctx, "UPDATE items SET note = 'NULL' WHERE id = 1"); err != nil {
+		t.Fatal(err)
+	}
+	res, err := adapter.Query(ctx, m.lastQuery)
+	if err != nil {
+		t.Fatal(err)
+	}
+	m.lastResult = res
+	m.applyResult(res)
+	m.colCursor = 0
+	m.toggleSort()
+	m.toggleSort() // id descending: the flags follow the rows
+
+	m.exportSt.path = textinput.New()
+	m.exportSt.table = textinput.New()
+	m.exportSt.keys = textinput.New()
+	m.exportSt.format = slices.Index(export.Formats, export.JSONLines)
+	m.exportSt.scope = scopeView
+	path := filepath.Join(t.TempDir(), "out.jsonl")
+	m.exportSt.saving = true
+	m.exportSt.path.SetValue(path)
+	m.saveExportFile()
+
+	got, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("file not written: %v (dialog err %q)", err, m.exportSt.err)
+	}
+	want := `{"id":2,"flag":"ok","note":null}` + "\n" + `{"id":1,"flag":"bad","note":"NULL"}` + "\n"
+	if string(got) != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+}
+
+func TestExport_NullsFollowViewRows(t *testing.T) {
+	m, adapter := newEditModel(t)
+	ctx := context.Background()
+	for _, q := range []string{
+		"UPDATE items SET flag = 'ok', note = 'NULL' WHERE id = 1",
+		"INSERT INTO items VALUES (3, 'bad', NULL)",
+	} {
+		if _, err := adapter.Query(ctx, q); err != nil {
+			t.Fatal(err)
+		}
+	}
+	res, err := adapter.Query(ctx, m.lastQuery)
+	if err != nil {
+		t.Fatal(err)
+	}
+	m.applyResult(res)
+	m.setFilter(1, "ok")
+	m.colCursor = 0
+	m.toggleSort()
+	m.toggleSort() // rows 2 then 1
+
+	want := [][]bool{{false, false, true}, {false, false, false}}
+	if got := m.exportNulls(scopeView); !slices.EqualFunc(got, want, slices.Equal) {
+		t.Errorf("view nulls = %v, want %v", got, want)
+	}
+
+	// A block selection of the note column on the first view row.
+	m.visual = visualState{active: true, block: true, anchorCol: 2}
+	m.colCursor = 2
+	if got := m.exportNulls(scopeSelected); !slices.EqualFunc(got, [][]bool{{true}}, slices.Equal) {
+		t.Errorf("selection nulls = %v", got)
+	}
+}
@@ -0,0 +1,96 @@
+package ui
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// editorClosedMsg carries the query written in the external editor.
+type editorClosedMsg struct {
+	query   string
+	execute bool // run the query once it is loaded
+	err     error
+}
+
+// editorCommand returns the command that edits path: $VISUAL, then
+// $EDITOR, then vi. The variable may carry arguments, as in "code --wait".
+func editorCommand(path string) *exec.Cmd {
+	editor := os.Getenv("VISUAL")
+	if strings.TrimSpace(editor) == "" {
+		editor = os.Getenv("EDITOR")
+	}
+	args := strings.Fields(editor)
+	if len(args) == 0 {
+		args = []string{"vi"}
+	}
+	return exec.Command(args[0], append(args[1:], path)...)
+}
+
+// openExternalEditor writes the buffer to a temporary .sql file and
+// suspends the program while the external editor runs on it.
+func (m *model) openExternalEditor(execute bool) tea.Cmd {
+	f, err := os.CreateTemp("", "asql-*.sql")
+	if err != nil {
+		m.setStatus("Cannot open editor: "+err.Error(), true)
+		return nil
+	}
+	path := f.Name()
+	_, err = f.WriteString(m.textarea.Value())
+	if cerr := f.Close(); err == nil {
+		err = cerr
+	}
+	if err != nil {
+		os.Remove(path)
+		m.setStatus("Cannot open editor: "+err.Error(), true)
+		return nil
+	}
+	m.closeCompletion()
+	return tea.ExecProcess(editorCommand(path), editorFinished(path, execute))
+}
+
+// editorFinished reads back the file the editor worked on and removes it.
+func editorFinished(path string, execute bool) tea.ExecCallback {
+	return func(err error) tea.Msg {
+		defer os.Remove(path)
+		if err != nil {
+			return editorClosedMsg{err: err}
+		}
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return editorClosedMsg{err: err}
+		}
+		// Editors end the file with a newline that the buffer did not have.
+		query := strings.TrimSuffix(strings.TrimSuffix(string(data), "\n"), "\r")
+		return editorClosedMsg{query: query, execute: execute}
+	}
+}
+
+// handleEditorClosed loads the query from the external editor into the
+// buffer and runs it if asked to. When the editor fails or exits with an
+// error, as with vim's :cq, the buffer is left as it was.
+func (m *model) handleEditorClosed(msg editorClosedMsg) tea.Cmd {
+	if msg.err != nil {
+		var exitErr *exec.ExitError
+		if errors.As(msg.err, &exitErr) {
+			m.setStatus(fmt.Sprintf("Editor exited with status %d; query unchanged", exitErr.ExitCode()), true)
+		} else {
+			m.setStatus("Editor failed: "+msg.err.Error(), true)
+		}
+		return nil
+	}
+	m.textarea.SetValue(msg.query)
+	m.historyIdx = -1
+	m.syncViewport()
+	query := strings.TrimSpace(msg.query)
+	if !msg.execute || query == "" {
+		m.setStatus("Query loaded from editor", false)
+		return nil
+	}
+	m.setStatus("Executing query...", false)
+	return m.prepareAndExecuteQuery(query)
+}
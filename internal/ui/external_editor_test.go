@@ -0,0 +1,90 @@
+package ui
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestEditorCommand(t *testing.T) {
+	tests := []struct {
+		visual, editor string
+		want           []string
+	}{
+		{"code --wait", "nano", []string{"code", "--wait", "q.sql"}},
+		{"", "nvim", []string{"nvim", "q.sql"}},
+		{" ", "", []string{"vi", "q.sql"}},
+	}
+	for _, tt := range tests {
+		t.Setenv("VISUAL", tt.visual)
+		t.Setenv("EDITOR", tt.editor)
+		if got := editorCommand("q.sql").Args; !slices.Equal(got, tt.want) {
+			t.Errorf("VISUAL=%q EDITOR=%q: args = %v, want %v", tt.visual, tt.editor, got, tt.want)
+		}
+	}
+}
+
+// runEditor runs the editor command on a file holding text, as the
+// program would, and returns what editorFinished reports.
+func runEditor(t *testing.T, script, text string, execute bool) editorClosedMsg {
+	t.Helper()
+	dir := t.TempDir()
+	scriptPath := filepath.Join(dir, "editor.sh")
+	if err := os.WriteFile(scriptPath, []byte(script), 0o700); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("VISUAL", "")
+	t.Setenv("EDITOR", "sh "+scriptPath)
+	path := filepath.Join(dir, "query.sql")
+	if err := os.WriteFile(path, []byte(text), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	msg := editorFinished(path, execute)(editorCommand(path).Run()).(editorClosedMsg)
+	if _, err := os.Stat(path); !os.IsNotExist(err) {
+		t.Errorf("expected the temporary file to be removed, got %v", err)
+	}
+	return msg
+}
+
+func TestExternalEditorReloadsBuffer(t *testing.T) {
+	m := newTestModel()
+	m.textarea.SetValue("SELECT 1")
+	msg := runEditor(t, `printf 'SELECT id\nFROM users\n' > "$1"`, m.textarea.Value(), false)
+	if cmd := m.handleEditorClosed(msg); cmd != nil {
+		t.Error("expected the query not to run")
+	}
+	if got := m.textarea.Value(); got != "SELECT id\nFROM users" {
+		t.Errorf("buffer = %q", got)
+	}
+	if m.statusError || !strings.Contains(m.statusText, "loaded") {
+		t.Errorf("status = %q", m.statusText)
+	}
+}
+
+func TestExternalEditorExecutes(t *testing.T) {
+	m := newTestModel()
+	msg := runEditor(t, `echo 'SELECT 2' > "$1"`, "", true)
+	if cmd := m.handleEditorClosed(msg); cmd == nil {
+		t.Fatal("expected the query to run")
+	}
+	if m.statusText != "Executing query..." || m.queryHistory[len(m.queryHistory)-1] != "SELECT 2" {
+		t.Errorf("status = %q, history = %v", m.statusText, m.queryHistory)
+	}
+}
+
+func TestExternalEditorFailureKeepsBuffer(t *testing.T) {
+	m := newTestModel()
+	m.textarea.SetValue("SELECT 1")
+	msg := runEditor(t, `echo 'DROP TABLE users' > "$1"; exit 1`, m.textarea.Value(), true)
+	if cmd := m.handleEditorClosed(msg); cmd != nil {
+		t.Error("expected nothing to run after the editor failed")
+	}
+	if got := m.textarea.Value(); got != "SELECT 1" {
+		t.Errorf("buffer = %q, want it unchanged", got)
+	}
+	if !m.statusError || !strings.Contains(m.statusText, "status 1") {
+		t.Errorf("status = %q", m.statusText)
+	}
+}
@@ -0,0 +1,55 @@
+package ui
+
+import "fmt"
+
+// filteredIndices returns the indexes of the rows that pass f.
+func filteredIndices(rows [][]string, f gridFilter) []int {
+	out := make([]int, 0, len(rows))
+	for i, row := range rows {
+		if !f.active || f.col < len(row) && row[f.col] == f.value {
+			out = append(out, i)
+		}
+	}
+	return out
+}
+
+// setFilter shows only the rows whose cell in col equals value.
+func (m *model) setFilter(col int, value string) {
+	if col < 0 || col >= len(m.lastResult.Columns) {
+		return
+	}
+	m.filter = gridFilter{active: true, col: col, value: value}
+	m.applySortedResult()
+	m.setStatus(fmt.Sprintf("Filter %s: %d of %d row(s) (F:clear)", m.filterLabel(), m.filter.matched, len(m.lastResult.Rows)), false)
+}
+
+// clearFilter shows every row again.
+func (m *model) clearFilter() {
+	if !m.filter.active {
+		m.setStatus("No filter", false)
+		return
+	}
+	m.filter = gridFilter{}
+	m.applySortedResult()
+	m.setStatus("Filter cleared", false)
+}
+
+// filterLabel describes the active filter, e.g. `flag = 'ok'`.
+func (m model) filterLabel() string {
+	if !m.filter.active || m.filter.col >= len(m.lastResult.Columns) {
+		return ""
+	}
+	value := "NULL"
+	if m.filter.value != "NULL" {
+		value = "'" + truncate(sanitize(m.filter.value), 20) + "'"
+	}
+	return sanitize(m.lastResult.Columns[m.filter.col]) + " = " + value
+}
+
+// rowCount returns the number of result rows shown in the grid.
+func (m model) rowCount() int {
+	if m.filter.active {
+		return m.filter.matched
+	}
+	return len(m.lastResult.Rows)
+}
@@ -0,0 +1,50 @@
+package ui
+
+import (
+	"testing"
+)
+
+func TestStatsTopValueFilter(t *testing.T) {
+	m, _ := newEditModel(t)
+	next, cmd := m.updateNormal(runeMsg("d"))
+	rm := runEditCmd(t, next.(model), cmd)
+
+	// Filter on flag's first top value ("bad" and "ok" tie; "bad" sorts first).
+	next, _ = rm.updateStats(runeMsg("j"))
+	rm = next.(model)
+	next, _ = rm.updateStats(runeMsg("1"))
+	rm = next.(model)
+	if rm.mode != normalMode || !rm.filter.active || rm.colCursor != 1 {
+		t.Fatalf("expected a filter on flag, got mode %q filter %+v", rm.mode, rm.filter)
+	}
+	rows := rm.viewRows()
+	if rm.rowCount() != 1 || len(rows) != 1 || rows[0][1] != "bad" {
+		t.Errorf("filtered rows = %v", rows)
+	}
+	if got := rm.statusPositionInfo(); got != "filter:flag = 'bad' col:flag 1/1" {
+		t.Errorf("status position = %q", got)
+	}
+
+	// Sorting keeps the filter; F clears it.
+	rm.toggleSort()
+	if rm.rowCount() != 1 {
+		t.Error("expected sorting to keep the filter")
+	}
+	next, _ = rm.updateNormal(runeMsg("F"))
+	rm = next.(model)
+	if rm.filter.active || len(rm.viewRows()) != 2 {
+		t.Errorf("expected F to clear the filter, got %+v", rm.filter)
+	}
+}
+
+func TestFilterNoMatches(t *testing.T) {
+	m, _ := newEditModel(t)
+	m.setFilter(2, "missing")
+	if m.rowCount() != 0 || m.viewRows() != nil {
+		t.Errorf("expected no rows, got %v", m.viewRows())
+	}
+	next, _ := m.updateNormal(runeMsg("V"))
+	if next.(model).visual.active {
+		t.Error("expected VISUAL to need rows")
+	}
+}
@@ -51,6 +51,12 @@ func (m model) updateInsert(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 		return m, m.prepareAndExecuteQuery(query)
 	case tea.KeyCtrlR:
 		return m.enterHistorySearchMode()
+	case tea.KeyCtrlG:
+		// Ctrl+F stays the editor's cursor-forward key.
+		m.formatQuery()
+		return m, nil
+	case tea.KeyCtrlO, tea.KeyCtrlX:
+		return m, m.openExternalEditor(msg.Type == tea.KeyCtrlX)
 	case tea.KeyCtrlL:
 		m.textarea.SetValue("")
 		m.historyIdx = -1
@@ -85,5 +91,8 @@ func (m model) updateInsert(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 	var cmd tea.Cmd
 	m.textarea, cmd = m.textarea.Update(msg)
 	m.syncViewport()
+	if msg.Type == tea.KeyRunes && string(msg.Runes) == "." {
+		return m, tea.Batch(cmd, m.completeAfterDot())
+	}
 	return m, cmd
 }
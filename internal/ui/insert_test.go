@@ -22,6 +22,33 @@ func TestInsert_EscReturnsNormal(t *testing.T) {
 	}
 }
 
+func TestInsert_CtrlGFormatsQuery(t *testing.T) {
+	m := newInsertModel()
+	m.textarea.SetValue("select a, b from t where a = 'x'")
+	updated, _ := m.Update(tea.KeyMsg{Type: tea.KeyCtrlG})
+	result := updated.(model)
+	want := "SELECT a,\n       b\nFROM t\nWHERE a = 'x'"
+	if got := result.textarea.Value(); got != want {
+		t.Errorf("value = %q, want %q", got, want)
+	}
+
+	result.textarea.SetValue("select 'open")
+	updated, _ = result.Update(tea.KeyMsg{Type: tea.KeyCtrlG})
+	result = updated.(model)
+	if result.textarea.Value() != "select 'open" || !result.statusError {
+		t.Errorf("expected an unterminated literal to be left alone with an error, got %q", result.textarea.Value())
+	}
+
+	// Ctrl+F keeps moving the cursor forward.
+	result.textarea.SetValue("select 1")
+	result.textarea.CursorStart()
+	updated, _ = result.Update(tea.KeyMsg{Type: tea.KeyCtrlF})
+	result = updated.(model)
+	if result.textarea.Value() != "select 1" || result.textarea.LineInfo().CharOffset != 1 {
+		t.Errorf("expected Ctrl+F to move the cursor, got %q at %d", result.textarea.Value(), result.textarea.LineInfo().CharOffset)
+	}
+}
+
 func TestInsert_CtrlLClearsEditor(t *testing.T) {
 	m := newInsertModel()
 	m.textarea.SetValue("SELECT 1")
@@ -149,7 +176,7 @@ func TestInsert_CtrlJReturnsCmd(t *testing.T) {
 func TestInsert_CompletionTabCyclesForward(t *testing.T) {
 	m := newInsertModel()
 	m.completion.active = true
-	m.completion.items = []string{"users", "posts", "comments"}
+	m.completion.items = nameItems([]string{"users", "posts", "comments"}, "")
 	m.completion.cursor = 0
 
 	updated, _ := m.Update(tea.KeyMsg{Type: tea.KeyTab})
@@ -171,7 +198,7 @@ func TestInsert_CompletionTabCyclesForward(t *testing.T) {
 func TestInsert_CompletionEscCloses(t *testing.T) {
 	m := newInsertModel()
 	m.completion.active = true
-	m.completion.items = []string{"users"}
+	m.completion.items = nameItems([]string{"users"}, "")
 
 	updated, _ := m.Update(tea.KeyMsg{Type: tea.KeyEsc})
 	result := updated.(model)
@@ -187,7 +214,7 @@ func TestInsert_CompletionEscCloses(t *testing.T) {
 func TestInsert_CompletionCtrlNMovesDown(t *testing.T) {
 	m := newInsertModel()
 	m.completion.active = true
-	m.completion.items = []string{"a", "b", "c"}
+	m.completion.items = nameItems([]string{"a", "b", "c"}, "")
 	m.completion.cursor = 0
 
 	updated, _ := m.Update(tea.KeyMsg{Type: tea.KeyCtrlN})
@@ -200,7 +227,7 @@ func TestInsert_CompletionCtrlNMovesDown(t *testing.T) {
 func TestInsert_CompletionCtrlPMovesUp(t *testing.T) {
 	m := newInsertModel()
 	m.completion.active = true
-	m.completion.items = []string{"a", "b", "c"}
+	m.completion.items = nameItems([]string{"a", "b", "c"}, "")
 	m.completion.cursor = 2
 
 	updated, _ := m.Update(tea.KeyMsg{Type: tea.KeyCtrlP})
@@ -0,0 +1,226 @@
+package ui
+
+import (
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/kwrkb/asql/internal/db"
+)
+
+// jsonPathElem is one step of a JSON path: an object key or an array index.
+type jsonPathElem struct {
+	key     string
+	index   int
+	isIndex bool
+}
+
+// jsonPath addresses a value inside a JSON document.
+type jsonPath []jsonPathElem
+
+// child returns a copy of p extended by elem, so sibling paths never share
+// a backing array.
+func (p jsonPath) child(elem jsonPathElem) jsonPath {
+	out := make(jsonPath, len(p), len(p)+1)
+	copy(out, p)
+	return append(out, elem)
+}
+
+var plainJSONKey = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+
+// String renders p as dotted keys with bracketed indexes, e.g.
+// "user.tags[0]". Keys that are not plain identifiers are quoted:
+// `["a.b"]`.
+func (p jsonPath) String() string {
+	var b strings.Builder
+	for _, e := range p {
+		switch {
+		case e.isIndex:
+			fmt.Fprintf(&b, "[%d]", e.index)
+		case plainJSONKey.MatchString(e.key):
+			if b.Len() > 0 {
+				b.WriteByte('.')
+			}
+			b.WriteString(e.key)
+		default:
+			b.WriteString("[" + strconv.Quote(e.key) + "]")
+		}
+	}
+	return b.String()
+}
+
+// lookupJSONPath follows p inside the JSON text cell. It reports false
+// when the cell is not JSON or the path does not exist.
+func lookupJSONPath(cell string, p jsonPath) (any, bool) {
+	if cell == "NULL" || cell == `""` {
+		return nil, false
+	}
+	dec := json.NewDecoder(strings.NewReader(cell))
+	dec.UseNumber()
+	var v any
+	if err := dec.Decode(&v); err != nil {
+		return nil, false
+	}
+	for _, e := range p {
+		switch node := v.(type) {
+		case map[string]any:
+			next, ok := node[e.key]
+			if e.isIndex || !ok {
+				return nil, false
+			}
+			v = next
+		case []any:
+			if !e.isIndex || e.index < 0 || e.index >= len(node) {
+				return nil, false
+			}
+			v = node[e.index]
+		default:
+			return nil, false
+		}
+	}
+	return v, true
+}
+
+// jsonDisplayCell renders a decoded JSON value as a result cell: strings
+// unquoted, numbers and booleans as text, objects and arrays as compact
+// JSON, and null as "NULL".
+func jsonDisplayCell(v any) string {
+	switch val := v.(type) {
+	case nil:
+		return "NULL"
+	case string:
+		if val == "" {
+			return `""`
+		}
+		return val
+	case json.Number:
+		return val.String()
+	case bool:
+		return strconv.FormatBool(val)
+	default:
+		b, err := json.Marshal(val)
+		if err != nil {
+			return "NULL"
+		}
+		return string(b)
+	}
+}
+
+// withJSONPathColumn returns a copy of result with a derived column that
+// extracts p from column src of every row. Missing paths and cells that are
+// not JSON yield NULL. The derived column's type is inferred from the
+// extracted values so sorting, stats and typed exports treat it like a
+// database column; it is flagged in Derived so that SQL exports, edits and
+// table profiles leave it out.
+func withJSONPathColumn(result db.QueryResult, src int, p jsonPath) (db.QueryResult, string) {
+	name := result.Columns[src] + "." + p.String()
+	if len(p) > 0 && p[0].isIndex {
+		name = result.Columns[src] + p.String()
+	}
+
+	values := make([]string, len(result.Rows))
+	kinds := map[string]bool{}
+	for i, row := range result.Rows {
+		values[i] = "NULL"
+		if src >= len(row) {
+			continue
+		}
+		v, ok := lookupJSONPath(row[src], p)
+		if !ok || v == nil {
+			continue
+		}
+		values[i] = jsonDisplayCell(v)
+		switch v.(type) {
+		case json.Number:
+			kinds["NUMERIC"] = true
+		case bool:
+			kinds["BOOLEAN"] = true
+		case string:
+			kinds["TEXT"] = true
+		default:
+			kinds["JSON"] = true
+		}
+	}
+	colType := "TEXT"
+	if len(kinds) == 1 {
+		for k := range kinds {
+			colType = k
+		}
+	}
+
+	out := result
+	out.Columns = append(append([]string(nil), result.Columns...), name)
+	types := make([]string, len(result.Columns), len(result.Columns)+1)
+	copy(types, result.ColumnTypes)
+	out.ColumnTypes = append(types, colType)
+	derived := make([]bool, len(result.Columns), len(result.Columns)+1)
+	copy(derived, result.Derived)
+	out.Derived = append(derived, true)
+	out.Rows = make([][]string, len(result.Rows))
+	for i, row := range result.Rows {
+		newRow := make([]string, len(result.Columns)+1)
+		copy(newRow, row)
+		newRow[len(result.Columns)] = values[i]
+		out.Rows[i] = newRow
+	}
+	if result.Nulls != nil {
+		// A missing path is NULL.
+		out.Nulls = make([][]bool, len(result.Rows))
+		for i := range out.Nulls {
+			nulls := make([]bool, len(result.Columns)+1)
+			if i < len(result.Nulls) {
+				copy(nulls, result.Nulls[i])
+			}
+			nulls[len(result.Columns)] = values[i] == "NULL"
+			out.Nulls[i] = nulls
+		}
+	}
+	return out, name
+}
+
+// derivedColumn reports whether column col of result was computed by asql.
+func derivedColumn(result db.QueryResult, col int) bool {
+	return col >= 0 && col < len(result.Derived) && result.Derived[col]
+}
+
+// withoutDerived drops the derived columns of the current result from
+// exported headers, rows, column types and NULL flags, which line up with
+// each other. Their first column is column first of the result; columns
+// are matched by position, so a real column sharing a derived column's
+// name stays.
+func (m *model) withoutDerived(first int, headers []string, rows [][]string, types []string, nulls [][]bool) ([]string, [][]string, []string, [][]bool) {
+	var keep []int
+	for i := range headers {
+		if !derivedColumn(m.lastResult, first+i) {
+			keep = append(keep, i)
+		}
+	}
+	if len(keep) == len(headers) {
+		return headers, rows, types, nulls
+	}
+	out := make([][]string, len(rows))
+	for r, row := range rows {
+		out[r] = pickCells(row, keep)
+	}
+	var outNulls [][]bool
+	if nulls != nil {
+		outNulls = make([][]bool, len(nulls))
+		for r, row := range nulls {
+			outNulls[r] = pickCells(row, keep)
+		}
+	}
+	return pickCells(headers, keep), out, pickCells(types, keep), outNulls
+}
+
+// pickCells returns the cells at the indexes keep, zero where missing.
+func pickCells[T any](cells []T, keep []int) []T {
+	out := make([]T, len(keep))
+	for j, i := range keep {
+		if i < len(cells) {
+			out[j] = cells[i]
+		}
+	}
+	return out
+}
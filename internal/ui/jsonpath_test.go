@@ -0,0 +1,181 @@
+package ui
+
+import (
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/charmbracelet/bubbles/textinput"
+
+	"github.com/kwrkb/asql/internal/db"
+)
+
+func TestJSONPath_String(t *testing.T) {
+	p := jsonPath{{key: "user"}, {key: "tags"}, {index: 0, isIndex: true}, {key: "a.b"}}
+	if got, want := p.String(), `user.tags[0]["a.b"]`; got != want {
+		t.Errorf("String() = %q, want %q", got, want)
+	}
+	if got := (jsonPath{{index: 2, isIndex: true}, {key: "id"}}).String(); got != "[2].id" {
+		t.Errorf("String() = %q, want [2].id", got)
+	}
+}
+
+func TestWithJSONPathColumn(t *testing.T) {
+	result := db.QueryResult{
+		Columns:     []string{"id", "payload"},
+		ColumnTypes: []string{"INTEGER", "JSONB"},
+		Rows: [][]string{
+			{"1", `{"user":{"id":10,"tags":["a","b"],"ok":true,"name":""}}`},
+			{"2", `{"user":{"id":2}}`},
+			{"3", "NULL"},
+			{"4", "not json"},
+			{"5", `{"user":{"id":null}}`},
+		},
+	}
+	orig := result.Rows[0]
+
+	tests := []struct {
+		name     string
+		path     jsonPath
+		wantName string
+		wantType string
+		want     []string
+	}{
+		{"number", jsonPath{{key: "user"}, {key: "id"}}, "payload.user.id", "NUMERIC",
+			[]string{"10", "2", "NULL", "NULL", "NULL"}},
+		{"array element", jsonPath{{key: "user"}, {key: "tags"}, {index: 1, isIndex: true}}, "payload.user.tags[1]", "TEXT",
+			[]string{"b", "NULL", "NULL", "NULL", "NULL"}},
+		{"container", jsonPath{{key: "user"}, {key: "tags"}}, "payload.user.tags", "JSON",
+			[]string{`["a","b"]`, "NULL", "NULL", "NULL", "NULL"}},
+		{"bool", jsonPath{{key: "user"}, {key: "ok"}}, "payload.user.ok", "BOOLEAN",
+			[]string{"true", "NULL", "NULL", "NULL", "NULL"}},
+		{"empty string", jsonPath{{key: "user"}, {key: "name"}}, "payload.user.name", "TEXT",
+			[]string{`""`, "NULL", "NULL", "NULL", "NULL"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, name := withJSONPathColumn(result, 1, tt.path)
+			if name != tt.wantName || got.Columns[2] != tt.wantName {
+				t.Errorf("name = %q, want %q", name, tt.wantName)
+			}
+			if got.ColumnTypes[2] != tt.wantType {
+				t.Errorf("type = %q, want %q", got.ColumnTypes[2], tt.wantType)
+			}
+			for i, row := range got.Rows {
+				if row[2] != tt.want[i] {
+					t.Errorf("row %d = %q, want %q", i, row[2], tt.want[i])
+				}
+			}
+		})
+	}
+	if len(result.Columns) != 2 || len(orig) != 2 {
+		t.Error("source result must not be modified")
+	}
+}
+
+func TestViewer_AddJSONPathColumn(t *testing.T) {
+	m := newTestModel()
+	m.viewer.search = textinput.New()
+	m.applyResult(db.QueryResult{
+		Columns:     []string{"id", "payload"},
+		ColumnTypes: []string{"INTEGER", "JSONB"},
+		Rows: [][]string{
+			{"1", `{"user":{"id":10}}`},
+			{"2", `{"user":{"id":9}}`},
+			{"3", `{"user":{}}`},
+		},
+	})
+	m.mode = detailMode
+	m.detail.fieldCursor = 1
+
+	result, _ := m.updateDetail(runeMsg("v"))
+	rm := result.(model)
+	// Lines: `{`, `"user": {`, `"id": 10`, ...
+	rm.viewer.cursor = 2
+	result, _ = rm.updateViewer(runeMsg("a"))
+	rm = result.(model)
+
+	if rm.mode != normalMode {
+		t.Fatalf("expected normalMode, got %q (%s)", rm.mode, rm.statusText)
+	}
+	if got := rm.lastResult.Columns; len(got) != 3 || got[2] != "payload.user.id" {
+		t.Fatalf("unexpected columns %v", got)
+	}
+	if rm.colCursor != 2 {
+		t.Errorf("expected cursor on new column, got %d", rm.colCursor)
+	}
+
+	// The derived column sorts numerically with NULL last.
+	rm.toggleSort()
+	var got []string
+	for _, row := range rm.displayRows {
+		got = append(got, row[2])
+	}
+	if want := []string{"9", "10", "NULL"}; len(got) != 3 || got[0] != want[0] || got[1] != want[1] || got[2] != want[2] {
+		t.Errorf("sorted column = %v, want %v", got, want)
+	}
+
+	stats := computeColumnStats(rm.lastResult)
+	if s := stats[2]; s.Min != "9" || s.Max != "10" || s.NullCnt != 1 {
+		t.Errorf("unexpected stats %+v", s)
+	}
+
+	// Adding the same path twice is rejected.
+	rm.mode = detailMode
+	rm.detail.fieldCursor = 1
+	result, _ = rm.updateDetail(runeMsg("v"))
+	rm = result.(model)
+	rm.viewer.cursor = 2
+	result, _ = rm.updateViewer(runeMsg("a"))
+	rm = result.(model)
+	if !rm.statusError || len(rm.lastResult.Columns) != 3 {
+		t.Errorf("expected duplicate column to be rejected, status %q", rm.statusText)
+	}
+}
+
+func TestDerivedColumnStaysOutOfTheTable(t *testing.T) {
+	m, _ := newEditModel(t)
+	m.lastResult, _ = withJSONPathColumn(m.lastResult, 2, jsonPath{{key: "a"}})
+	m.applySortedResult()
+	if !derivedColumn(m.lastResult, 3) || derivedColumn(m.lastResult, 2) {
+		t.Fatalf("Derived = %v", m.lastResult.Derived)
+	}
+
+	// It cannot be edited.
+	next, cmd := m.startCellEdit(0, 3)
+	if rm := next.(model); cmd != nil || !rm.statusError || !strings.Contains(rm.statusText, "derived") {
+		t.Errorf("expected the edit to be refused, status %q", rm.statusText)
+	}
+
+	// A duplicate leaves it out.
+	next, cmd = m.updateNormal(runeMsg("D"))
+	rm := runEditCmd(t, next.(model), cmd)
+	if len(rm.editSt.pending) != 1 || !slices.Equal(rm.editSt.pending[0].insertCols, []string{"flag", "note"}) {
+		t.Errorf("pending = %+v", rm.editSt.pending)
+	}
+
+	// SQL exports drop it.
+	headers, rows, types, nulls := m.withoutDerived(0, m.lastResult.Columns, m.lastResult.Rows, m.lastResult.ColumnTypes, m.lastResult.Nulls)
+	if !slices.Equal(headers, []string{"id", "flag", "note"}) || len(rows[0]) != 3 || len(types) != 3 || len(nulls[0]) != 3 {
+		t.Errorf("withoutDerived = %v %v %v %v", headers, rows, types, nulls)
+	}
+	// Columns are matched by position: a real column named like the
+	// derived one stays, and a column range starts at its own offset.
+	same := []string{"flag", "note", m.lastResult.Columns[3]}
+	same[0] = same[2]
+	headers, rows, _, _ = m.withoutDerived(1, same, [][]string{{"bad", "x", "1"}}, nil, nil)
+	if !slices.Equal(headers, same[:2]) || !slices.Equal(rows[0], []string{"bad", "x"}) {
+		t.Errorf("withoutDerived by position = %v %v", headers, rows)
+	}
+
+	// A table profile does not query it.
+	m.mode = statsMode
+	next, cmd = m.updateStats(runeMsg("f"))
+	rm = runUntilIdle(t, next.(model), cmd)
+	if s := rm.statsSt.stats[3]; s.Pending || !strings.Contains(s.Err, "derived") {
+		t.Errorf("derived column stats = %+v", s)
+	}
+	if !strings.HasPrefix(rm.statusText, "Profiled 3 column(s)") {
+		t.Errorf("status = %q", rm.statusText)
+	}
+}
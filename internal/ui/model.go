@@ -16,7 +16,9 @@ import (
 	"github.com/charmbracelet/lipgloss"
 
 	"github.com/kwrkb/asql/internal/ai"
+	"github.com/kwrkb/asql/internal/clipboard"
 	"github.com/kwrkb/asql/internal/db"
+	"github.com/kwrkb/asql/internal/db/dbutil"
 	"github.com/kwrkb/asql/internal/profile"
 	"github.com/kwrkb/asql/internal/snippet"
 )
@@ -34,6 +36,14 @@ const (
 	historySearchMode mode = "SEARCH"
 	profileMode       mode = "PROFILE"
 	statsMode         mode = "STATS"
+	viewerMode        mode = "VIEWER"
+	editMode          mode = "EDIT"
+	pendingMode       mode = "PENDING"
+	visualMode        mode = "VISUAL"
+	pivotMode         mode = "PIVOT"
+	chartMode         mode = "CHART"
+	bucketJoinMode    mode = "JOIN"
+	plotMode          mode = "PLOT"
 
 	queryTimeout       = 5 * time.Second
 	sidebarWidth       = 25
@@ -57,15 +67,11 @@ var typeStyle = lipgloss.NewStyle().Foreground(mutedTextColor)
 
 type queryExecutedMsg struct {
 	seq    uint64
+	query  string
 	result db.QueryResult
 	err    error
 }
 
-type tablesLoadedMsg struct {
-	tables []string
-	err    error
-}
-
 type aiResponseMsg struct {
 	seq uint64
 	sql string
@@ -80,11 +86,12 @@ type connSwitchedMsg struct {
 type statsComputedMsg struct {
 	seq   uint64
 	stats []columnStat
+	diffs []columnStatDiff
 }
 
 type columnsLoadedMsg struct {
 	table   string
-	columns []string
+	columns []db.ColumnInfo
 	err     error
 	connGen uint64 // connection generation when fetch was initiated
 }
@@ -98,11 +105,12 @@ type model struct {
 
 	// Core UI
 	mode     mode
-	textarea textarea.Model
+	textarea sqlEditor
 	table    table.Model
 	viewport viewport.Model
 	width    int
 	height   int
+	clip     *clipboard.Clipboard
 
 	// Status bar
 	statusText  string
@@ -115,6 +123,7 @@ type model struct {
 	queryCancel  context.CancelFunc
 	querySeq     uint64
 	lastResult   db.QueryResult
+	lastQuery    string   // query that produced lastResult
 	queryHistory []string // executed queries (newest at end)
 	historyIdx   int      // -1 = new input, 0..n = history position
 	historyDraft string   // input saved before navigating history
@@ -122,14 +131,25 @@ type model struct {
 	// Result table
 	sortCol         int
 	sortDir         sortOrder
+	filter          gridFilter
+	pivotSt         pivotState
+	chartSt         chartState
+	joinSt          joinState
+	plotSt          plotState
+	tz              tzState
+	pivotBase       *pivotBase  // result before a group-by, restored with u
 	colCursor       int         // column cursor in NORMAL mode
 	colOffset       int         // first visible column index for horizontal windowing
 	cachedColWidths []int       // cached column widths (recomputed only when result changes)
 	displayRows     []table.Row // sorted rows for windowing source
+	displaySrc      []int       // index in lastResult.Rows of each displayRow; nil when in order
 	lastVisStart    int         // cached visible range start for rebuild optimization
 	lastVisEnd      int         // cached visible range end for rebuild optimization
 	viewportDirty   bool        // forces column/row rebuild on next syncViewport
 
+	visual      visualState
+	yankPending bool // y pressed in NORMAL mode, waiting for the yank key
+
 	// Compare
 	pinned      *pinnedPane // nil = side-by-side OFF
 	comparePane int         // 0=left(pinned), 1=right(active)
@@ -141,14 +161,18 @@ type model struct {
 
 	// Mode-specific state
 	detail     detailState
+	viewer     viewerState
 	exportSt   exportState
+	streamSt   streamExportState
 	aiSt       aiState
 	snippetSt  snippetState
 	profileSt  profileState
 	histSearch histSearchState
 	completion completionState
 	sidebar    sidebarState
+	schema     schemaState
 	statsSt    statsState
+	editSt     editState
 }
 
 // CloseAll closes all database connections managed by this model.
@@ -159,7 +183,7 @@ func (m model) CloseAll() {
 	}
 }
 
-func NewModel(adapter db.DBAdapter, dbPath string, rawDSN string, connName string, aiClient *ai.Client, snippets []snippet.Snippet, profiles []profile.Profile) model {
+func NewModel(adapter db.DBAdapter, dbPath string, rawDSN string, connName string, aiClient *ai.Client, clip *clipboard.Clipboard, snippets []snippet.Snippet, profiles []profile.Profile, loc *time.Location) model {
 	input := textarea.New()
 
 	placeholder := db.Placeholder(adapter.Type())
@@ -231,6 +255,33 @@ func NewModel(adapter db.DBAdapter, dbPath string, rawDSN string, connName strin
 	profileIn.CharLimit = 100
 	profileIn.Width = 30
 
+	exportPathIn := textinput.New()
+	exportPathIn.Placeholder = "File path..."
+	exportPathIn.CharLimit = 500
+	exportPathIn.Width = 40
+
+	exportTableIn := textinput.New()
+	exportTableIn.Placeholder = "Table name..."
+	exportTableIn.CharLimit = 200
+	exportTableIn.Width = 40
+
+	exportKeysIn := textinput.New()
+	exportKeysIn.Placeholder = "Key columns (comma-separated)..."
+	exportKeysIn.CharLimit = 500
+	exportKeysIn.Width = 40
+
+	viewerSearchIn := textinput.New()
+	viewerSearchIn.Placeholder = "Search value..."
+	viewerSearchIn.Prompt = "/"
+	viewerSearchIn.CharLimit = 200
+	viewerSearchIn.Width = 40
+
+	editIn := textinput.New()
+	editIn.Placeholder = "New value..."
+	editIn.Prompt = "New: "
+	editIn.CharLimit = 0
+	editIn.Width = 40
+
 	histSearchIn := textinput.New()
 	histSearchIn.Placeholder = "Search history..."
 	histSearchIn.CharLimit = 200
@@ -244,17 +295,27 @@ func NewModel(adapter db.DBAdapter, dbPath string, rawDSN string, connName strin
 		dbPath:     dbPath,
 		rawDSN:     rawDSN,
 		mode:       insertMode,
-		textarea:   input,
+		textarea:   sqlEditor{Model: input, dialect: dbutil.DialectFor(adapter.Type())},
 		table:      tbl,
 		viewport:   vp,
+		clip:       clip,
 		statusText: "Ready",
 		historyIdx: -1,
+		schema:     schemaState{refreshing: true}, // loaded by Init
 		aiSt: aiState{
 			enabled: aiClient != nil,
 			client:  aiClient,
 			input:   aiIn,
 			spinner: sp,
 		},
+		viewer: viewerState{
+			search: viewerSearchIn,
+		},
+		exportSt: exportState{
+			path:  exportPathIn,
+			table: exportTableIn,
+			keys:  exportKeysIn,
+		},
 		snippetSt: snippetState{
 			items: snippets,
 			input: snippetIn,
@@ -266,7 +327,12 @@ func NewModel(adapter db.DBAdapter, dbPath string, rawDSN string, connName strin
 		histSearch: histSearchState{
 			input: histSearchIn,
 		},
+		editSt: editState{
+			input: editIn,
+		},
+		tz: tzState{base: loc},
 	}
+	m.loadProfileZone()
 	m.modeStyle = lipgloss.NewStyle().Bold(true).Padding(0, 1).Background(accentColor).Foreground(panelBackground)
 	m.messageStyle = lipgloss.NewStyle().Padding(0, 1).Foreground(textColor).Background(statusBackground)
 	m.pathStyle = lipgloss.NewStyle().Padding(0, 1).Foreground(mutedTextColor).Background(statusBackground)
@@ -279,7 +345,7 @@ func (m *model) activeDB() db.DBAdapter {
 }
 
 func (m model) Init() tea.Cmd {
-	return tea.Batch(textarea.Blink, loadTablesCmd(m.connMgr.Active()))
+	return tea.Batch(textarea.Blink, loadSchemaCmd(m.activeDB(), m.rawDSN, m.connGen))
 }
 
 func (m *model) blurActiveInput() {
@@ -294,6 +360,14 @@ func (m *model) blurActiveInput() {
 		m.profileSt.input.Blur()
 	case historySearchMode:
 		m.histSearch.input.Blur()
+	case viewerMode:
+		m.viewer.search.Blur()
+	case editMode:
+		m.editSt.input.Blur()
+	case exportMode:
+		m.exportSt.path.Blur()
+		m.exportSt.table.Blur()
+		m.exportSt.keys.Blur()
 	default:
 		m.textarea.Blur()
 	}
@@ -308,6 +382,10 @@ func (m model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		return m, nil
 	case tea.KeyMsg:
 		if msg.Type == tea.KeyCtrlC {
+			if m.streamSt.cancel != nil {
+				m.cancelStreamExport()
+				return m, nil
+			}
 			if m.queryCancel != nil {
 				m.queryCancel()
 				m.queryCancel = nil
@@ -337,6 +415,8 @@ func (m model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 			return m.updateExport(msg)
 		case detailMode:
 			return m.updateDetail(msg)
+		case viewerMode:
+			return m.updateViewer(msg)
 		case snippetMode:
 			return m.updateSnippet(msg)
 		case profileMode:
@@ -345,7 +425,23 @@ func (m model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 			return m.updateHistorySearch(msg)
 		case statsMode:
 			return m.updateStats(msg)
+		case editMode:
+			return m.updateEdit(msg)
+		case pendingMode:
+			return m.updatePending(msg)
+		case visualMode:
+			return m.updateVisual(msg)
+		case pivotMode:
+			return m.updatePivot(msg)
+		case chartMode:
+			return m.updateChart(msg)
+		case bucketJoinMode:
+			return m.updateBucketJoin(msg)
+		case plotMode:
+			return m.updatePlot(msg)
 		}
+	case editorClosedMsg:
+		return m, m.handleEditorClosed(msg)
 	case aiResponseMsg:
 		if msg.seq != m.querySeq {
 			return m, nil
@@ -359,12 +455,28 @@ func (m model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 			m.aiSt.err = msg.err.Error()
 			return m, nil
 		}
-		m.textarea.SetValue(msg.sql)
+		// Generated SQL often comes back on one line; lay it out for review.
+		// Format refuses, and the SQL is kept as generated, when the layout
+		// would not read back as the same tokens.
+		sql := msg.sql
+		if formatted, err := dbutil.Format(sql, m.textarea.dialect); err == nil {
+			sql = formatted
+		}
+		m.textarea.SetValue(sql)
 		m.aiSt.input.Blur()
 		m.mode = insertMode
 		m.textarea.Focus()
 		m.setStatus("AI generated SQL — review before executing", false)
 		return m, nil
+	case editTargetMsg:
+		return m, m.handleEditTarget(msg)
+	case editsAppliedMsg:
+		return m, m.handleEditsApplied(msg)
+	case streamExportTickMsg:
+		return m, m.handleStreamExportTick(msg)
+	case streamExportDoneMsg:
+		m.handleStreamExportDone(msg)
+		return m, nil
 	case spinner.TickMsg:
 		if m.aiSt.loading {
 			var cmd tea.Cmd
@@ -390,64 +502,60 @@ func (m model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		m.dbPath = db.MaskDSN(m.connMgr.ActiveDSN())
 		m.rawDSN = m.connMgr.ActiveDSN()
 		m.completion.colCache = nil
-		m.completion.colOrder = nil
 		m.sidebar.tables = nil
+		m.loadProfileZone()
+		m.applyZone()
+		if adapter := m.connMgr.Active(); adapter != nil {
+			m.textarea.dialect = dbutil.DialectFor(adapter.Type())
+		}
 		m.setStatus(fmt.Sprintf("Connected to %s", sanitize(m.connMgr.ActiveName())), false)
 		m.mode = normalMode
 		m.textarea.Blur()
 		if msg.reExecute {
 			query := strings.TrimSpace(m.textarea.Value())
 			if query != "" {
-				return m, tea.Batch(loadTablesCmd(m.connMgr.Active()), m.prepareAndExecuteQuery(query))
+				return m, tea.Batch(m.loadSchema(), m.prepareAndExecuteQuery(query))
 			}
 		}
-		return m, loadTablesCmd(m.connMgr.Active())
-	case tablesLoadedMsg:
-		if msg.err != nil {
-			m.setStatus("Failed to load tables: "+msg.err.Error(), true)
-			return m, nil
-		}
-		m.sidebar.tables = msg.tables
-		m.completion.colCache = nil
-		m.completion.colOrder = nil // invalidate column cache
-		if m.sidebar.cursor >= len(msg.tables) {
-			m.sidebar.cursor = max(len(msg.tables)-1, 0)
-		}
-		return m, nil
+		return m, m.loadSchema()
+	case schemaLoadedMsg:
+		return m, m.handleSchemaLoaded(msg)
 	case columnsLoadedMsg:
 		if msg.connGen != m.connGen {
 			return m, nil // stale fetch from previous connection
 		}
 		if msg.err == nil && msg.columns != nil {
 			if m.completion.colCache == nil {
-				m.completion.colCache = make(map[string][]string)
-			}
-			const maxColCacheSize = 64
-			if len(m.completion.colCache) >= maxColCacheSize && len(m.completion.colOrder) > 0 {
-				evict := m.completion.colOrder[0]
-				m.completion.colOrder = m.completion.colOrder[1:]
-				delete(m.completion.colCache, evict)
+				m.completion.colCache = make(map[string][]db.ColumnInfo)
 			}
 			m.completion.colCache[msg.table] = msg.columns
-			m.completion.colOrder = append(m.completion.colOrder, msg.table)
 			// Re-trigger completion only if cursor context still matches
 			if m.mode == insertMode && m.completion.pendingPrefix != "" {
-				prefix, _ := wordAtCursor(m.textarea.Value(), m.textarea.Line(), m.textarea.LineInfo().CharOffset)
+				prefix, _ := wordAtCursor(m.textarea.Value(), m.textarea.Line(), m.textarea.cursorColumn())
 				if prefix == m.completion.pendingPrefix {
-					return m, m.triggerCompletion()
+					return m, m.complete(m.completion.pendingAuto)
 				}
 				m.completion.pendingPrefix = ""
 			}
 		}
 		return m, nil
+	case profileColumnsMsg:
+		return m, m.handleProfileColumns(msg)
+	case profileColumnMsg:
+		return m, m.handleProfileColumn(msg)
 	case statsComputedMsg:
 		if msg.seq != m.statsSt.seq {
 			return m, nil // stale stats result — discard
 		}
 		m.statsSt.stats = msg.stats
+		m.statsSt.diffs = msg.diffs
 		m.statsSt.loading = false
 		if m.mode == statsMode {
-			m.setStatus("Stats mode", false)
+			if m.statsSt.scope == statsCompare {
+				m.setStatus(compareStatsSummary(msg.diffs), false)
+			} else {
+				m.setStatus("Stats mode", false)
+			}
 		}
 		return m, nil
 	case queryExecutedMsg:
@@ -466,9 +574,15 @@ func (m model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 			m.setStatus(errMsg, true)
 			return m, nil
 		}
+		if adapter := m.activeDB(); adapter != nil {
+			msg.result = zoneResult(msg.result, adapter.Type(), m.displayLocation())
+		}
 		m.lastResult = msg.result
+		m.lastQuery = msg.query
 		m.sortDir = sortNone
 		m.sortCol = 0
+		m.filter = gridFilter{}
+		m.pivotBase = nil
 		m.colCursor = 0
 		m.colOffset = 0
 		m.applyResult(msg.result)
@@ -476,7 +590,10 @@ func (m model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		if m.pinned != nil {
 			m.setStatus(m.compareStatusSummary(), false)
 		}
-		return m, loadTablesCmd(m.activeDB())
+		if dbutil.ChangesSchema(msg.query, m.textarea.dialect) {
+			return m, m.refreshSchema(false)
+		}
+		return m, nil
 	}
 
 	var cmd tea.Cmd
@@ -505,6 +622,13 @@ func (m model) View() string {
 		return "Loading..."
 	}
 
+	if m.mode == viewerMode {
+		return lipgloss.NewStyle().
+			MaxHeight(m.height).
+			MaxWidth(m.width).
+			Render(lipgloss.JoinVertical(lipgloss.Left, m.renderViewer(), m.renderStatusBar()))
+	}
+
 	fullWidth := m.fullContentWidth()
 
 	editorView := m.textarea.View()
@@ -569,6 +693,30 @@ func (m model) View() string {
 		view = m.renderWithProfileOverlay(view)
 	}
 
+	if m.mode == editMode {
+		view = m.renderWithEditOverlay(view)
+	}
+
+	if m.mode == pendingMode {
+		view = m.renderWithPendingOverlay(view)
+	}
+
+	if m.mode == pivotMode {
+		view = m.renderWithPivotOverlay(view)
+	}
+
+	if m.mode == chartMode {
+		view = m.renderWithChartOverlay(view)
+	}
+
+	if m.mode == bucketJoinMode {
+		view = m.renderWithBucketJoinOverlay(view)
+	}
+
+	if m.mode == plotMode {
+		view = m.renderWithPlotOverlay(view)
+	}
+
 	return lipgloss.NewStyle().
 		MaxHeight(m.height).
 		MaxWidth(m.width).
@@ -641,6 +789,14 @@ func (m *model) resize() {
 	m.snippetSt.input.Width = max(calcModalWidth(m.width, 50)-12, 1)
 	m.profileSt.input.Width = max(calcModalWidth(m.width, 60)-12, 1)
 	m.histSearch.input.Width = max(calcModalWidth(m.width, 60)-10, 10)
+	m.exportSt.path.Width = max(calcModalWidth(m.width, 60)-16, 1)
+	m.exportSt.table.Width = max(calcModalWidth(m.width, 60)-16, 1)
+	m.exportSt.keys.Width = max(calcModalWidth(m.width, 60)-16, 1)
+	m.viewer.search.Width = max(m.width-8, 1)
+	m.editSt.input.Width = max(calcModalWidth(m.width, 72)-14, 1)
+	if m.mode == viewerMode {
+		m.rebuildViewer()
+	}
 }
 
 func (m *model) editorHeight() int {
@@ -86,7 +86,7 @@ func newTestModel() *model {
 		connMgr:    newConnManager("test", "", nil),
 		table:      tbl,
 		viewport:   vp,
-		textarea:   ta,
+		textarea:   sqlEditor{Model: ta},
 		width:      80,
 		height:     24,
 		historyIdx: -1,
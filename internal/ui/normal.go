@@ -8,6 +8,9 @@ import (
 )
 
 func (m model) updateNormal(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	if m.yankPending {
+		return m.updateYank(msg)
+	}
 	switch msg.Type {
 	case tea.KeyRunes:
 		if msg.Alt {
@@ -41,18 +44,10 @@ func (m model) updateNormal(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 			}
 		case "d":
 			if len(m.lastResult.Columns) > 0 && len(m.lastResult.Rows) > 0 {
-				m.statsSt.seq++
 				m.statsSt.cursor = 0
 				m.statsSt.scroll = 0
-				m.statsSt.stats = nil
-				m.statsSt.loading = true
 				m.mode = statsMode
-				m.setStatus("Computing stats...", false)
-				result := m.lastResult
-				seq := m.statsSt.seq
-				return m, func() tea.Msg {
-					return statsComputedMsg{seq: seq, stats: computeColumnStats(result)}
-				}
+				return m, m.startResultStats()
 			}
 		case "c":
 			if m.pinned != nil {
@@ -135,6 +130,71 @@ func (m model) updateNormal(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 			}
 			m.setStatus("Re-executing query...", false)
 			return m, m.prepareAndExecuteQuery(query)
+		case "E":
+			if m.pinned != nil && m.comparePane == 0 {
+				m.setStatus("The pinned result cannot be edited", true)
+				break
+			}
+			return m.startCellEdit(m.table.Cursor(), m.colCursor)
+		case "W":
+			return m.openPendingEdits()
+		case "y":
+			if m.pinned != nil && m.comparePane == 0 {
+				m.setStatus("Switch to the active pane to yank", true)
+				break
+			}
+			m.yankPending = true
+			m.setStatus("Yank: y:row c:cell C:column i:IN list", false)
+		case "v", "V":
+			if m.pinned != nil && m.comparePane == 0 {
+				m.setStatus("The pinned result cannot be selected", true)
+				break
+			}
+			m.enterVisual(string(msg.Runes) == "v")
+		case "o", "D", "X":
+			if m.pinned != nil && m.comparePane == 0 {
+				m.setStatus("The pinned result cannot be edited", true)
+				break
+			}
+			switch string(msg.Runes) {
+			case "o":
+				return m.startRowChange(changeInsert, true)
+			case "D":
+				return m.startRowChange(changeInsert, false)
+			default:
+				return m.startRowChange(changeDelete, false)
+			}
+		case "g", "u":
+			if m.pinned != nil && m.comparePane == 0 {
+				m.setStatus("The pinned result cannot be grouped", true)
+				break
+			}
+			if string(msg.Runes) == "u" {
+				m.restorePivot()
+				break
+			}
+			return m.startPivot()
+		case "T":
+			if m.pinned != nil && m.comparePane == 0 {
+				m.setStatus("The pinned result cannot be charted", true)
+				break
+			}
+			return m.startChart()
+		case "C":
+			if m.pinned != nil && m.comparePane == 0 {
+				m.setStatus("The pinned result cannot be plotted", true)
+				break
+			}
+			return m.startPlot()
+		case "J":
+			return m.startBucketJoin()
+		case "Z":
+			m.toggleUTC()
+		case "F":
+			if m.pinned != nil && m.comparePane == 0 {
+				break
+			}
+			m.clearFilter()
 		case "S":
 			m.mode = snippetMode
 			m.snippetSt.cursor = 0
@@ -158,6 +218,8 @@ func (m model) updateNormal(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 		}
 	case tea.KeyCtrlS:
 		return m.enterSnippetNamingMode()
+	case tea.KeyCtrlO, tea.KeyCtrlX:
+		return m, m.openExternalEditor(msg.Type == tea.KeyCtrlX)
 	case tea.KeyCtrlK:
 		if m.aiSt.enabled {
 			m.mode = aiMode
@@ -170,7 +232,7 @@ func (m model) updateNormal(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 		}
 		m.setStatus("AI not configured", true)
 	case tea.KeyEnter:
-		if len(m.lastResult.Columns) > 0 && len(m.lastResult.Rows) > 0 {
+		if len(m.lastResult.Columns) > 0 && m.rowCount() > 0 {
 			m.mode = detailMode
 			m.detail.fieldCursor = 0
 			m.detail.scroll = 0
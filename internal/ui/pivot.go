@@ -0,0 +1,255 @@
+package ui
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+
+	"github.com/kwrkb/asql/internal/db"
+)
+
+// startPivot groups the result by the column under the cursor. When the
+// result has other numeric columns, it first asks which one to sum and
+// average.
+func (m model) startPivot() (tea.Model, tea.Cmd) {
+	if len(m.lastResult.Columns) == 0 || m.rowCount() == 0 {
+		m.setStatus("No rows to group", true)
+		return m, nil
+	}
+	col := m.colCursor
+	var choices []int
+	for i := range m.lastResult.Columns {
+		if i == col {
+			continue
+		}
+		if (i < len(m.lastResult.ColumnTypes) && detectNumericColumn(m.lastResult.ColumnTypes[i])) || looksLikeNumeric(m.lastResult.Rows, i) {
+			choices = append(choices, i)
+		}
+	}
+	if len(choices) == 0 {
+		m.applyPivot(col, -1)
+		return m, nil
+	}
+	m.pivotSt = pivotState{col: col, choices: choices}
+	m.mode = pivotMode
+	m.setStatus(fmt.Sprintf("Group by %s", sanitize(m.lastResult.Columns[col])), false)
+	return m, nil
+}
+
+func (m model) updatePivot(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	n := len(m.pivotSt.choices) + 1 // "count only" comes first
+	switch msg.Type {
+	case tea.KeyEsc:
+		m.mode = normalMode
+		m.setStatus("Normal mode", false)
+	case tea.KeyEnter:
+		valCol := -1
+		if m.pivotSt.cursor > 0 {
+			valCol = m.pivotSt.choices[m.pivotSt.cursor-1]
+		}
+		m.mode = normalMode
+		m.applyPivot(m.pivotSt.col, valCol)
+	case tea.KeyDown:
+		moveCursor(&m.pivotSt.cursor, n, 1)
+	case tea.KeyUp:
+		moveCursor(&m.pivotSt.cursor, n, -1)
+	case tea.KeyRunes:
+		if msg.Alt {
+			break
+		}
+		switch string(msg.Runes) {
+		case "j":
+			moveCursor(&m.pivotSt.cursor, n, 1)
+		case "k":
+			moveCursor(&m.pivotSt.cursor, n, -1)
+		case "q":
+			m.mode = normalMode
+			m.setStatus("Normal mode", false)
+		}
+	}
+	return m, nil
+}
+
+// applyPivot replaces the result with the group-by on col of the rows the
+// grid shows, summing and averaging valCol unless it is -1. The result
+// before the first pivot is kept so that u can bring it back.
+func (m *model) applyPivot(col, valCol int) {
+	view := m.lastResult
+	view.Rows = m.viewRows()
+	if view.Nulls != nil {
+		view.Nulls = m.viewNulls(0, m.rowCount())
+	}
+	grouped := groupBy(view, col, valCol)
+	if m.pivotBase == nil {
+		m.pivotBase = &pivotBase{result: m.lastResult, query: m.lastQuery}
+	}
+	m.showDerivedResult(grouped, "")
+	m.setStatus(fmt.Sprintf("Grouped by %s: %s (u:restore)", sanitize(m.lastResult.Columns[0]), grouped.Message), false)
+}
+
+// restorePivot brings back the result from before the pivot.
+func (m *model) restorePivot() {
+	if m.pivotBase == nil {
+		m.setStatus("No grouped result to restore", true)
+		return
+	}
+	base := m.pivotBase
+	m.pivotBase = nil
+	m.showDerivedResult(base.result, base.query)
+	m.setStatus("Restored "+base.result.Message, false)
+}
+
+// showDerivedResult shows a result computed on the client. query is the
+// statement behind it, or "" when it has none, which keeps derived rows
+// from being edited.
+func (m *model) showDerivedResult(result db.QueryResult, query string) {
+	m.lastResult = result
+	m.lastQuery = query
+	m.sortDir = sortNone
+	m.sortCol = 0
+	m.filter = gridFilter{}
+	m.colCursor = 0
+	m.colOffset = 0
+	m.applyResult(result)
+	m.table.GotoTop()
+	m.syncCompareTables()
+}
+
+// groupBy counts the rows of each value of column col, most frequent first,
+// with the sum and average of the numbers in column valCol unless it is -1.
+// NULL is a group of its own, apart from the text "NULL" when the result
+// flags its NULL cells.
+func groupBy(result db.QueryResult, col, valCol int) db.QueryResult {
+	type key struct {
+		value string
+		null  bool
+	}
+	type group struct {
+		key
+		count int
+		sum   float64
+		nums  int
+	}
+	groups := make(map[key]*group)
+	var order []*group
+	for r, row := range result.Rows {
+		k := key{value: "NULL", null: true}
+		if col < len(row) {
+			k.value = row[col]
+			if result.Nulls == nil {
+				k.null = k.value == "NULL"
+			} else {
+				k.null = r < len(result.Nulls) && col < len(result.Nulls[r]) && result.Nulls[r][col]
+			}
+		}
+		g, ok := groups[k]
+		if !ok {
+			g = &group{key: k}
+			groups[k] = g
+			order = append(order, g)
+		}
+		g.count++
+		if valCol >= 0 && valCol < len(row) {
+			if f, err := strconv.ParseFloat(row[valCol], 64); err == nil && !math.IsNaN(f) && !math.IsInf(f, 0) {
+				g.sum += f
+				g.nums++
+			}
+		}
+	}
+	sort.SliceStable(order, func(i, j int) bool {
+		if order[i].count != order[j].count {
+			return order[i].count > order[j].count
+		}
+		return smartCompare(order[i].value, order[j].value) < 0
+	})
+
+	name := result.Columns[col]
+	typ := ""
+	if col < len(result.ColumnTypes) {
+		typ = result.ColumnTypes[col]
+	}
+	out := db.QueryResult{
+		Columns:     []string{name, "count"},
+		ColumnTypes: []string{typ, "INTEGER"},
+	}
+	if valCol >= 0 {
+		val := result.Columns[valCol]
+		out.Columns = append(out.Columns, "sum("+val+")", "avg("+val+")")
+		out.ColumnTypes = append(out.ColumnTypes, "REAL", "REAL")
+	}
+	for _, g := range order {
+		row := []string{g.value, strconv.Itoa(g.count)}
+		nulls := []bool{g.null, false}
+		if valCol >= 0 {
+			if g.nums == 0 {
+				row = append(row, "NULL", "NULL")
+				nulls = append(nulls, true, true)
+			} else {
+				row = append(row, formatAggregate(g.sum), formatAggregate(g.sum/float64(g.nums)))
+				nulls = append(nulls, false, false)
+			}
+		}
+		out.Rows = append(out.Rows, row)
+		out.Nulls = append(out.Nulls, nulls)
+	}
+	out.Message = fmt.Sprintf("%d group(s) from %d row(s)", len(out.Rows), len(result.Rows))
+	return out
+}
+
+func (m model) renderWithPivotOverlay(background string) string {
+	modalWidth := calcModalWidth(m.width, 50)
+	innerWidth := max(modalWidth-6, 1)
+
+	titleStyle := lipgloss.NewStyle().
+		Bold(true).
+		Foreground(accentColor).
+		MarginBottom(1)
+
+	boxStyle := lipgloss.NewStyle().
+		Border(lipgloss.RoundedBorder()).
+		BorderForeground(accentColor).
+		Padding(1, 2).
+		Width(modalWidth).
+		Background(panelBackground)
+
+	itemStyle := lipgloss.NewStyle().
+		Foreground(textColor).
+		Background(panelBackground).
+		Width(innerWidth).
+		Padding(0, 1)
+
+	selectedStyle := lipgloss.NewStyle().
+		Foreground(panelBackground).
+		Background(accentColor).
+		Bold(true).
+		Width(innerWidth).
+		Padding(0, 1)
+
+	labels := []string{"count only"}
+	for _, c := range m.pivotSt.choices {
+		labels = append(labels, "count, sum and avg of "+sanitize(m.lastResult.Columns[c]))
+	}
+
+	var items strings.Builder
+	for i, label := range labels {
+		if i == m.pivotSt.cursor {
+			items.WriteString(selectedStyle.Render(label))
+		} else {
+			items.WriteString(itemStyle.Render(label))
+		}
+		if i < len(labels)-1 {
+			items.WriteByte('\n')
+		}
+	}
+
+	title := "Group by " + sanitize(m.lastResult.Columns[m.pivotSt.col])
+	footer := "\n" + lipgloss.NewStyle().Foreground(mutedTextColor).Background(panelBackground).Render("Enter:group Esc:cancel")
+	modal := boxStyle.Render(titleStyle.Render(title) + "\n" + items.String() + footer)
+
+	return overlayModal(m.width, background, modal)
+}
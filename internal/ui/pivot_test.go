@@ -0,0 +1,107 @@
+package ui
+
+import (
+	"slices"
+	"testing"
+
+	tea "github.com/charmbracelet/bubbletea"
+
+	"github.com/kwrkb/asql/internal/db"
+)
+
+func TestGroupBy(t *testing.T) {
+	result := db.QueryResult{
+		Columns:     []string{"status", "amount"},
+		ColumnTypes: []string{"TEXT", "INTEGER"},
+		Rows: [][]string{
+			{"open", "10"}, {"done", "5"}, {"open", "20"}, {"NULL", "1"}, {"done", "NULL"}, {"open", "x"},
+		},
+	}
+
+	got := groupBy(result, 0, 1)
+	if want := []string{"status", "count", "sum(amount)", "avg(amount)"}; !slices.Equal(got.Columns, want) {
+		t.Errorf("columns = %v", got.Columns)
+	}
+	want := [][]string{
+		{"open", "3", "30", "15"},
+		{"done", "2", "5", "5"},
+		{"NULL", "1", "1", "1"},
+	}
+	if !slices.EqualFunc(got.Rows, want, slices.Equal) {
+		t.Errorf("rows = %v, want %v", got.Rows, want)
+	}
+	if got.Message != "3 group(s) from 6 row(s)" {
+		t.Errorf("message = %q", got.Message)
+	}
+
+	counts := groupBy(result, 1, -1)
+	if len(counts.Columns) != 2 || counts.Rows[0][1] != "1" {
+		t.Errorf("count-only group = %v %v", counts.Columns, counts.Rows)
+	}
+}
+
+func TestGroupBy_NullFlags(t *testing.T) {
+	result := db.QueryResult{
+		Columns: []string{"v"},
+		Rows:    [][]string{{"NULL"}, {"NULL"}, {"NULL"}},
+		Nulls:   [][]bool{{true}, {false}, {true}},
+	}
+	got := groupBy(result, 0, -1)
+	want := [][]string{{"NULL", "2"}, {"NULL", "1"}}
+	if !slices.EqualFunc(got.Rows, want, slices.Equal) {
+		t.Fatalf("rows = %v, want %v", got.Rows, want)
+	}
+	if !got.Nulls[0][0] || got.Nulls[1][0] {
+		t.Errorf("nulls = %v, want the NULL group flagged and the text kept", got.Nulls)
+	}
+}
+
+func TestPivot_GroupsFilteredRows(t *testing.T) {
+	m, adapter := newEditModel(t)
+	if _, err := adapter.Query(t.Context(), "INSERT INTO items VALUES (3, 'ok', 'NULL')"); err != nil {
+		t.Fatal(err)
+	}
+	res, _ := adapter.Query(t.Context(), m.lastQuery)
+	m.applyResult(res)
+	m.setFilter(1, "ok")
+
+	m.applyPivot(2, -1)
+	want := [][]string{{"NULL", "1"}, {"NULL", "1"}}
+	if !slices.EqualFunc(m.lastResult.Rows, want, slices.Equal) {
+		t.Fatalf("rows = %v, want one group per NULL kind of the filtered rows", m.lastResult.Rows)
+	}
+	if !m.lastResult.Nulls[0][0] || m.lastResult.Nulls[1][0] {
+		t.Errorf("nulls = %v", m.lastResult.Nulls)
+	}
+}
+
+func TestPivotAndRestore(t *testing.T) {
+	m, _ := newEditModel(t)
+	m.colCursor = 1 // flag
+
+	next, _ := m.updateNormal(runeMsg("g"))
+	rm := next.(model)
+	if rm.mode != pivotMode || !slices.Equal(rm.pivotSt.choices, []int{0}) {
+		t.Fatalf("expected the aggregate picker with id, got %q %v", rm.mode, rm.pivotSt.choices)
+	}
+	next, _ = rm.updatePivot(runeMsg("j"))
+	rm = next.(model)
+	next, _ = rm.updatePivot(tea.KeyMsg{Type: tea.KeyEnter})
+	rm = next.(model)
+	if rm.mode != normalMode || len(rm.lastResult.Columns) != 4 || len(rm.lastResult.Rows) != 2 {
+		t.Fatalf("expected a grouped result, got %v", rm.lastResult)
+	}
+	if rm.lastQuery != "" {
+		t.Error("a grouped result must not keep the source query")
+	}
+	// The grouped rows cannot be edited.
+	if tbl := rm.editableTable(true); tbl != "" {
+		t.Errorf("expected grouped rows to be read-only, got table %q", tbl)
+	}
+
+	next, _ = rm.updateNormal(runeMsg("u"))
+	rm = next.(model)
+	if rm.pivotBase != nil || len(rm.lastResult.Rows) != 2 || len(rm.lastResult.Columns) != 3 || rm.lastQuery == "" {
+		t.Errorf("expected the original result back, got %v (%q)", rm.lastResult.Columns, rm.lastQuery)
+	}
+}
@@ -0,0 +1,260 @@
+package ui
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+)
+
+// plotKind is how PLOT mode draws a result.
+type plotKind int
+
+const (
+	plotBar     plotKind = iota // categories: one horizontal bar per row
+	plotLine                    // dates: a line over time buckets
+	plotScatter                 // numbers × numbers: braille dots
+)
+
+func (k plotKind) String() string {
+	switch k {
+	case plotLine:
+		return "line"
+	case plotScatter:
+		return "scatter"
+	default:
+		return "bar"
+	}
+}
+
+// numericColumns returns the columns of the shown result that hold numbers.
+func (m model) numericColumns() []int {
+	var cols []int
+	rows := m.viewRows()
+	for i := range m.lastResult.Columns {
+		if (i < len(m.lastResult.ColumnTypes) && detectNumericColumn(m.lastResult.ColumnTypes[i])) || looksLikeNumeric(rows, i) {
+			cols = append(cols, i)
+		}
+	}
+	return cols
+}
+
+// startPlot charts the column under the cursor against a numeric column:
+// bars for categories, a line for dates and a scatter for numbers. With the
+// cursor on the only numeric column, the first other column is the X axis.
+func (m model) startPlot() (tea.Model, tea.Cmd) {
+	if len(m.lastResult.Columns) < 2 || m.rowCount() == 0 {
+		m.setStatus("Plotting needs a result with two columns and some rows", true)
+		return m, nil
+	}
+	x := m.colCursor
+	var ys []int
+	for _, c := range m.numericColumns() {
+		if c != x {
+			ys = append(ys, c)
+		}
+	}
+	if len(ys) == 0 {
+		if !m.isNumericColumn(x) {
+			m.setStatus("No numeric column to plot", true)
+			return m, nil
+		}
+		ys = []int{x}
+		x = 0
+		if x == ys[0] {
+			x = 1
+		}
+	}
+
+	kind := plotBar
+	switch {
+	case dateColumn(m.lastResult, x):
+		kind = plotLine
+	case m.isNumericColumn(x):
+		kind = plotScatter
+	}
+	m.plotSt = plotState{x: x, y: ys[0], ys: ys, kind: kind}
+	m.mode = plotMode
+	m.refreshPlot()
+	return m, nil
+}
+
+func (m model) isNumericColumn(col int) bool {
+	for _, c := range m.numericColumns() {
+		if c == col {
+			return true
+		}
+	}
+	return false
+}
+
+// refreshPlot recomputes the plotted data after a setting changed.
+func (m *model) refreshPlot() {
+	p := &m.plotSt
+	p.points, p.xs, p.yvals, p.err, p.scroll = nil, nil, nil, "", 0
+	rows := m.viewRows()
+	switch p.kind {
+	case plotLine:
+		// Bucket like the sparkline: by day, month or year depending on
+		// the span, summing the values of each bucket.
+		var times []time.Time
+		for _, row := range rows {
+			if p.x < len(row) {
+				if t, ok := parseDate(row[p.x]); ok {
+					times = append(times, t)
+				}
+			}
+		}
+		gran := granDay
+		if len(times) > 0 {
+			lo, hi := times[0], times[0]
+			for _, t := range times {
+				if t.Before(lo) {
+					lo = t
+				}
+				if t.After(hi) {
+					hi = t
+				}
+			}
+			gran, _ = chooseGranularity(lo, hi)
+		}
+		points, _, err := timeSeries(rows, p.x, p.y, gran, false)
+		if err != nil {
+			p.err = err.Error()
+			break
+		}
+		p.points = points
+		p.gran = gran
+	case plotScatter:
+		for _, row := range rows {
+			if p.x >= len(row) || p.y >= len(row) {
+				continue
+			}
+			xv, xerr := strconv.ParseFloat(row[p.x], 64)
+			yv, yerr := strconv.ParseFloat(row[p.y], 64)
+			if xerr != nil || yerr != nil || math.IsNaN(xv) || math.IsNaN(yv) || math.IsInf(xv, 0) || math.IsInf(yv, 0) {
+				continue
+			}
+			p.xs = append(p.xs, xv)
+			p.yvals = append(p.yvals, yv)
+		}
+		if len(p.xs) == 0 {
+			p.err = "no rows with numbers in both columns"
+		}
+	default:
+		for _, row := range rows {
+			pt := chartPoint{Label: "NULL", Missing: true}
+			if p.x < len(row) {
+				pt.Label = row[p.x]
+			}
+			if p.y < len(row) {
+				if v, err := strconv.ParseFloat(row[p.y], 64); err == nil && !math.IsNaN(v) && !math.IsInf(v, 0) {
+					pt.Value, pt.Missing = v, false
+				}
+			}
+			p.points = append(p.points, pt)
+		}
+	}
+	if p.err != "" {
+		m.setStatus(p.err, true)
+		return
+	}
+	m.setStatus(m.plotTitle(), false)
+}
+
+// plotTitle describes the plot, e.g. "amount by region (bar)".
+func (m model) plotTitle() string {
+	p := m.plotSt
+	x := sanitize(m.lastResult.Columns[p.x])
+	y := sanitize(m.lastResult.Columns[p.y])
+	switch p.kind {
+	case plotLine:
+		return fmt.Sprintf("sum(%s) by %s of %s (line)", y, granularityName(p.gran), x)
+	case plotScatter:
+		return fmt.Sprintf("%s × %s, %d point(s) (scatter)", x, y, len(p.xs))
+	default:
+		return fmt.Sprintf("%s by %s, %d row(s) (bar)", y, x, len(p.points))
+	}
+}
+
+// plotHeight is the number of plot rows that fit in the overlay.
+func (m model) plotHeight() int {
+	// border(2), padding(2), title(1), x axis(2), status bar(1), margin(1).
+	return max(m.height-9, 3)
+}
+
+func (m model) updatePlot(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	p := &m.plotSt
+	maxScroll := 0
+	if p.kind == plotBar {
+		maxScroll = max(len(p.points)-m.plotHeight()-2, 0) // bars also use the axis rows
+	}
+	switch msg.Type {
+	case tea.KeyEsc:
+		m.mode = normalMode
+		m.setStatus("Normal mode", false)
+	case tea.KeyDown:
+		p.scroll = min(p.scroll+1, maxScroll)
+	case tea.KeyUp:
+		p.scroll = max(p.scroll-1, 0)
+	case tea.KeyRunes:
+		if msg.Alt {
+			break
+		}
+		switch string(msg.Runes) {
+		case "j":
+			p.scroll = min(p.scroll+1, maxScroll)
+		case "k":
+			p.scroll = max(p.scroll-1, 0)
+		case "m":
+			// Next numeric column as Y.
+			for i, c := range p.ys {
+				if c == p.y {
+					p.y = p.ys[(i+1)%len(p.ys)]
+					break
+				}
+			}
+			m.refreshPlot()
+		case "q":
+			m.mode = normalMode
+			m.setStatus("Normal mode", false)
+		}
+	}
+	return m, nil
+}
+
+func (m model) renderWithPlotOverlay(background string) string {
+	modalWidth := calcModalWidth(m.width, m.width)
+	contentWidth := max(modalWidth-6, 10)
+	p := m.plotSt
+
+	var b strings.Builder
+	b.WriteString(lipgloss.NewStyle().Bold(true).Foreground(accentColor).Render(truncateRunes(m.plotTitle(), contentWidth)))
+	b.WriteByte('\n')
+
+	height := m.plotHeight()
+	switch {
+	case p.err != "":
+		b.WriteString(lipgloss.NewStyle().Foreground(errorColor).Render(truncateRunes(p.err, contentWidth)))
+	case p.kind == plotLine:
+		b.WriteString(renderChart(p.points, chartLine, false, contentWidth, height))
+	case p.kind == plotScatter:
+		b.WriteString(renderScatter(p.xs, p.yvals, contentWidth, height))
+	default:
+		b.WriteString(renderHBars(p.points, contentWidth, height+2, p.scroll))
+	}
+
+	modal := lipgloss.NewStyle().
+		Border(lipgloss.RoundedBorder()).
+		BorderForeground(accentColor).
+		Padding(1, 2).
+		Width(modalWidth).
+		Background(panelBackground).
+		Render(b.String())
+
+	return overlayModal(m.width, background, modal)
+}
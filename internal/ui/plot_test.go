@@ -0,0 +1,101 @@
+package ui
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/kwrkb/asql/internal/db"
+)
+
+func plotModel(result db.QueryResult, col int) model {
+	m := newTestModel()
+	m.showDerivedResult(result, "")
+	m.colCursor = col
+	next, _ := m.updateNormal(runeMsg("C"))
+	return next.(model)
+}
+
+func TestStartPlotKinds(t *testing.T) {
+	regions := db.QueryResult{
+		Columns: []string{"region", "amount"},
+		Rows:    [][]string{{"east", "10"}, {"west", "NULL"}, {"north", "-5"}},
+	}
+	rm := plotModel(regions, 1) // cursor on the only numeric column
+	if rm.mode != plotMode || rm.plotSt.kind != plotBar || rm.plotSt.x != 0 || rm.plotSt.y != 1 {
+		t.Fatalf("expected bars of amount by region, got mode %q %+v", rm.mode, rm.plotSt)
+	}
+	if len(rm.plotSt.points) != 3 || !rm.plotSt.points[1].Missing || rm.plotSt.points[2].Value != -5 {
+		t.Errorf("bar points = %+v", rm.plotSt.points)
+	}
+
+	daily := db.QueryResult{
+		Columns: []string{"day", "n"},
+		Rows:    [][]string{{"2024-01-01", "3"}, {"2024-01-03", "4"}},
+	}
+	rm = plotModel(daily, 0)
+	if rm.plotSt.kind != plotLine || len(rm.plotSt.points) != 3 || !rm.plotSt.points[1].Missing {
+		t.Errorf("expected a daily line with a gap, got %+v", rm.plotSt)
+	}
+	if got := rm.plotTitle(); got != "sum(n) by day of day (line)" {
+		t.Errorf("title = %q", got)
+	}
+
+	pairs := db.QueryResult{
+		Columns: []string{"x", "y", "z"},
+		Rows:    [][]string{{"1", "2", "5"}, {"2", "4", "6"}, {"3", "x", "7"}},
+	}
+	rm = plotModel(pairs, 0)
+	if rm.plotSt.kind != plotScatter || len(rm.plotSt.xs) != 2 || rm.plotSt.y != 1 {
+		t.Fatalf("expected a scatter of x × y, got %+v", rm.plotSt)
+	}
+	next, _ := rm.updatePlot(runeMsg("m"))
+	rm = next.(model)
+	if rm.plotSt.y != 2 || len(rm.plotSt.xs) != 3 {
+		t.Errorf("expected m to plot z, got %+v", rm.plotSt)
+	}
+
+	none := db.QueryResult{Columns: []string{"a", "b"}, Rows: [][]string{{"x", "y"}}}
+	if rm = plotModel(none, 0); rm.mode == plotMode {
+		t.Error("expected a result without numbers to be refused")
+	}
+}
+
+func TestRenderScatter(t *testing.T) {
+	out := renderScatter([]float64{0, 10}, []float64{0, 100}, 20, 4)
+	lines := strings.Split(out, "\n")
+	if len(lines) != 6 {
+		t.Fatalf("expected 4 plot rows, an axis and labels, got %d lines:\n%s", len(lines), out)
+	}
+	// (0, 0) is the bottom-left dot, (10, 100) the top-right one.
+	if !strings.HasPrefix(lines[0], "100┤") || !strings.HasSuffix(lines[0], "⠈") {
+		t.Errorf("top row = %q", lines[0])
+	}
+	if !strings.HasPrefix(lines[3], "  0┤⡀") {
+		t.Errorf("bottom row = %q", lines[3])
+	}
+	if !strings.Contains(lines[5], "0") || !strings.HasSuffix(lines[5], "10") {
+		t.Errorf("x labels = %q", lines[5])
+	}
+}
+
+func TestRenderHBars(t *testing.T) {
+	points := []chartPoint{{Label: "east", Value: 10}, {Label: "west", Missing: true}, {Label: "north", Value: -5}}
+	out := renderHBars(points, 30, 10, 0)
+	lines := strings.Split(out, "\n")
+	if len(lines) != 3 {
+		t.Fatalf("expected 3 bars, got:\n%s", out)
+	}
+	full := strings.Count(lines[0], "█")
+	half := strings.Count(lines[2], "█")
+	if full == 0 || half != full/2 {
+		t.Errorf("bars not proportional: %d vs %d\n%s", full, half, out)
+	}
+	if !strings.Contains(lines[1], "·") || !strings.HasSuffix(lines[1], "NULL") || !strings.HasSuffix(lines[2], "-5") {
+		t.Errorf("unexpected bars:\n%s", out)
+	}
+
+	scrolled := renderHBars(points, 30, 1, 2)
+	if !strings.HasPrefix(scrolled, "north") || strings.Contains(scrolled, "\n") {
+		t.Errorf("scrolled = %q", scrolled)
+	}
+}
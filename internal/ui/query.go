@@ -8,15 +8,6 @@ import (
 	"github.com/kwrkb/asql/internal/db"
 )
 
-func loadTablesCmd(adapter db.DBAdapter) tea.Cmd {
-	return func() tea.Msg {
-		ctx, cancel := context.WithTimeout(context.Background(), queryTimeout)
-		defer cancel()
-		tables, err := adapter.Tables(ctx)
-		return tablesLoadedMsg{tables: tables, err: err}
-	}
-}
-
 // prepareAndExecuteQuery cancels any in-flight query, records the query in
 // history, and returns a Cmd that executes it. Callers should use this instead
 // of duplicating cancel/history/execute logic.
@@ -44,6 +35,6 @@ func executeQueryCmd(parent context.Context, adapter db.DBAdapter, query string,
 		defer cancel()
 
 		result, err := adapter.Query(ctx, query)
-		return queryExecutedMsg{seq: seq, result: result, err: err}
+		return queryExecutedMsg{seq: seq, query: query, result: result, err: err}
 	}
 }
@@ -5,7 +5,6 @@ import (
 	"github.com/charmbracelet/lipgloss"
 
 	"github.com/kwrkb/asql/internal/db"
-	"github.com/kwrkb/asql/internal/db/dbutil"
 )
 
 // adjustColOffset ensures colCursor is within the visible column window.
@@ -68,21 +67,21 @@ func (m *model) syncViewport() {
 
 	// Rebuild columns/rows only when the visible window or column cursor changes.
 	// For row-only navigation (j/k) we skip the expensive rebuild.
-	rebuildNeeded := visStart != m.lastVisStart || visEnd != m.lastVisEnd || m.viewportDirty
+	// An active selection follows the cursor, so it always rebuilds.
+	rebuildNeeded := visStart != m.lastVisStart || visEnd != m.lastVisEnd || m.viewportDirty || m.visual.active
 	if rebuildNeeded {
 		// Build windowed columns
 		selectedStyle := lipgloss.NewStyle().Reverse(true)
 		columns := make([]table.Column, 0, visEnd-visStart)
 		for i := visStart; i < visEnd; i++ {
 			header := sanitize(m.lastResult.Columns[i])
-			if i < len(m.lastResult.ColumnTypes) && m.lastResult.ColumnTypes[i] != "" {
-				shortType := dbutil.ShortenTypeName(sanitize(m.lastResult.ColumnTypes[i]))
+			if shortType := m.columnTypeLabel(m.lastResult, i); shortType != "" {
 				header = header + " " + typeStyle.Render(shortType)
 			}
 			if i == m.sortCol && m.sortDir != sortNone {
 				header += sortIndicator(m.sortDir)
 			}
-			if m.mode == normalMode && i == m.colCursor && (m.pinned == nil || m.comparePane == 1) {
+			if (m.mode == normalMode || m.mode == visualMode) && i == m.colCursor && (m.pinned == nil || m.comparePane == 1) {
 				header = selectedStyle.Render(header)
 			}
 			columns = append(columns, table.Column{Title: header, Width: min(m.cachedColWidths[i], max(m.contentWidth()-8, 1))})
@@ -97,6 +96,8 @@ func (m *model) syncViewport() {
 					cell := sanitize(row[i])
 					if m.activeCellDiff(rowIdx, i) {
 						cell = diffCellStyle.Render(cell)
+					} else if m.cellSelected(rowIdx, i) {
+						cell = selectedCellStyle.Render(cell)
 					}
 					windowed = append(windowed, cell)
 				} else {
@@ -130,6 +131,7 @@ func (m *model) syncViewport() {
 
 func (m *model) applyResult(result db.QueryResult) {
 	m.lastResult = result
+	m.displaySrc = nil
 	m.applyResultWithSort(result)
 }
 
@@ -167,13 +169,28 @@ func (m *model) toggleSort() {
 
 func (m *model) applySortedResult() {
 	result := m.lastResult
-	result.Rows = sortedRows(m.lastResult.Rows, m.sortCol, m.sortDir)
+	src := filteredIndices(m.lastResult.Rows, m.filter)
+	rows := make([][]string, len(src))
+	for i, r := range src {
+		rows[i] = m.lastResult.Rows[r]
+	}
+	if m.filter.active {
+		m.filter.matched = len(rows)
+	}
+	order := sortedIndices(rows, m.sortCol, m.sortDir)
+	result.Rows = make([][]string, len(order))
+	m.displaySrc = make([]int, len(order))
+	for i, o := range order {
+		result.Rows[i] = rows[o]
+		m.displaySrc[i] = src[o]
+	}
 	m.applyResultWithSort(result)
 	m.table.GotoTop()
 }
 
 // applyResultWithSort computes column widths, saves displayRows, and delegates rendering to syncViewport.
 func (m *model) applyResultWithSort(result db.QueryResult) {
+	m.clearVisual()
 	if len(result.Columns) == 0 {
 		// Message-only result: set directly without windowing
 		m.cachedColWidths = nil
@@ -192,8 +209,7 @@ func (m *model) applyResultWithSort(result db.QueryResult) {
 	m.cachedColWidths = make([]int, len(result.Columns))
 	for i, title := range result.Columns {
 		header := sanitize(title)
-		if i < len(result.ColumnTypes) && result.ColumnTypes[i] != "" {
-			shortType := dbutil.ShortenTypeName(sanitize(result.ColumnTypes[i]))
+		if shortType := m.columnTypeLabel(result, i); shortType != "" {
 			header = header + " " + typeStyle.Render(shortType)
 		}
 		if i == m.sortCol && m.sortDir != sortNone {
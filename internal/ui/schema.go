@@ -0,0 +1,188 @@
+package ui
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+
+	"github.com/kwrkb/asql/internal/db"
+	"github.com/kwrkb/asql/internal/schemacache"
+)
+
+const (
+	// schemaMaxAge is how old a snapshot read from disk may be before it
+	// is refreshed in the background.
+	schemaMaxAge = 15 * time.Minute
+	// schemaFetchTimeout bounds reading the columns of every table; the
+	// table list is bounded by queryTimeout.
+	schemaFetchTimeout = 2 * time.Minute
+)
+
+// schemaState tracks the schema snapshot of the active connection. Its
+// tables live in sidebar.tables and its columns in completion.colCache.
+type schemaState struct {
+	fetched    time.Time // when the snapshot was read from the database
+	refreshing bool
+	again      bool // the schema changed while refreshing: refresh once more
+	announce   bool // report the result of the refresh in the status bar
+}
+
+// schemaLoadedMsg carries the schema of the connection of connGen. The
+// database is read in two steps: the tables, so that the sidebar fills at
+// once, then the columns of all of them.
+type schemaLoadedMsg struct {
+	snap       schemacache.Snapshot
+	cached     bool  // read from the disk snapshot rather than the database
+	tablesOnly bool  // the columns are read next
+	saveErr    error // the snapshot could not be written to disk
+	err        error // with snap.Partial, the columns were only partly read
+	connGen    uint64
+}
+
+// loadSchemaCmd reads the disk snapshot of dsn, or the database when there
+// is none.
+func loadSchemaCmd(adapter db.DBAdapter, dsn string, gen uint64) tea.Cmd {
+	return func() tea.Msg {
+		if snap, ok, err := schemacache.Load(dsn); err == nil && ok {
+			return schemaLoadedMsg{snap: snap, cached: true, connGen: gen}
+		}
+		return fetchTables(adapter, gen)
+	}
+}
+
+// fetchTables reads the table list from the database.
+func fetchTables(adapter db.DBAdapter, gen uint64) schemaLoadedMsg {
+	ctx, cancel := context.WithTimeout(context.Background(), queryTimeout)
+	defer cancel()
+	snap, err := schemacache.FetchTables(ctx, adapter)
+	return schemaLoadedMsg{snap: snap, tablesOnly: true, err: err, connGen: gen}
+}
+
+// fetchColumnsCmd adds the columns of the tables in snap and saves the
+// snapshot for dsn, even when it could only be read in part.
+func fetchColumnsCmd(adapter db.DBAdapter, dsn string, snap schemacache.Snapshot, gen uint64) tea.Cmd {
+	return func() tea.Msg {
+		ctx, cancel := context.WithTimeout(context.Background(), schemaFetchTimeout)
+		defer cancel()
+		snap, err := schemacache.FetchColumns(ctx, adapter, snap)
+		msg := schemaLoadedMsg{snap: snap, err: err, connGen: gen}
+		if dsn != "" {
+			msg.saveErr = schemacache.Save(dsn, snap)
+		}
+		return msg
+	}
+}
+
+// loadSchema starts loading the schema of a connection just opened.
+func (m *model) loadSchema() tea.Cmd {
+	adapter := m.activeDB()
+	if adapter == nil {
+		return nil
+	}
+	m.schema = schemaState{refreshing: true}
+	return loadSchemaCmd(adapter, m.rawDSN, m.connGen)
+}
+
+// refreshSchema reads the schema again in the background. A refresh asked
+// for while one runs starts when that one ends, as it may have read the
+// schema from before the change.
+func (m *model) refreshSchema(announce bool) tea.Cmd {
+	adapter := m.activeDB()
+	if adapter == nil {
+		return nil
+	}
+	m.schema.announce = m.schema.announce || announce
+	if m.schema.refreshing {
+		m.schema.again = true
+		return nil
+	}
+	m.schema.refreshing = true
+	gen := m.connGen
+	return func() tea.Msg { return fetchTables(adapter, gen) }
+}
+
+// handleSchemaLoaded applies a schema snapshot and decides whether it has
+// to be read again.
+func (m *model) handleSchemaLoaded(msg schemaLoadedMsg) tea.Cmd {
+	if msg.connGen != m.connGen {
+		return nil // stale fetch from a previous connection
+	}
+	if msg.tablesOnly && msg.err == nil {
+		// Show the tables now; until the columns arrive, completion keeps
+		// the columns it has and reads missing ones on demand.
+		m.setSchemaTables(msg.snap.Tables)
+		return fetchColumnsCmd(m.activeDB(), m.rawDSN, msg.snap, m.connGen)
+	}
+	m.schema.refreshing = false
+	read := len(msg.snap.Columns)
+	if msg.err == nil || msg.snap.Partial {
+		m.setSchemaTables(msg.snap.Tables)
+		if msg.snap.Partial {
+			// Keep what completion already read for the tables not reached.
+			for t, cols := range m.completion.colCache {
+				if _, ok := msg.snap.Columns[t]; !ok {
+					msg.snap.Columns[t] = cols
+				}
+			}
+		}
+		m.completion.colCache = msg.snap.Columns
+		m.schema.fetched = msg.snap.Fetched
+	}
+	if m.schema.again {
+		m.schema.again = false
+		return m.refreshSchema(false)
+	}
+
+	announce := m.schema.announce
+	m.schema.announce = false
+	switch {
+	case msg.err != nil && msg.snap.Partial:
+		m.setStatus(fmt.Sprintf("Schema partly loaded (%d of %d tables): %v", read, len(msg.snap.Tables), msg.err), true)
+		return nil
+	case msg.err != nil:
+		m.setStatus("Failed to load schema: "+msg.err.Error(), true)
+		return nil
+	case msg.saveErr != nil:
+		m.setStatus("Schema cache not saved: "+msg.saveErr.Error(), true)
+	case announce:
+		m.setStatus(fmt.Sprintf("Schema refreshed: %d table(s)", len(msg.snap.Tables)), false)
+	}
+	if msg.cached && (msg.snap.Partial || time.Since(msg.snap.Fetched) > schemaMaxAge) {
+		return m.refreshSchema(false)
+	}
+	return nil
+}
+
+// setSchemaTables shows tables in the sidebar.
+func (m *model) setSchemaTables(tables []string) {
+	m.sidebar.tables = tables
+	if m.sidebar.cursor >= len(m.sidebar.tables) {
+		m.sidebar.cursor = max(len(m.sidebar.tables)-1, 0)
+	}
+}
+
+// schemaStatus describes the schema snapshot for the status bar.
+func (m model) schemaStatus() string {
+	switch {
+	case m.schema.refreshing:
+		return "schema: refreshing…"
+	case m.schema.fetched.IsZero():
+		return ""
+	}
+	return "schema: " + formatAge(time.Since(m.schema.fetched))
+}
+
+// formatAge renders d in its largest whole unit, as in "5m ago".
+func formatAge(d time.Duration) string {
+	switch {
+	case d < time.Minute:
+		return "just now"
+	case d < time.Hour:
+		return fmt.Sprintf("%dm ago", int(d/time.Minute))
+	case d < 24*time.Hour:
+		return fmt.Sprintf("%dh ago", int(d/time.Hour))
+	}
+	return fmt.Sprintf("%dd ago", int(d/(24*time.Hour)))
+}
@@ -0,0 +1,191 @@
+package ui
+
+import (
+	"context"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+
+	"github.com/kwrkb/asql/internal/db"
+	"github.com/kwrkb/asql/internal/db/sqlite"
+	"github.com/kwrkb/asql/internal/schemacache"
+)
+
+// newSchemaModel returns a model connected to an in-memory SQLite database
+// with one table, and its schema cache in a temporary directory.
+func newSchemaModel(t *testing.T) (*model, *sqlite.Adapter) {
+	t.Helper()
+	t.Setenv("XDG_CACHE_HOME", t.TempDir())
+	adapter, err := sqlite.Open(":memory:")
+	if err != nil {
+		t.Fatalf("sqlite.Open: %v", err)
+	}
+	t.Cleanup(func() { adapter.Close() })
+	if _, err := adapter.Query(context.Background(), "CREATE TABLE users (id INTEGER PRIMARY KEY)"); err != nil {
+		t.Fatal(err)
+	}
+	m := newTestModel()
+	m.connMgr = newConnManager("test", ":memory:", adapter)
+	m.rawDSN = ":memory:"
+	return m, adapter
+}
+
+// runSchemaCmd hands the schema read by cmd to the model, reading the
+// columns after the tables, and returns the command that follows.
+func runSchemaCmd(m *model, cmd tea.Cmd) tea.Cmd {
+	for {
+		msg := cmd().(schemaLoadedMsg)
+		next := m.handleSchemaLoaded(msg)
+		if !msg.tablesOnly || msg.err != nil {
+			return next
+		}
+		cmd = next
+	}
+}
+
+func TestSchemaLoadsDiskSnapshot(t *testing.T) {
+	m, _ := newSchemaModel(t)
+	snap := schemacache.Snapshot{
+		Fetched: time.Now(),
+		Tables:  []string{"cached"},
+		Columns: map[string][]db.ColumnInfo{"cached": {{Name: "c"}}},
+	}
+	if err := schemacache.Save(m.rawDSN, snap); err != nil {
+		t.Fatal(err)
+	}
+
+	// A fresh snapshot is used as it is.
+	if cmd := runSchemaCmd(m, m.loadSchema()); cmd != nil {
+		t.Error("expected no refresh of a fresh snapshot")
+	}
+	if !slices.Equal(m.sidebar.tables, []string{"cached"}) || len(m.completion.colCache["cached"]) != 1 {
+		t.Fatalf("expected the snapshot, got %v and %v", m.sidebar.tables, m.completion.colCache)
+	}
+
+	// An old one is refreshed from the database, which saves it again.
+	snap.Fetched = time.Now().Add(-2 * schemaMaxAge)
+	if err := schemacache.Save(m.rawDSN, snap); err != nil {
+		t.Fatal(err)
+	}
+	cmd := runSchemaCmd(m, m.loadSchema())
+	if cmd == nil || !m.schema.refreshing {
+		t.Fatal("expected a background refresh of an old snapshot")
+	}
+	runSchemaCmd(m, cmd)
+	if !slices.Equal(m.sidebar.tables, []string{"users"}) || m.schema.refreshing {
+		t.Errorf("expected the tables of the database, got %v", m.sidebar.tables)
+	}
+	if saved, _, _ := schemacache.Load(m.rawDSN); !slices.Equal(saved.Tables, []string{"users"}) {
+		t.Errorf("expected the refreshed snapshot on disk, got %v", saved.Tables)
+	}
+}
+
+func TestSchemaRefreshesAfterDDL(t *testing.T) {
+	m, adapter := newSchemaModel(t)
+	runSchemaCmd(m, m.loadSchema())
+
+	// A query that does not change the schema leaves it alone.
+	m.querySeq = 1
+	_, cmd := m.Update(queryExecutedMsg{seq: 1, query: "SELECT * FROM users"})
+	if cmd != nil {
+		t.Fatal("expected no refresh after SELECT")
+	}
+
+	if _, err := adapter.Query(context.Background(), "CREATE TABLE orders (id INTEGER)"); err != nil {
+		t.Fatal(err)
+	}
+	m.querySeq = 2
+	updated, cmd := m.Update(queryExecutedMsg{seq: 2, query: "CREATE TABLE orders (id INTEGER)"})
+	if cmd == nil {
+		t.Fatal("expected a refresh after CREATE TABLE")
+	}
+	result := updated.(model)
+
+	// A second change while refreshing queues one more refresh.
+	if result.refreshSchema(false) != nil || !result.schema.again {
+		t.Fatal("expected the refresh to be queued")
+	}
+	next := runSchemaCmd(&result, cmd)
+	if next == nil {
+		t.Fatal("expected the queued refresh to start")
+	}
+	runSchemaCmd(&result, next)
+	if !slices.Equal(result.sidebar.tables, []string{"orders", "users"}) {
+		t.Errorf("tables = %v", result.sidebar.tables)
+	}
+}
+
+func TestSidebar_RRefreshesSchema(t *testing.T) {
+	m, _ := newSchemaModel(t)
+	m.mode = sidebarMode
+	m.sidebar.open = true
+	m.schema.fetched = time.Now().Add(-5 * time.Minute)
+	if !strings.Contains(m.renderStatusBar(), "schema: 5m ago") {
+		t.Errorf("expected the schema age in the status bar:\n%s", m.renderStatusBar())
+	}
+
+	updated, cmd := m.Update(runeMsg("r"))
+	if cmd == nil {
+		t.Fatal("expected r to refresh the schema")
+	}
+	result := updated.(model)
+	if !strings.Contains(result.renderStatusBar(), "schema: refreshing") {
+		t.Errorf("expected the refresh in the status bar:\n%s", result.renderStatusBar())
+	}
+	runSchemaCmd(&result, cmd)
+	if !strings.Contains(result.statusText, "Schema refreshed: 1 table(s)") {
+		t.Errorf("status = %q", result.statusText)
+	}
+}
+
+func TestFormatAge(t *testing.T) {
+	tests := map[time.Duration]string{
+		10 * time.Second: "just now",
+		5 * time.Minute:  "5m ago",
+		3 * time.Hour:    "3h ago",
+		50 * time.Hour:   "2d ago",
+	}
+	for d, want := range tests {
+		if got := formatAge(d); got != want {
+			t.Errorf("formatAge(%v) = %q, want %q", d, got, want)
+		}
+	}
+}
+
+func TestSchemaShowsTablesBeforeColumns(t *testing.T) {
+	m, _ := newSchemaModel(t)
+	msg := m.loadSchema()().(schemaLoadedMsg)
+	if !msg.tablesOnly {
+		t.Fatal("expected the tables to be read first")
+	}
+	cmd := m.handleSchemaLoaded(msg)
+	if cmd == nil || !slices.Equal(m.sidebar.tables, []string{"users"}) || !m.schema.refreshing {
+		t.Fatalf("expected the tables while the columns load, got %v", m.sidebar.tables)
+	}
+	m.handleSchemaLoaded(cmd().(schemaLoadedMsg))
+	if m.schema.refreshing || len(m.completion.colCache["users"]) != 1 {
+		t.Errorf("expected the columns, got %v", m.completion.colCache)
+	}
+}
+
+func TestSchemaKeepsPartialColumns(t *testing.T) {
+	m, _ := newSchemaModel(t)
+	m.completion.colCache = map[string][]db.ColumnInfo{"orders": {{Name: "id"}}}
+	m.schema.refreshing = true
+	snap := schemacache.Snapshot{
+		Fetched: time.Now(),
+		Tables:  []string{"orders", "users"},
+		Columns: map[string][]db.ColumnInfo{"users": {{Name: "id"}}},
+		Partial: true,
+	}
+	m.handleSchemaLoaded(schemaLoadedMsg{snap: snap, err: context.DeadlineExceeded})
+	if !slices.Equal(m.sidebar.tables, snap.Tables) || len(m.completion.colCache) != 2 {
+		t.Errorf("expected the partial snapshot, got %v and %v", m.sidebar.tables, m.completion.colCache)
+	}
+	if !m.statusError || !strings.Contains(m.statusText, "partly loaded (1 of 2 tables)") {
+		t.Errorf("status = %q", m.statusText)
+	}
+}
@@ -9,6 +9,7 @@ import (
 )
 
 func (m model) updateSidebar(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	var cmd tea.Cmd
 	switch msg.Type {
 	case tea.KeyEsc:
 		m.sidebar.open = false
@@ -29,6 +30,9 @@ func (m model) updateSidebar(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 			moveCursor(&m.sidebar.cursor, len(m.sidebar.tables), 1)
 		case "k":
 			moveCursor(&m.sidebar.cursor, len(m.sidebar.tables), -1)
+		case "r":
+			cmd = m.refreshSchema(true)
+			m.setStatus("Refreshing schema...", false)
 		}
 	case tea.KeyDown:
 		moveCursor(&m.sidebar.cursor, len(m.sidebar.tables), 1)
@@ -48,7 +52,7 @@ func (m model) updateSidebar(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 		}
 	}
 	m.syncViewport()
-	return m, nil
+	return m, cmd
 }
 
 func (m model) renderSidebar() string {
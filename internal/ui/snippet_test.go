@@ -17,7 +17,7 @@ func newSnippetTestModel(snippets []snippet.Snippet) *model {
 	m.snippetSt.cursor = 0
 	m.snippetSt.input = textinput.New()
 	m.snippetSt.input.CharLimit = 100
-	m.textarea = textarea.New()
+	m.textarea = sqlEditor{Model: textarea.New()}
 	return m
 }
 
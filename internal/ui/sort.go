@@ -51,11 +51,23 @@ func sortedRows(rows [][]string, col int, dir sortOrder) [][]string {
 	if dir == sortNone || len(rows) == 0 {
 		return rows
 	}
+	indices := sortedIndices(rows, col, dir)
+	result := make([][]string, len(rows))
+	for i, idx := range indices {
+		result[i] = rows[idx]
+	}
+	return result
+}
 
+// sortedIndices returns the indexes of rows in sorted order.
+func sortedIndices(rows [][]string, col int, dir sortOrder) []int {
 	indices := make([]int, len(rows))
 	for i := range indices {
 		indices[i] = i
 	}
+	if dir == sortNone {
+		return indices
+	}
 
 	sort.SliceStable(indices, func(i, j int) bool {
 		ai, bi := indices[i], indices[j]
@@ -81,12 +93,7 @@ func sortedRows(rows [][]string, col int, dir sortOrder) [][]string {
 		}
 		return cmp < 0
 	})
-
-	result := make([][]string, len(rows))
-	for i, idx := range indices {
-		result[i] = rows[idx]
-	}
-	return result
+	return indices
 }
 
 // sortIndicator returns the sort direction symbol for a column header.
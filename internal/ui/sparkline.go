@@ -1,6 +1,7 @@
 package ui
 
 import (
+	"fmt"
 	"sort"
 	"strings"
 	"time"
@@ -59,6 +60,9 @@ const (
 	granDay timeGranularity = iota
 	granMonth
 	granYear
+	granHour
+	granWeek
+	granQuarter
 )
 
 const (
@@ -88,12 +92,38 @@ func truncateTime(t time.Time, gran timeGranularity) time.Time {
 		return time.Date(t.Year(), t.Month(), 1, 0, 0, 0, 0, t.Location())
 	case granYear:
 		return time.Date(t.Year(), 1, 1, 0, 0, 0, 0, t.Location())
+	case granHour:
+		return time.Date(t.Year(), t.Month(), t.Day(), t.Hour(), 0, 0, 0, t.Location())
+	case granWeek:
+		// ISO weeks start on Monday.
+		day := time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, t.Location())
+		return day.AddDate(0, 0, -((int(t.Weekday()) + 6) % 7))
+	case granQuarter:
+		return time.Date(t.Year(), (t.Month()-1)/3*3+1, 1, 0, 0, 0, 0, t.Location())
 	default:
 		// Fallback to day granularity for unknown values
 		return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, t.Location())
 	}
 }
 
+// nextBucket returns the start of the bucket after the one starting at t.
+func nextBucket(t time.Time, gran timeGranularity) time.Time {
+	switch gran {
+	case granHour:
+		return t.Add(time.Hour)
+	case granWeek:
+		return t.AddDate(0, 0, 7)
+	case granMonth:
+		return t.AddDate(0, 1, 0)
+	case granQuarter:
+		return t.AddDate(0, 3, 0)
+	case granYear:
+		return t.AddDate(1, 0, 0)
+	default:
+		return t.AddDate(0, 0, 1)
+	}
+}
+
 // bucketKey returns a sortable string key for a time bucket.
 func bucketKey(t time.Time, gran timeGranularity) string {
 	switch gran {
@@ -103,6 +133,13 @@ func bucketKey(t time.Time, gran timeGranularity) string {
 		return t.Format("2006-01")
 	case granYear:
 		return t.Format("2006")
+	case granHour:
+		return t.Format("2006-01-02 15:00")
+	case granWeek:
+		year, week := t.ISOWeek()
+		return fmt.Sprintf("%d-W%02d", year, week)
+	case granQuarter:
+		return fmt.Sprintf("%d-Q%d", t.Year(), (t.Month()-1)/3+1)
 	default:
 		// Fallback to day granularity for unknown values
 		return t.Format("2006-01-02")
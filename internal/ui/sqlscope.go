@@ -0,0 +1,390 @@
+package ui
+
+import (
+	"strings"
+
+	"github.com/kwrkb/asql/internal/db/dbutil"
+)
+
+// tableRef is a table, CTE or subquery that a query reads from.
+type tableRef struct {
+	name    string   // table or CTE name, unquoted; "" for a subquery
+	alias   string   // alias given in FROM or JOIN
+	cols    []string // columns of a CTE or subquery
+	derived bool     // a CTE or subquery rather than a database table
+}
+
+// queryBlock is one SELECT (or other statement) and the names it defines.
+type queryBlock struct {
+	parent  *queryBlock
+	refs    []tableRef
+	ctes    []tableRef
+	outputs []string // names of the SELECT items
+}
+
+// sqlScope is what completion knows about the statement around the cursor.
+type sqlScope struct {
+	ctx        completionContext
+	clause     string     // clause the cursor is in, lower-cased ("select", "order", ...)
+	refs       []tableRef // tables in scope, those of the innermost query first
+	ctes       []tableRef
+	outputs    []string // SELECT item names, offered in GROUP BY and ORDER BY
+	insertList bool     // the cursor is in the column list of an INSERT
+}
+
+// resolve finds the table, CTE or subquery that qualifier (as in
+// "qualifier.column") stands for.
+func (s sqlScope) resolve(qualifier string) (tableRef, bool) {
+	for _, r := range s.refs {
+		if r.alias != "" && strings.EqualFold(r.alias, qualifier) {
+			return r, true
+		}
+	}
+	for _, r := range s.refs {
+		if r.alias == "" && r.name != "" && nameMatches(r.name, qualifier) {
+			return r, true
+		}
+	}
+	for _, c := range s.ctes {
+		if strings.EqualFold(c.name, qualifier) {
+			return c, true
+		}
+	}
+	return tableRef{}, false
+}
+
+// nameMatches reports whether the possibly schema-qualified name refers to
+// table, ignoring case.
+func nameMatches(name, table string) bool {
+	if strings.EqualFold(name, table) {
+		return true
+	}
+	_, short := dbutil.SplitTableName(name)
+	_, other := dbutil.SplitTableName(table)
+	return strings.EqualFold(short, other)
+}
+
+// clauseKeywords are the keywords that switch the clause a token is in.
+var clauseKeywords = map[string]string{
+	"with": "with", "select": "select", "from": "from", "join": "join", "on": "on",
+	"using": "on", "where": "where", "group": "group", "order": "order", "having": "having",
+	"limit": "limit", "offset": "limit", "insert": "insert", "into": "into", "values": "values",
+	"update": "update", "set": "set", "delete": "delete", "returning": "returning",
+	"union": "", "intersect": "", "except": "", "window": "window",
+}
+
+// sqlParser walks the tokens of one statement and records the state at the
+// completion position.
+type sqlParser struct {
+	text string
+	toks []dbutil.Token
+	pos  int // byte offset of the word being completed
+
+	// State after the last token before pos.
+	block  *queryBlock
+	clause string
+	prev   int // index of that token, -1 if none
+	insert string
+}
+
+// analyzeSQL tokenizes the statement of text that contains byte offset pos
+// and works out the context of a word starting there, and the tables,
+// aliases and CTEs in scope.
+func analyzeSQL(text string, pos int, dialect dbutil.Dialect) sqlScope {
+	var toks []dbutil.Token
+	for _, t := range dbutil.Tokenize(text, dialect) {
+		if t.Kind == dbutil.TokenSpace || t.Kind == dbutil.TokenComment {
+			continue
+		}
+		if t.Kind == dbutil.TokenPunct && t.Text(text) == ";" {
+			if t.Start < pos {
+				toks = toks[:0]
+				continue
+			}
+			break
+		}
+		toks = append(toks, t)
+	}
+
+	p := &sqlParser{text: text, toks: toks, pos: pos, prev: -1}
+	root, _ := p.parseQuery(0, nil)
+	if p.block == nil {
+		p.block = root
+	}
+
+	scope := sqlScope{clause: p.clause}
+	for b := p.block; b != nil; b = b.parent {
+		scope.refs = append(scope.refs, b.refs...)
+		scope.ctes = append(scope.ctes, b.ctes...)
+	}
+	if p.clause == "group" || p.clause == "order" {
+		scope.outputs = p.block.outputs
+	}
+
+	prev := ""
+	if p.prev >= 0 {
+		prev = p.lower(p.prev)
+	}
+	switch p.clause {
+	case "from", "join", "into", "update":
+		switch {
+		case prev == p.clause || prev == "," || prev == "join" || prev == "table":
+			scope.ctx = contextTable
+		case prev == "(":
+			scope.ctx = contextUnknown
+		default:
+			// After a table reference: an alias or the next clause.
+			scope.ctx = contextClause
+		}
+	case "select", "where", "having", "set", "on", "group", "order", "returning":
+		scope.ctx = contextColumn
+	}
+	if p.insert != "" {
+		scope.ctx = contextColumn
+		scope.insertList = true
+		scope.refs = []tableRef{{name: p.insert}}
+	}
+	return scope
+}
+
+func (p *sqlParser) lower(i int) string {
+	if i < 0 || i >= len(p.toks) {
+		return ""
+	}
+	return strings.ToLower(p.toks[i].Text(p.text))
+}
+
+// isName reports whether token i is an identifier rather than a keyword
+// or punctuation.
+func (p *sqlParser) isName(i int) bool {
+	if i < 0 || i >= len(p.toks) {
+		return false
+	}
+	k := p.toks[i].Kind
+	return k == dbutil.TokenWord || k == dbutil.TokenQuotedIdent
+}
+
+// name returns identifier token i without its quotes.
+func (p *sqlParser) name(i int) string {
+	text := p.toks[i].Text(p.text)
+	if p.toks[i].Kind != dbutil.TokenQuotedIdent || len(text) < 2 {
+		return text
+	}
+	quote := text[:1]
+	if quote == "[" {
+		return text[1 : len(text)-1]
+	}
+	return strings.ReplaceAll(text[1:len(text)-1], quote+quote, quote)
+}
+
+// startsQuery reports whether the bracket at i opens a subquery.
+func (p *sqlParser) startsQuery(i int) bool {
+	next := p.lower(i + 1)
+	return next == "select" || next == "with"
+}
+
+// record notes the state after token i if it comes before the position.
+func (p *sqlParser) record(i int, blk *queryBlock, clause, insert string) {
+	if i < len(p.toks) && p.toks[i].Start < p.pos {
+		p.block, p.clause, p.prev, p.insert = blk, clause, i, insert
+	}
+}
+
+// parseQuery reads one query from token i up to the bracket that closes
+// it, or the end. It returns the block and the index of that bracket.
+func (p *sqlParser) parseQuery(i int, parent *queryBlock) (*queryBlock, int) {
+	blk := &queryBlock{parent: parent}
+	clause := ""
+	depth := 0       // brackets that do not open a subquery
+	insertDepth := 0 // depth of the INSERT column list, 0 outside it
+	insertTable := ""
+	var item []int // tokens of the current SELECT item
+
+	endItem := func() {
+		if name := p.outputName(item); name != "" {
+			blk.outputs = append(blk.outputs, name)
+		}
+		item = nil
+	}
+
+	for ; i < len(p.toks); i++ {
+		lower := p.lower(i)
+		switch {
+		case lower == "(" && p.startsQuery(i):
+			p.record(i, blk, clause, "")
+			sub, end := p.parseQuery(i+1, blk)
+			i = end
+			p.record(i, blk, clause, "")
+			if depth == 0 && (clause == "from" || clause == "join") {
+				ref := tableRef{cols: sub.outputs, derived: true}
+				ref.alias, i = p.alias(i + 1)
+				blk.refs = append(blk.refs, ref)
+				p.record(i, blk, clause, "")
+			}
+			continue
+		case lower == "(":
+			depth++
+			if clause == "into" && insertDepth == 0 && len(blk.refs) > 0 && p.isName(i-1) {
+				insertDepth, insertTable = depth, blk.refs[len(blk.refs)-1].name
+			}
+		case lower == ")":
+			if depth == 0 {
+				if clause == "select" {
+					endItem()
+				}
+				return blk, i
+			}
+			if depth == insertDepth {
+				insertDepth = 0
+			}
+			depth--
+		case depth > 0:
+		case p.toks[i].Kind == dbutil.TokenKeyword && p.isClause(i, lower):
+			if clause == "select" {
+				endItem()
+			}
+			clause = clauseKeywords[lower]
+		case clause == "with" && p.isName(i) && (p.lower(i-1) == "with" || p.lower(i-1) == "recursive" || p.lower(i-1) == ","):
+			i = p.parseCTE(i, blk)
+			p.record(i, blk, clause, "")
+			continue
+		case (clause == "from" || clause == "join" || clause == "into" || clause == "update") && p.isName(i):
+			prev := p.lower(i - 1)
+			if prev == clause || prev == "join" || prev == "," || prev == "table" {
+				i = p.tableRef(i, blk)
+				p.record(i, blk, clause, "")
+				continue
+			}
+		}
+		if clause == "select" && lower != "select" {
+			switch {
+			case depth == 0 && lower == ",":
+				endItem()
+			case len(item) == 0 && (lower == "distinct" || lower == "all"):
+			default:
+				item = append(item, i)
+			}
+		}
+		insert := ""
+		if insertDepth > 0 && depth == insertDepth {
+			insert = insertTable
+		}
+		p.record(i, blk, clause, insert)
+	}
+	if clause == "select" {
+		endItem()
+	}
+	return blk, i
+}
+
+// isClause reports whether keyword token i starts a clause.
+func (p *sqlParser) isClause(i int, kw string) bool {
+	if _, ok := clauseKeywords[kw]; !ok {
+		return false
+	}
+	prev := p.lower(i - 1)
+	switch kw {
+	case "from":
+		return prev != "distinct"
+	case "update":
+		return prev != "do" && prev != "for" && prev != "on"
+	case "set":
+		return prev != "update" && prev != "delete"
+	case "on":
+		return prev != "do" && p.lower(i+1) != "conflict"
+	case "group", "order":
+		return p.lower(i+1) == "by"
+	}
+	return true
+}
+
+// tableRef reads "name [[AS] alias]" at i into blk and returns the index of
+// its last token.
+func (p *sqlParser) tableRef(i int, blk *queryBlock) int {
+	name := p.name(i)
+	for p.lower(i+1) == "." && p.isName(i+2) {
+		name += "." + p.name(i+2)
+		i += 2
+	}
+	ref := tableRef{name: name}
+	for b := blk; b != nil; b = b.parent {
+		for _, c := range b.ctes {
+			if strings.EqualFold(c.name, name) {
+				ref.cols, ref.derived = c.cols, true
+			}
+		}
+	}
+	ref.alias, i = p.alias(i + 1)
+	blk.refs = append(blk.refs, ref)
+	return i
+}
+
+// alias reads an optional "[AS] alias" at i. It returns the alias and the
+// index of the last token read.
+func (p *sqlParser) alias(i int) (string, int) {
+	if p.lower(i) == "as" && p.isName(i+1) {
+		return p.name(i + 1), i + 1
+	}
+	if p.isName(i) {
+		return p.name(i), i
+	}
+	return "", i - 1
+}
+
+// parseCTE reads "name [(columns)] AS [[NOT] MATERIALIZED] (query)" at i into
+// blk and returns the index of the closing bracket.
+func (p *sqlParser) parseCTE(i int, blk *queryBlock) int {
+	cte := tableRef{name: p.name(i), derived: true}
+	j := i + 1
+	explicit := false
+	if p.lower(j) == "(" && !p.startsQuery(j) {
+		explicit = true
+		for j++; j < len(p.toks) && p.lower(j) != ")"; j++ {
+			if p.isName(j) {
+				cte.cols = append(cte.cols, p.name(j))
+			}
+		}
+		j++
+	}
+	if p.lower(j) != "as" {
+		return j - 1
+	}
+	j++
+	for p.lower(j) == "not" || p.lower(j) == "materialized" {
+		j++
+	}
+	if p.lower(j) != "(" {
+		return j - 1
+	}
+	p.record(j, blk, "with", "")
+	sub, end := p.parseQuery(j+1, blk)
+	if !explicit {
+		cte.cols = sub.outputs
+	}
+	blk.ctes = append(blk.ctes, cte)
+	return end
+}
+
+// outputName is the column name a SELECT item produces: its alias, or the
+// column it reads. Expressions without an alias have none.
+func (p *sqlParser) outputName(item []int) string {
+	n := len(item)
+	if n == 0 || !p.isName(item[n-1]) {
+		return ""
+	}
+	last := item[n-1]
+	if n == 1 {
+		return p.name(last)
+	}
+	switch prev := item[n-2]; {
+	case p.lower(prev) == "as", p.lower(prev) == ".":
+		return p.name(last)
+	case p.isName(prev), p.lower(prev) == ")":
+		return p.name(last)
+	}
+	switch p.toks[item[n-2]].Kind {
+	case dbutil.TokenNumber, dbutil.TokenString:
+		return p.name(last)
+	}
+	return ""
+}
@@ -1,10 +1,15 @@
 package ui
 
 import (
+	"context"
+	"time"
+
 	"github.com/charmbracelet/bubbles/spinner"
 	"github.com/charmbracelet/bubbles/textinput"
 
 	"github.com/kwrkb/asql/internal/ai"
+	"github.com/kwrkb/asql/internal/db"
+	"github.com/kwrkb/asql/internal/export"
 	"github.com/kwrkb/asql/internal/profile"
 	"github.com/kwrkb/asql/internal/snippet"
 )
@@ -18,6 +23,108 @@ type detailState struct {
 // exportState holds state for the export overlay (EXPORT mode).
 type exportState struct {
 	cursor int
+	saving bool            // true while the save-to-file dialog is open
+	path   textinput.Model // destination path in the save dialog
+	format int             // index into export.Formats
+	scope  exportScope
+	focus  int // focused save dialog field (exportField*)
+	err    string
+	// overwrite is the existing file the user agreed to replace by
+	// pressing Enter a second time.
+	overwrite string
+
+	// JSON formats only
+	jsonArrays bool // header array + row arrays instead of objects
+
+	// SQL format only
+	table  textinput.Model // target table name
+	upsert bool            // emit UPSERT instead of plain INSERT
+	keys   textinput.Model // comma-separated conflict key columns
+}
+
+// streamExportState tracks a running full-result export (see export_stream.go).
+// The export is in progress while cancel is non-nil.
+type streamExportState struct {
+	seq     uint64 // invalidates messages from earlier exports
+	cancel  context.CancelFunc
+	sink    *export.FileSink
+	path    string
+	format  export.Format
+	started time.Time
+}
+
+// viewerState holds state for the full-screen value viewer (VIEWER mode).
+type viewerState struct {
+	title   string
+	column  int        // result column the value came from
+	raw     string     // unsanitized cell value; copied as-is with y
+	kind    viewerKind // requested format
+	shown   viewerKind // format actually rendered (falls back to text)
+	tree    *jsonNode  // parsed JSON, keeps fold state across rebuilds
+	lines   []viewerLine
+	rows    []viewerRow // lines wrapped to the current width
+	cursor  int         // index into rows
+	scroll  int
+	xOffset int // horizontal scroll when wrap is off
+	wrap    bool
+
+	searching bool
+	search    textinput.Model
+	query     string
+	matches   []int // rows containing query
+}
+
+// changeKind is the statement a pendingEdit turns into.
+type changeKind int
+
+const (
+	changeUpdate changeKind = iota // UPDATE one column of one row
+	changeInsert                   // INSERT a new row
+	changeDelete                   // DELETE one row
+)
+
+// visualState holds the selection made in VISUAL mode. The selection stays
+// active while the export menu it was opened from is shown.
+type visualState struct {
+	active    bool
+	block     bool // v: rectangular block of cells; V: whole rows
+	anchorRow int  // display row where the selection started
+	anchorCol int
+	yank      bool // waiting for the yank format key after y
+}
+
+// pendingEdit is a staged single-row change, applied later together with the
+// other pending changes (see edit.go).
+type pendingEdit struct {
+	kind     changeKind
+	dsn      string   // connection the edit was made on
+	table    string   // possibly "schema.table"
+	keyCols  []string // primary key columns
+	keyVals  []string // primary key cells of the row (update, delete, duplicate)
+	keyTypes []string
+
+	// changeUpdate
+	column   string
+	colType  string
+	oldValue string // previous cell, in result cell encoding
+	value    string // new value as typed
+	null     bool   // set the column to NULL instead of value
+
+	// changeInsert: columns copied from the row with keyVals. No columns
+	// inserts a row of defaults.
+	insertCols []string
+}
+
+// editState holds state for the cell editor (EDIT mode) and the list of
+// staged changes (PENDING mode).
+type editState struct {
+	seq      uint64 // invalidates stale key lookups and apply results
+	input    textinput.Model
+	target   pendingEdit // edit being composed
+	prevMode mode        // mode to return to after editing
+	pending  []pendingEdit
+	cursor   int // selected pending edit
+	applying bool
 }
 
 // aiState holds state for the AI text-to-SQL overlay (AI mode).
@@ -57,12 +164,14 @@ type histSearchState struct {
 // completionState holds state for tab-completion in INSERT mode.
 type completionState struct {
 	active        bool
-	items         []string
+	items         []completionItem
 	cursor        int
 	prefix        string
-	colCache      map[string][]string
-	colOrder      []string // LRU order: most recently used at end
-	pendingPrefix string   // prefix when async fetch was initiated (empty = no pending)
+	colCache      map[string][]db.ColumnInfo // by table; filled from the schema snapshot
+	pendingPrefix string                     // prefix when async fetch was initiated (empty = no pending)
+	pendingAuto   bool                       // the pending completion was opened by typing "."
+	recent        map[string]int             // lower-cased accepted item -> useSeq when last accepted
+	useSeq        int                        // number of completions accepted
 }
 
 // sidebarState holds state for the table-list sidebar (SIDEBAR mode).
@@ -97,6 +206,93 @@ type columnStat struct {
 	Max       string
 	Sparkline sparklineData // non-empty only for date/timestamp columns
 	Histogram histogramData // non-empty only for numeric columns
+
+	TopValues   []valueCount // most frequent non-NULL values, most frequent first
+	Percentiles []string     // values at statsPercentiles; empty when not computed
+
+	// Numeric columns only
+	Numeric      int // values that parse as numbers; 0 when not computed
+	Mean, Stddev float64
+
+	// Text columns only
+	LenMin, LenMax int
+	LenAvg         float64
+	Pattern        string  // most common value shape, e.g. `\d{3}-\d{4}`
+	PatternRate    float64 // share of non-NULL values matching Pattern
+
+	// Table profiles only (see tableprofile.go)
+	Pending bool   // still being profiled
+	Err     string // profile query error
+}
+
+// valueCount is one entry of a column's most frequent values.
+type valueCount struct {
+	Value string
+	Count int
+	Share float64 // of all rows, NULLs included
+}
+
+// gridFilter restricts the result grid to the rows whose cell in col equals
+// value.
+type gridFilter struct {
+	active  bool
+	col     int
+	value   string
+	matched int // rows left by the filter
+}
+
+// pivotState holds state for choosing the aggregate of a group-by (PIVOT
+// mode).
+type pivotState struct {
+	col     int   // grouped column
+	choices []int // numeric columns that can be summed and averaged
+	cursor  int   // 0 = count only, i = choices[i-1]
+}
+
+// plotState holds state for the chart of a result (PLOT mode).
+type plotState struct {
+	x, y   int   // X axis column and plotted numeric column
+	ys     []int // numeric columns m cycles through
+	kind   plotKind
+	gran   timeGranularity // plotLine buckets
+	points []chartPoint    // plotBar and plotLine
+	xs     []float64       // plotScatter
+	yvals  []float64       // plotScatter
+	scroll int             // first bar shown
+	err    string
+}
+
+// joinState holds state for the bucket join picker (JOIN mode).
+type joinState struct {
+	leftCol  int // date column of the pinned result
+	rightCol int // date column of the active result
+	cursor   int // index into joinGranularities
+}
+
+// tzState holds the display time zone of zoned timestamps.
+type tzState struct {
+	base    *time.Location // configured time_zone; nil = system zone
+	profile *time.Location // time_zone of the active connection's profile
+	utc     bool           // Z: show UTC instead
+}
+
+// chartState holds state for the time-series chart (CHART mode).
+type chartState struct {
+	col      int             // date column
+	gran     timeGranularity // bucket size
+	measure  int             // summed column, -1 = row count
+	measures []int           // numeric columns m cycles through
+	average  bool            // average the measure instead of summing it
+	line     bool            // line instead of bars
+	points   []chartPoint
+	gaps     []string // keys of the empty buckets
+	err      string
+}
+
+// pivotBase is the result a group-by replaced.
+type pivotBase struct {
+	result db.QueryResult
+	query  string
 }
 
 // statsState holds state for the column-statistics overlay (STATS mode).
@@ -104,6 +300,15 @@ type statsState struct {
 	cursor  int
 	scroll  int
 	stats   []columnStat
-	loading bool   // true while stats are being computed asynchronously
-	seq     uint64 // incremented on each stats request to discard stale results
+	diffs   []columnStatDiff // statsCompare only
+	loading bool             // true while stats are being computed asynchronously
+	seq     uint64           // incremented on each stats request to discard stale results
+
+	scope    statsScope
+	table    string             // profiled table (statsSampled, statsFull)
+	columns  []string           // table column of each profiled stat; "" when not in the table
+	seed     int64              // sample seed shared by the queries of a profile (Postgres)
+	fullScan bool               // the sample filters every row of the table (MySQL, SQLite)
+	total    int64              // rows scanned by the profile; -1 until known
+	cancel   context.CancelFunc // cancels a running table profile
 }
@@ -2,6 +2,8 @@ package ui
 
 import (
 	"fmt"
+	"math"
+	"strconv"
 	"strings"
 
 	tea "github.com/charmbracelet/bubbletea"
@@ -20,7 +22,7 @@ func computeColumnStats(result db.QueryResult) []columnStat {
 			s.Type = result.ColumnTypes[i]
 		}
 
-		distinct := make(map[string]struct{})
+		counts := make(map[string]int)
 		firstNonNull := true
 
 		for _, row := range result.Rows {
@@ -32,7 +34,7 @@ func computeColumnStats(result db.QueryResult) []columnStat {
 				s.NullCnt++
 				continue
 			}
-			distinct[val] = struct{}{}
+			counts[val]++
 			if firstNonNull {
 				s.Min = val
 				s.Max = val
@@ -47,15 +49,19 @@ func computeColumnStats(result db.QueryResult) []columnStat {
 			}
 		}
 
-		s.Distinct = len(distinct)
+		s.Distinct = len(counts)
 		if rowCount > 0 {
 			s.NullRate = float64(s.NullCnt) / float64(rowCount)
 		}
+		s.TopValues = topValues(counts, rowCount)
 
 		if detectDateColumn(s.Type) || looksLikeDate(result.Rows, i) {
 			s.Sparkline = computeSparkline(result.Rows, i)
 		} else if detectNumericColumn(s.Type) || looksLikeNumeric(result.Rows, i) {
 			s.Histogram = computeHistogram(result.Rows, i)
+			summarizeNumbers(&s, result.Rows, i)
+		} else {
+			summarizeText(&s, result.Rows, i)
 		}
 
 		stats[i] = s
@@ -63,9 +69,26 @@ func computeColumnStats(result db.QueryResult) []columnStat {
 	return stats
 }
 
+// startResultStats computes the stats of the fetched result rows in the
+// background.
+func (m *model) startResultStats() tea.Cmd {
+	m.cancelProfile()
+	m.statsSt.seq++
+	m.statsSt.scope = statsResult
+	m.statsSt.stats = nil
+	m.statsSt.loading = true
+	m.setStatus("Computing stats...", false)
+	result := m.lastResult
+	seq := m.statsSt.seq
+	return func() tea.Msg {
+		return statsComputedMsg{seq: seq, stats: computeColumnStats(result)}
+	}
+}
+
 func (m model) updateStats(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 	switch msg.Type {
 	case tea.KeyEsc:
+		m.cancelProfile()
 		m.mode = normalMode
 		m.setStatus("", false)
 	case tea.KeyRunes:
@@ -74,17 +97,50 @@ func (m model) updateStats(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 		}
 		switch string(msg.Runes) {
 		case "q":
+			m.cancelProfile()
 			m.mode = normalMode
 			m.setStatus("", false)
+		case "c":
+			// Switch between the active result's stats and the comparison
+			// with the pinned result.
+			if m.pinned == nil {
+				m.setStatus("Pin a result with c to compare stats", true)
+				break
+			}
+			m.statsSt.cursor = 0
+			m.statsSt.scroll = 0
+			if m.statsSt.scope == statsCompare {
+				return m, m.startResultStats()
+			}
+			return m, m.startCompareStats()
+		case "f", "s", "r", "1", "2", "3", "4", "5", "6", "7", "8", "9", "0":
+			key := string(msg.Runes)
+			if m.statsSt.scope == statsCompare && key != "r" {
+				break
+			}
+			switch key {
+			case "f":
+				return m.startProfile(statsFull)
+			case "s":
+				return m.startProfile(statsSampled)
+			case "r":
+				if m.statsSt.scope != statsResult {
+					m.statsSt.cursor = 0
+					m.statsSt.scroll = 0
+					return m, m.startResultStats()
+				}
+			default:
+				return m.filterTopValue(int(key[0]-'0'+9) % 10)
+			}
 		case "j":
-			moveCursor(&m.statsSt.cursor, len(m.statsSt.stats), 1)
+			moveCursor(&m.statsSt.cursor, m.statsLen(), 1)
 		case "k":
-			moveCursor(&m.statsSt.cursor, len(m.statsSt.stats), -1)
+			moveCursor(&m.statsSt.cursor, m.statsLen(), -1)
 		}
 	case tea.KeyDown:
-		moveCursor(&m.statsSt.cursor, len(m.statsSt.stats), 1)
+		moveCursor(&m.statsSt.cursor, m.statsLen(), 1)
 	case tea.KeyUp:
-		moveCursor(&m.statsSt.cursor, len(m.statsSt.stats), -1)
+		moveCursor(&m.statsSt.cursor, m.statsLen(), -1)
 	}
 
 	// Adjust scroll to keep cursor visible (must happen here, not in render,
@@ -100,12 +156,41 @@ func (m model) updateStats(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 	return m, nil
 }
 
+// filterTopValue closes the overlay and filters the result grid to the
+// n-th (0-based) top value of the column under the cursor.
+func (m model) filterTopValue(n int) (tea.Model, tea.Cmd) {
+	if m.statsSt.cursor >= len(m.statsSt.stats) || n >= len(m.statsSt.stats[m.statsSt.cursor].TopValues) {
+		return m, nil
+	}
+	value := m.statsSt.stats[m.statsSt.cursor].TopValues[n].Value
+	m.cancelProfile()
+	m.mode = normalMode
+	m.colCursor = m.statsSt.cursor
+	m.adjustColOffset()
+	m.setFilter(m.statsSt.cursor, value)
+	return m, nil
+}
+
+// statsLen returns the number of rows of the STATS overlay.
+func (m model) statsLen() int {
+	if m.statsSt.scope == statsCompare {
+		return len(m.statsSt.diffs)
+	}
+	return len(m.statsSt.stats)
+}
+
 // statsMaxVisible returns the number of stat rows visible in the overlay,
-// accounting for the extra line when the cursor row has a sparkline or histogram.
+// accounting for the extra lines shown under the cursor row.
 func (m model) statsMaxVisible() int {
 	// Fixed overhead: border(2), padding(2), title(1), separator(1), header(1) = 7 lines.
 	available := max(m.height-7, 1)
 	v := available
+	if m.statsSt.scope == statsCompare {
+		if m.statsSt.cursor < len(m.statsSt.diffs) {
+			v = max(v-len(statsDiffDetail(m.statsSt.diffs[m.statsSt.cursor])), 1)
+		}
+		return v
+	}
 	if m.statsSt.cursor < len(m.statsSt.stats) {
 		s := m.statsSt.stats[m.statsSt.cursor]
 		hasExtra := s.Sparkline.Bars != "" || s.Sparkline.Skipped ||
@@ -113,15 +198,53 @@ func (m model) statsMaxVisible() int {
 		if hasExtra {
 			v = max(v-1, 1)
 		}
+		v = max(v-len(statsDetail(s)), 1)
 	}
 	return v
 }
 
+// statsDetail returns the lines shown under the cursor row after its
+// sparkline or histogram: percentiles, moments, text shape and top values.
+func statsDetail(s columnStat) []string {
+	if s.Err != "" {
+		return []string{"error: " + sanitize(s.Err)}
+	}
+	var lines []string
+	if len(s.Percentiles) > 0 {
+		parts := make([]string, len(s.Percentiles))
+		for i, v := range s.Percentiles {
+			parts[i] = fmt.Sprintf("p%g:%s", statsPercentiles[i]*100, truncate(sanitize(v), 12))
+		}
+		lines = append(lines, strings.Join(parts, "  "))
+	}
+	if s.Numeric > 0 {
+		lines = append(lines, fmt.Sprintf("mean:%s  stddev:%s", formatAggregate(s.Mean), formatAggregate(s.Stddev)))
+	}
+	if s.LenMax > 0 {
+		line := fmt.Sprintf("len:%d-%d avg:%s", s.LenMin, s.LenMax, formatAggregate(math.Round(s.LenAvg*10)/10))
+		if s.Pattern != "" {
+			line += fmt.Sprintf("  %.0f%% match `%s`", s.PatternRate*100, sanitize(s.Pattern))
+		}
+		lines = append(lines, line)
+	}
+	if len(s.TopValues) > 0 {
+		lines = append(lines, "top values (1-9,0:filter)")
+		for i, tv := range s.TopValues {
+			lines = append(lines, fmt.Sprintf("%d %-20s %7d %5.1f%%", (i+1)%10, truncate(sanitize(tv.Value), 20), tv.Count, tv.Share*100))
+		}
+	}
+	return lines
+}
+
 func (m model) renderWithStatsOverlay(background string) string {
 	if m.statsSt.loading {
+		text := "Computing stats..."
+		if m.statsSt.scope == statsCompare {
+			text = "Comparing stats..."
+		}
 		msg := lipgloss.NewStyle().
 			Foreground(lipgloss.Color(mutedTextColor)).
-			Render("Computing stats...")
+			Render(text)
 		modal := lipgloss.NewStyle().
 			Border(lipgloss.RoundedBorder()).
 			BorderForeground(lipgloss.Color(accentColor)).
@@ -132,6 +255,10 @@ func (m model) renderWithStatsOverlay(background string) string {
 		return overlayModal(m.width, background, modal)
 	}
 
+	if m.statsSt.scope == statsCompare {
+		return m.renderWithStatsDiffOverlay(background)
+	}
+
 	stats := m.statsSt.stats
 	if len(stats) == 0 {
 		return background
@@ -139,10 +266,9 @@ func (m model) renderWithStatsOverlay(background string) string {
 
 	modalWidth := calcModalWidth(m.width, 76)
 	contentWidth := modalWidth - 6 // padding
-	rowCount := len(m.lastResult.Rows)
 
 	var b strings.Builder
-	title := fmt.Sprintf("Column Statistics (%d rows)", rowCount)
+	title := m.statsTitle()
 	b.WriteString(lipgloss.NewStyle().Bold(true).Foreground(lipgloss.Color(accentColor)).Render(title))
 	b.WriteByte('\n')
 	b.WriteString(strings.Repeat("─", contentWidth))
@@ -171,7 +297,7 @@ func (m model) renderWithStatsOverlay(background string) string {
 	b.WriteByte('\n')
 
 	maxVisible := m.statsMaxVisible()
-	rowFmt := fmt.Sprintf("%%s %%-%ds  %%-%ds  %%6s  %%8d  %%s", nameW, typeW)
+	rowFmt := fmt.Sprintf("%%s %%-%ds  %%-%ds  %%6s  %%8s  %%s", nameW, typeW)
 	end := min(m.statsSt.scroll+maxVisible, len(stats))
 
 	for i := m.statsSt.scroll; i < end; i++ {
@@ -182,6 +308,13 @@ func (m model) renderWithStatsOverlay(background string) string {
 		}
 
 		nullPct := fmt.Sprintf("%.1f%%", s.NullRate*100)
+		distinct := strconv.Itoa(s.Distinct)
+		switch {
+		case s.Pending:
+			nullPct, distinct = "…", "…"
+		case s.Err != "":
+			nullPct, distinct = "-", "error"
+		}
 
 		minMax := ""
 		if s.Distinct > 0 {
@@ -197,7 +330,7 @@ func (m model) renderWithStatsOverlay(background string) string {
 		name := truncate(sanitize(s.Name), nameW)
 		typ := truncate(sanitize(s.Type), typeW)
 
-		line := fmt.Sprintf(rowFmt, cursor, name, typ, nullPct, s.Distinct, minMax)
+		line := fmt.Sprintf(rowFmt, cursor, name, typ, nullPct, distinct, minMax)
 		if i == m.statsSt.cursor {
 			line = lipgloss.NewStyle().Foreground(lipgloss.Color(textColor)).Bold(true).Render(line)
 		} else {
@@ -234,6 +367,19 @@ func (m model) renderWithStatsOverlay(background string) string {
 			}
 		}
 
+		if i == m.statsSt.cursor {
+			// same indent as sparkline: aligns under the NULL% column.
+			indent := strings.Repeat(" ", nameW+typeW+7)
+			style := lipgloss.NewStyle().Foreground(lipgloss.Color(mutedTextColor))
+			if s.Err != "" {
+				style = style.Foreground(lipgloss.Color(errorColor))
+			}
+			for _, line := range statsDetail(s) {
+				b.WriteByte('\n')
+				b.WriteString(indent + style.Render(truncateRunes(line, max(contentWidth-len(indent), 1))))
+			}
+		}
+
 		if i < end-1 {
 			b.WriteByte('\n')
 		}
@@ -260,3 +406,16 @@ func truncate(s string, maxLen int) string {
 	}
 	return s[:maxLen-1] + "…"
 }
+
+// truncateRunes is truncate for text holding multi-byte characters, such
+// as bars and arrows.
+func truncateRunes(s string, maxLen int) string {
+	r := []rune(s)
+	if len(r) <= maxLen {
+		return s
+	}
+	if maxLen <= 1 {
+		return "…"
+	}
+	return string(r[:maxLen-1]) + "…"
+}
@@ -0,0 +1,342 @@
+package ui
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+
+	"github.com/kwrkb/asql/internal/db"
+)
+
+const (
+	// nullShiftThreshold flags a column whose NULL rate moved by at least
+	// this much (0.05 = 5 percentage points).
+	nullShiftThreshold = 0.05
+	// distShiftThreshold flags a column whose value distributions differ by
+	// at least this total variation distance.
+	distShiftThreshold = 0.3
+	// diffHistogramBuckets is the number of shared bins of the side-by-side
+	// histograms.
+	diffHistogramBuckets = 12
+	// nearUniqueRatio and nearUniqueMin skip the frequency comparison of a
+	// column whose non-NULL values are mostly distinct, such as IDs or
+	// timestamps: two samples of it share few values whatever the data.
+	nearUniqueRatio = 0.5
+	nearUniqueMin   = 20
+)
+
+// columnStatDiff compares one column of the pinned (left) and active
+// (right) results.
+type columnStatDiff struct {
+	Name            string
+	Left, Right     columnStat
+	InLeft, InRight bool
+	// Shift is the total variation distance between the two value
+	// distributions: 0 when identical, 1 when they share nothing. Numbers
+	// are compared over shared bins, anything else by value frequency.
+	Shift                 float64
+	NearUnique            bool   // values mostly distinct: Shift not computed
+	ShapeLeft, ShapeRight string // histograms over shared bins (numeric columns)
+	ShapeLabel            string // range of the shared bins
+	Flags                 []string
+}
+
+// compareColumnStats pairs the columns of two results by name, in the
+// active result's order followed by the pinned-only columns.
+func compareColumnStats(left, right db.QueryResult) []columnStatDiff {
+	leftStats := computeColumnStats(left)
+	rightStats := computeColumnStats(right)
+	leftIdx := make(map[string]int, len(left.Columns))
+	for i, c := range left.Columns {
+		if _, ok := leftIdx[c]; !ok {
+			leftIdx[c] = i
+		}
+	}
+
+	var diffs []columnStatDiff
+	seen := make(map[string]bool)
+	for ri, c := range right.Columns {
+		if seen[c] {
+			continue
+		}
+		seen[c] = true
+		d := columnStatDiff{Name: c, Right: rightStats[ri], InRight: true}
+		if li, ok := leftIdx[c]; ok {
+			d.Left, d.InLeft = leftStats[li], true
+			d.compare(left.Rows, li, right.Rows, ri)
+		} else {
+			d.Flags = []string{"only in active"}
+		}
+		diffs = append(diffs, d)
+	}
+	for li, c := range left.Columns {
+		if seen[c] {
+			continue
+		}
+		seen[c] = true
+		diffs = append(diffs, columnStatDiff{Name: c, Left: leftStats[li], InLeft: true, Flags: []string{"only in pinned"}})
+	}
+	return diffs
+}
+
+// compare computes the distribution shift of a column present on both
+// sides and flags notable changes.
+func (d *columnStatDiff) compare(leftRows [][]string, li int, rightRows [][]string, ri int) {
+	lv, lok := numericValues(leftRows, li)
+	rv, rok := numericValues(rightRows, ri)
+	if lok && rok {
+		d.Shift, d.ShapeLeft, d.ShapeRight, d.ShapeLabel = histogramShift(lv, rv)
+	} else if lf, rf := valueFrequencies(leftRows, li), valueFrequencies(rightRows, ri); nearUnique(lf) || nearUnique(rf) {
+		d.NearUnique = true
+	} else {
+		d.Shift = frequencyShift(lf, rf)
+	}
+
+	if delta := d.Right.NullRate - d.Left.NullRate; math.Abs(delta) >= nullShiftThreshold {
+		d.Flags = append(d.Flags, fmt.Sprintf("NULL%% %+.1f", delta*100))
+	}
+	if d.Shift >= distShiftThreshold {
+		d.Flags = append(d.Flags, fmt.Sprintf("distribution shift %.2f", d.Shift))
+	}
+}
+
+// numericValues returns the numbers of a column, and whether most of its
+// non-NULL values are numbers (the rule computeHistogram uses).
+func numericValues(rows [][]string, col int) ([]float64, bool) {
+	var vals []float64
+	nonNull := 0
+	for _, row := range rows {
+		if col >= len(row) || row[col] == "NULL" {
+			continue
+		}
+		nonNull++
+		v, err := strconv.ParseFloat(row[col], 64)
+		if err != nil || math.IsNaN(v) || math.IsInf(v, 0) {
+			continue
+		}
+		vals = append(vals, v)
+	}
+	return vals, len(vals) > 0 && len(vals)*2 >= nonNull
+}
+
+// histogramShift bins both sides over their combined range and returns the
+// total variation distance with the rendered bars and the range label.
+func histogramShift(left, right []float64) (float64, string, string, string) {
+	lo, hi := left[0], left[0]
+	for _, vals := range [][]float64{left, right} {
+		for _, v := range vals {
+			lo, hi = min(lo, v), max(hi, v)
+		}
+	}
+	if lo == hi {
+		return 0, "", "", ""
+	}
+	bin := func(vals []float64) []int {
+		counts := make([]int, diffHistogramBuckets)
+		width := (hi - lo) / diffHistogramBuckets
+		for _, v := range vals {
+			counts[min(int((v-lo)/width), diffHistogramBuckets-1)]++
+		}
+		return counts
+	}
+	lc, rc := bin(left), bin(right)
+	var tvd float64
+	for i := range lc {
+		tvd += math.Abs(float64(lc[i])/float64(len(left)) - float64(rc[i])/float64(len(right)))
+	}
+	return tvd / 2, renderSparklineBars(lc), renderSparklineBars(rc), formatHistogramLabel(lo, hi)
+}
+
+// valueFrequencies counts the non-NULL values of a column.
+func valueFrequencies(rows [][]string, col int) map[string]int {
+	counts := make(map[string]int)
+	for _, row := range rows {
+		if col < len(row) && row[col] != "NULL" {
+			counts[row[col]]++
+		}
+	}
+	return counts
+}
+
+// nearUnique reports whether most of the values counted in freq are
+// distinct.
+func nearUnique(freq map[string]int) bool {
+	if len(freq) < nearUniqueMin {
+		return false
+	}
+	var n int
+	for _, c := range freq {
+		n += c
+	}
+	return float64(len(freq)) > nearUniqueRatio*float64(n)
+}
+
+// frequencyShift returns the total variation distance between two value
+// frequency tables.
+func frequencyShift(left, right map[string]int) float64 {
+	var ln, rn int
+	for _, n := range left {
+		ln += n
+	}
+	for _, n := range right {
+		rn += n
+	}
+	if ln == 0 || rn == 0 {
+		return 0
+	}
+	var tvd float64
+	for v, n := range left {
+		tvd += math.Abs(float64(n)/float64(ln) - float64(right[v])/float64(rn))
+	}
+	for v, n := range right {
+		if _, ok := left[v]; !ok {
+			tvd += float64(n) / float64(rn)
+		}
+	}
+	return tvd / 2
+}
+
+// startCompareStats compares the stats of the pinned and active results in
+// the background.
+func (m *model) startCompareStats() tea.Cmd {
+	m.cancelProfile()
+	m.statsSt.seq++
+	m.statsSt.scope = statsCompare
+	m.statsSt.stats = nil
+	m.statsSt.diffs = nil
+	m.statsSt.loading = true
+	m.setStatus("Comparing stats...", false)
+	left, right := m.pinned.result, m.lastResult
+	seq := m.statsSt.seq
+	return func() tea.Msg {
+		return statsComputedMsg{seq: seq, diffs: compareColumnStats(left, right)}
+	}
+}
+
+// compareStatsSummary reports how many columns were flagged.
+func compareStatsSummary(diffs []columnStatDiff) string {
+	flagged := 0
+	for _, d := range diffs {
+		if len(d.Flags) > 0 {
+			flagged++
+		}
+	}
+	if flagged == 0 {
+		return fmt.Sprintf("Compared %d column(s): no notable shifts", len(diffs))
+	}
+	return fmt.Sprintf("Compared %d column(s): %d shifted", len(diffs), flagged)
+}
+
+// statsDiffDetail returns the lines shown under the cursor row of the
+// comparison: extremes and histograms of both sides, and why it is flagged.
+func statsDiffDetail(d columnStatDiff) []string {
+	var lines []string
+	for _, side := range []struct {
+		label string
+		in    bool
+		s     columnStat
+	}{{"pinned", d.InLeft, d.Left}, {"active", d.InRight, d.Right}} {
+		if side.in && side.s.Distinct > 0 {
+			lines = append(lines, fmt.Sprintf("%s: %s → %s", side.label, truncate(sanitize(side.s.Min), 16), truncate(sanitize(side.s.Max), 16)))
+		}
+	}
+	if d.ShapeLeft != "" {
+		lines = append(lines, fmt.Sprintf("pinned %s  active %s  %s", d.ShapeLeft, d.ShapeRight, d.ShapeLabel))
+	}
+	if d.NearUnique {
+		lines = append(lines, "values mostly distinct: distribution not compared")
+	}
+	if len(d.Flags) > 0 {
+		lines = append(lines, "! "+strings.Join(d.Flags, ", "))
+	}
+	return lines
+}
+
+func (m model) renderWithStatsDiffOverlay(background string) string {
+	diffs := m.statsSt.diffs
+	if len(diffs) == 0 {
+		return background
+	}
+
+	modalWidth := calcModalWidth(m.width, 84)
+	contentWidth := modalWidth - 6 // padding
+
+	var b strings.Builder
+	title := fmt.Sprintf("Column Statistics: pinned %s (%d rows) vs active %s (%d rows)",
+		sanitize(m.pinned.connName), len(m.pinned.result.Rows), sanitize(m.connMgr.ActiveName()), len(m.lastResult.Rows))
+	b.WriteString(lipgloss.NewStyle().Bold(true).Foreground(lipgloss.Color(accentColor)).Render(truncateRunes(title, contentWidth)))
+	b.WriteByte('\n')
+	b.WriteString(strings.Repeat("─", contentWidth))
+	b.WriteByte('\n')
+
+	nameW := 6
+	for _, d := range diffs {
+		nameW = max(nameW, len(d.Name))
+	}
+	nameW = min(nameW, 20)
+
+	headerFmt := fmt.Sprintf("  %%-%ds  %%-22s  %%-15s  %%5s", nameW)
+	header := fmt.Sprintf(headerFmt, "Column", "NULL% pinned→active", "Distinct", "Shift")
+	b.WriteString(lipgloss.NewStyle().Foreground(lipgloss.Color(mutedTextColor)).Render(header))
+	b.WriteByte('\n')
+
+	rowFmt := fmt.Sprintf("%%s%%-%ds  %%-22s  %%-15s  %%5s %%s", nameW)
+	maxVisible := m.statsMaxVisible()
+	end := min(m.statsSt.scroll+maxVisible, len(diffs))
+	for i := m.statsSt.scroll; i < end; i++ {
+		d := diffs[i]
+		cursor := "  "
+		if i == m.statsSt.cursor {
+			cursor = lipgloss.NewStyle().Foreground(lipgloss.Color(accentColor)).Render("▸ ")
+		}
+
+		nulls, distinct, shift := "-", "-", "-"
+		if d.InLeft && d.InRight {
+			nulls = fmt.Sprintf("%.1f→%.1f (%+.1f)", d.Left.NullRate*100, d.Right.NullRate*100, (d.Right.NullRate-d.Left.NullRate)*100)
+			distinct = fmt.Sprintf("%d→%d", d.Left.Distinct, d.Right.Distinct)
+			if !d.NearUnique {
+				shift = fmt.Sprintf("%.2f", d.Shift)
+			}
+		}
+		mark := " "
+		if len(d.Flags) > 0 {
+			mark = "!"
+		}
+
+		line := fmt.Sprintf(rowFmt, cursor, truncate(sanitize(d.Name), nameW), nulls, distinct, shift, mark)
+		style := lipgloss.NewStyle().Foreground(lipgloss.Color(textColor))
+		if len(d.Flags) > 0 {
+			style = style.Foreground(lipgloss.Color(keywordColor))
+		}
+		if i == m.statsSt.cursor {
+			style = style.Bold(true)
+		}
+		b.WriteString(style.Render(line))
+
+		if i == m.statsSt.cursor {
+			indent := strings.Repeat(" ", nameW+4)
+			muted := lipgloss.NewStyle().Foreground(lipgloss.Color(mutedTextColor))
+			for _, l := range statsDiffDetail(d) {
+				b.WriteByte('\n')
+				b.WriteString(indent + muted.Render(truncateRunes(l, max(contentWidth-len(indent), 1))))
+			}
+		}
+		if i < end-1 {
+			b.WriteByte('\n')
+		}
+	}
+
+	modal := lipgloss.NewStyle().
+		Border(lipgloss.RoundedBorder()).
+		BorderForeground(lipgloss.Color(accentColor)).
+		Padding(1, 2).
+		Width(modalWidth).
+		Background(panelBackground).
+		Render(b.String())
+
+	return overlayModal(m.width, background, modal)
+}
@@ -0,0 +1,119 @@
+package ui
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/kwrkb/asql/internal/db"
+)
+
+func TestCompareColumnStats(t *testing.T) {
+	var staging, prod [][]string
+	for i := range 100 {
+		email := fmt.Sprintf("u%d@example.com", i)
+		prodEmail := email
+		if i < 3 {
+			email = "NULL"
+		}
+		if i < 40 {
+			prodEmail = "NULL"
+		}
+		staging = append(staging, []string{fmt.Sprint(i), email, fmt.Sprint(i % 10)})
+		prod = append(prod, []string{fmt.Sprint(i), prodEmail, fmt.Sprint(i%10 + 50)})
+	}
+	left := db.QueryResult{Columns: []string{"id", "email", "score", "legacy"}, Rows: padRows(staging, 4)}
+	right := db.QueryResult{Columns: []string{"id", "email", "score"}, Rows: prod}
+
+	diffs := compareColumnStats(left, right)
+	if len(diffs) != 4 {
+		t.Fatalf("got %d diffs, want 4", len(diffs))
+	}
+	id, email, score, legacy := diffs[0], diffs[1], diffs[2], diffs[3]
+
+	if len(id.Flags) != 0 || id.Shift != 0 || id.ShapeLeft == "" || id.ShapeLeft != id.ShapeRight {
+		t.Errorf("id should be unchanged, got %+v", id)
+	}
+	if len(email.Flags) == 0 || email.Flags[0] != "NULL% +37.0" {
+		t.Errorf("email flags = %v", email.Flags)
+	}
+	if score.Shift != 1 || !strings.Contains(strings.Join(score.Flags, ","), "distribution shift 1.00") {
+		t.Errorf("score shift = %v flags = %v", score.Shift, score.Flags)
+	}
+	if !legacy.InLeft || legacy.InRight || legacy.Flags[0] != "only in pinned" {
+		t.Errorf("legacy = %+v", legacy)
+	}
+	if got := compareStatsSummary(diffs); got != "Compared 4 column(s): 3 shifted" {
+		t.Errorf("summary = %q", got)
+	}
+}
+
+func padRows(rows [][]string, n int) [][]string {
+	for i := range rows {
+		rows[i] = padCells(rows[i], n)
+	}
+	return rows
+}
+
+func TestFrequencyShift(t *testing.T) {
+	same := map[string]int{"a": 1, "b": 1}
+	if got := frequencyShift(same, map[string]int{"a": 2, "b": 2}); got != 0 {
+		t.Errorf("same proportions: got %v", got)
+	}
+	if got := frequencyShift(same, map[string]int{"c": 1}); got != 1 {
+		t.Errorf("disjoint: got %v", got)
+	}
+	if got := frequencyShift(map[string]int{"a": 3, "b": 1}, same); got != 0.25 {
+		t.Errorf("partial: got %v", got)
+	}
+}
+
+func TestCompareSkipsNearUniqueColumns(t *testing.T) {
+	left := db.QueryResult{Columns: []string{"id", "kind"}}
+	right := db.QueryResult{Columns: []string{"id", "kind"}}
+	for i := range 40 {
+		left.Rows = append(left.Rows, []string{fmt.Sprintf("a%d", i), "x"})
+		right.Rows = append(right.Rows, []string{fmt.Sprintf("b%d", i), "y"})
+	}
+	diffs := compareColumnStats(left, right)
+	if !diffs[0].NearUnique || len(diffs[0].Flags) != 0 {
+		t.Errorf("expected the id column not to be compared, got %+v", diffs[0])
+	}
+	if diffs[1].NearUnique || diffs[1].Shift != 1 {
+		t.Errorf("expected the kind column to shift, got %+v", diffs[1])
+	}
+}
+
+func TestStatsCKeyComparesPinned(t *testing.T) {
+	m := newTestModel()
+	m.width, m.height = 120, 30
+	m.mode = normalMode
+	m.applyResult(db.QueryResult{Columns: []string{"v"}, Rows: [][]string{{"a"}, {"NULL"}}})
+	m.pinned = m.pinCurrentResult()
+	m.comparePane = 1
+	m.applyResult(db.QueryResult{Columns: []string{"v"}, Rows: [][]string{{"a"}, {"a"}}})
+
+	// d keeps to the active result, so its filters stay reachable.
+	next, cmd := m.updateNormal(runeMsg("d"))
+	rm := runEditCmd(t, next.(model), cmd)
+	if rm.statsSt.scope != statsResult || len(rm.statsSt.stats) != 1 {
+		t.Fatalf("expected the active result's stats, got scope %v", rm.statsSt.scope)
+	}
+
+	next, cmd = rm.updateStats(runeMsg("c"))
+	rm = runEditCmd(t, next.(model), cmd)
+	if rm.statsSt.scope != statsCompare || len(rm.statsSt.diffs) != 1 {
+		t.Fatalf("expected a stats comparison, got scope %v with %d diffs", rm.statsSt.scope, len(rm.statsSt.diffs))
+	}
+	if !strings.Contains(rm.renderWithStatsOverlay(""), "NULL% -50.0") {
+		t.Error("expected the overlay to show the flagged NULL rate shift")
+	}
+	// Profiling and filtering do not apply to a comparison.
+	if _, cmd := rm.updateStats(runeMsg("f")); cmd != nil {
+		t.Error("expected f to be ignored in a comparison")
+	}
+	next, cmd = rm.updateStats(runeMsg("c"))
+	if rm = runEditCmd(t, next.(model), cmd); rm.statsSt.scope != statsResult {
+		t.Errorf("expected c to return to the result stats, got scope %v", rm.statsSt.scope)
+	}
+}
@@ -15,30 +15,81 @@ func (m model) statusHints() string {
 		}
 		return "C-c:cancel"
 	}
+	if m.streamSt.cancel != nil {
+		return "C-c:cancel export"
+	}
+
+	pendingHint := ""
+	if n := len(m.editSt.pending); n > 0 {
+		pendingHint = fmt.Sprintf("W:pending(%d) ", n)
+	}
+	if m.filter.active {
+		pendingHint += "F:clear filter "
+	}
+	if m.pivotBase != nil {
+		pendingHint += "u:ungroup "
+	}
 
 	switch m.mode {
 	case normalMode:
+		if m.yankPending {
+			return "y:row c:cell C:column i:IN list"
+		}
 		if m.pinned != nil {
-			return "c:close Tab:switch h/l:col s:sort j/k:row i:insert q:quit"
+			return "c:close Tab:switch d:stats diff J:join by date h/l:col s:sort j/k:row i:insert q:quit"
 		} else if m.aiSt.enabled {
-			return "c:compare d:stats h/l:col s:sort R:re-exec t:tables i:insert e:export S:snippets P:profiles C-k:AI q:quit"
+			return pendingHint + "c:compare d:stats g:group C:chart T:timeseries Z:UTC h/l:col s:sort E:edit v/V:visual y:yank R:re-exec t:tables i:insert C-o:editor e:export S:snippets P:profiles C-k:AI q:quit"
 		}
-		return "c:compare d:stats h/l:col s:sort R:re-exec t:tables i:insert e:export S:snippets P:profiles q:quit"
+		return pendingHint + "c:compare d:stats g:group C:chart T:timeseries Z:UTC h/l:col s:sort E:edit v/V:visual y:yank R:re-exec t:tables i:insert C-o:editor e:export S:snippets P:profiles q:quit"
 	case insertMode:
 		if m.completion.active {
 			return "Tab/C-n:next C-p:prev Enter:accept Esc:cancel"
 		}
-		return "Tab:complete C-Enter/C-j:exec C-r:search C-l:clear C-p/C-n:hist C-s:save Esc:normal"
+		return "Tab:complete C-Enter/C-j:exec C-g:format C-o/C-x:editor/+exec C-r:search C-l:clear C-p/C-n:hist C-s:save Esc:normal"
 	case sidebarMode:
-		return "j/k:nav Enter:select Esc:close"
+		return "j/k:nav Enter:select r:refresh schema Esc:close"
 	case aiMode:
 		return "Enter:generate Esc:cancel"
 	case exportMode:
+		if m.exportSt.saving {
+			return "Tab:field ←/→:change Enter:save Esc:back"
+		}
 		return "j/k:nav Enter:select Esc:cancel"
 	case detailMode:
-		return "j/k:field n/N:row q/Esc:close"
+		return "j/k:field n/N:row v:view e:edit q/Esc:close"
+	case viewerMode:
+		if m.viewer.searching {
+			return "Enter:search Esc:cancel"
+		}
+		return "j/k:scroll /:search n/N:match Enter:fold -/+:fold all w:wrap m:format a:add column y:copy q/Esc:back"
+	case editMode:
+		return "Enter:stage C-n:NULL Esc:cancel"
+	case visualMode:
+		if m.visual.yank {
+			return "c:CSV t:TSV m:Markdown i:IN list"
+		}
+		return "hjkl:extend v/V:block/line y:yank e:export D:duplicate X:delete Esc:cancel"
+	case pendingMode:
+		return "j/k:nav a:apply d:drop X:discard Esc:close"
+	case pivotMode:
+		return "j/k:nav Enter:group Esc:cancel"
+	case plotMode:
+		if m.plotSt.kind == plotBar {
+			return "j/k:scroll m:next value column q/Esc:close"
+		}
+		return "m:next value column q/Esc:close"
+	case bucketJoinMode:
+		return "j/k:nav Enter:join Esc:cancel"
+	case chartMode:
+		return "H/D/W/M/Q:hour/day/week/month/quarter m:measure a:sum/avg l:line/bar q/Esc:close"
 	case statsMode:
-		return "j/k:nav q/Esc:close"
+		if m.statsSt.scope == statsCompare {
+			return "j/k:nav c/r:result stats q/Esc:close"
+		}
+		if m.pinned != nil {
+			return "j/k:nav 1-9,0:filter top value f:full table s:sample r:result rows c:compare q/Esc:close"
+		}
+		return "j/k:nav 1-9,0:filter top value f:full table s:sample r:result rows q/Esc:close"
 	case historySearchMode:
 		return "Enter:select C-p/C-n:nav Esc:cancel"
 	case snippetMode:
@@ -77,6 +128,10 @@ func (m model) statusPositionInfo() string {
 	}
 
 	if len(m.lastResult.Columns) > 0 && len(m.lastResult.Rows) > 0 {
+		filter := ""
+		if m.filter.active {
+			filter = "filter:" + m.filterLabel() + " "
+		}
 		colName := ""
 		if m.colCursor < len(m.lastResult.Columns) {
 			colName = m.lastResult.Columns[m.colCursor]
@@ -85,9 +140,9 @@ func (m model) statusPositionInfo() string {
 		visCount := visEnd - m.colOffset
 		totalCols := len(m.lastResult.Columns)
 		if visCount < totalCols {
-			return fmt.Sprintf("col:%s [%d/%d] %d/%d", sanitize(colName), m.colCursor+1, totalCols, m.table.Cursor()+1, len(m.lastResult.Rows))
+			return fmt.Sprintf("%scol:%s [%d/%d] %d/%d", filter, sanitize(colName), m.colCursor+1, totalCols, min(m.table.Cursor()+1, m.rowCount()), m.rowCount())
 		}
-		return fmt.Sprintf("col:%s %d/%d", sanitize(colName), m.table.Cursor()+1, len(m.lastResult.Rows))
+		return fmt.Sprintf("%scol:%s %d/%d", filter, sanitize(colName), min(m.table.Cursor()+1, m.rowCount()), m.rowCount())
 	}
 	return ""
 }
@@ -131,7 +186,11 @@ func (m model) renderStatusBar() string {
 
 	center := dbLabelStyle.Render("["+m.statusConnectionLabel()+"]") + m.pathStyle.Render(m.dbPath)
 	middle := msgStyle.Render(sanitize(m.statusText))
-	pos := posStyle.Render(m.statusPositionInfo())
+	posInfo := m.selectionInfo() + m.statusPositionInfo()
+	if m.mode == sidebarMode {
+		posInfo = m.schemaStatus()
+	}
+	pos := posStyle.Render(posInfo)
 	right := hintStyle.Render(m.statusHints())
 
 	leftPart := lipgloss.JoinHorizontal(lipgloss.Left, modeStr, center, middle)
@@ -0,0 +1,167 @@
+package ui
+
+import (
+	"math"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// statsTopN is the number of most frequent values kept per column.
+const statsTopN = 10
+
+// statsPercentiles are the percentiles reported for numeric columns.
+var statsPercentiles = []float64{0.25, 0.5, 0.75, 0.95, 0.99}
+
+// maxPatternLen is the longest value shape reported as a pattern; longer
+// shapes come from free text and say nothing useful.
+const maxPatternLen = 40
+
+// percentileIndex returns the 0-based position of the q-th percentile among
+// n sorted values (nearest rank, like percentile_disc).
+func percentileIndex(q float64, n int) int {
+	return min(max(int(math.Ceil(q*float64(n)))-1, 0), n-1)
+}
+
+// topValues returns the statsTopN most frequent values, most frequent first;
+// ties are broken by value. Shares are relative to rows.
+func topValues(counts map[string]int, rows int) []valueCount {
+	top := make([]valueCount, 0, len(counts))
+	for v, n := range counts {
+		top = append(top, valueCount{Value: v, Count: n})
+	}
+	sort.Slice(top, func(i, j int) bool {
+		if top[i].Count != top[j].Count {
+			return top[i].Count > top[j].Count
+		}
+		return smartCompare(top[i].Value, top[j].Value) < 0
+	})
+	top = top[:min(len(top), statsTopN)]
+	for i := range top {
+		if rows > 0 {
+			top[i].Share = float64(top[i].Count) / float64(rows)
+		}
+	}
+	return top
+}
+
+// summarizeNumbers sets the percentiles, mean and standard deviation of the
+// values of column col that parse as numbers.
+func summarizeNumbers(s *columnStat, rows [][]string, col int) {
+	var vals []float64
+	for _, row := range rows {
+		if col >= len(row) || row[col] == "NULL" {
+			continue
+		}
+		v, err := strconv.ParseFloat(row[col], 64)
+		if err != nil || math.IsNaN(v) || math.IsInf(v, 0) {
+			continue
+		}
+		vals = append(vals, v)
+	}
+	if len(vals) == 0 {
+		return
+	}
+	sort.Float64s(vals)
+	s.Numeric = len(vals)
+	s.Percentiles = make([]string, len(statsPercentiles))
+	for i, q := range statsPercentiles {
+		s.Percentiles[i] = formatAggregate(vals[percentileIndex(q, len(vals))])
+	}
+	var sum float64
+	for _, v := range vals {
+		sum += v
+	}
+	s.Mean = sum / float64(len(vals))
+	var sq float64
+	for _, v := range vals {
+		sq += (v - s.Mean) * (v - s.Mean)
+	}
+	s.Stddev = math.Sqrt(sq / float64(len(vals)))
+}
+
+// summarizeText sets the length stats and the most common shape of the
+// non-NULL values of column col.
+func summarizeText(s *columnStat, rows [][]string, col int) {
+	shapes := make(map[string]int)
+	var n, total int
+	for _, row := range rows {
+		if col >= len(row) || row[col] == "NULL" {
+			continue
+		}
+		// Measure the value itself, not the `""` empty-string marker.
+		v := row[col]
+		if v == `""` {
+			v = ""
+		}
+		l := utf8.RuneCountInString(v)
+		if n == 0 || l < s.LenMin {
+			s.LenMin = l
+		}
+		if n == 0 || l > s.LenMax {
+			s.LenMax = l
+		}
+		total += l
+		n++
+		shapes[valueShape(v)]++
+	}
+	if n == 0 {
+		return
+	}
+	s.LenAvg = float64(total) / float64(n)
+
+	best, bestN := "", 0
+	for shape, c := range shapes {
+		if c > bestN || (c == bestN && shape < best) {
+			best, bestN = shape, c
+		}
+	}
+	if utf8.RuneCountInString(best) <= maxPatternLen {
+		s.Pattern = best
+		s.PatternRate = float64(bestN) / float64(n)
+	}
+}
+
+// valueShape abstracts a value into a regular expression of its character
+// classes, e.g. "555-1234" → `\d{3}-\d{4}`.
+func valueShape(v string) string {
+	var b strings.Builder
+	prev, run := "", 0
+	flush := func() {
+		if run == 0 {
+			return
+		}
+		b.WriteString(prev)
+		if run > 1 {
+			b.WriteString("{" + strconv.Itoa(run) + "}")
+		}
+	}
+	for _, r := range v {
+		var class string
+		switch {
+		case r >= '0' && r <= '9':
+			class = `\d`
+		case r >= 'a' && r <= 'z':
+			class = "[a-z]"
+		case r >= 'A' && r <= 'Z':
+			class = "[A-Z]"
+		case unicode.IsLetter(r):
+			class = `\p{L}`
+		case unicode.IsSpace(r):
+			class = `\s`
+		default:
+			class = regexp.QuoteMeta(string(r))
+		}
+		if class == prev {
+			run++
+			continue
+		}
+		flush()
+		prev, run = class, 1
+	}
+	flush()
+	return b.String()
+}
@@ -0,0 +1,92 @@
+package ui
+
+import (
+	"math"
+	"slices"
+	"strconv"
+	"testing"
+
+	"github.com/kwrkb/asql/internal/db"
+)
+
+func TestValueShape(t *testing.T) {
+	for in, want := range map[string]string{
+		"555-1234":  `\d{3}-\d{4}`,
+		"Alice":     `[A-Z][a-z]{4}`,
+		"a.b c":     `[a-z]\.[a-z]\s[a-z]`,
+		"東京1":       `\p{L}{2}\d`,
+		"":          "",
+		"(03)1234x": `\(\d{2}\)\d{4}[a-z]`,
+	} {
+		if got := valueShape(in); got != want {
+			t.Errorf("valueShape(%q) = %q, want %q", in, got, want)
+		}
+	}
+}
+
+func TestTopValues(t *testing.T) {
+	counts := map[string]int{"b": 2, "a": 2, "c": 5}
+	for i := range 20 {
+		counts[string(rune('d'+i))] = 1
+	}
+	got := topValues(counts, 30)
+	if len(got) != statsTopN {
+		t.Fatalf("len = %d, want %d", len(got), statsTopN)
+	}
+	if want := []valueCount{{"c", 5, 5.0 / 30}, {"a", 2, 2.0 / 30}, {"b", 2, 2.0 / 30}}; !slices.Equal(got[:3], want) {
+		t.Errorf("top = %v, want %v", got[:3], want)
+	}
+}
+
+func TestComputeColumnStats_Summaries(t *testing.T) {
+	var rows [][]string
+	for i := 1; i <= 100; i++ {
+		phone := "555-0100"
+		if i%10 == 0 {
+			phone = "n/a"
+		}
+		rows = append(rows, []string{strconv.Itoa(i), phone})
+	}
+	rows = append(rows, []string{"NULL", "NULL"})
+	stats := computeColumnStats(db.QueryResult{
+		Columns:     []string{"n", "phone"},
+		ColumnTypes: []string{"INTEGER", "TEXT"},
+		Rows:        rows,
+	})
+
+	n := stats[0]
+	if want := []string{"25", "50", "75", "95", "99"}; !slices.Equal(n.Percentiles, want) {
+		t.Errorf("percentiles = %v, want %v", n.Percentiles, want)
+	}
+	if n.Numeric != 100 || n.Mean != 50.5 || math.Abs(n.Stddev-28.866) > 0.001 {
+		t.Errorf("numeric = %d mean = %v stddev = %v", n.Numeric, n.Mean, n.Stddev)
+	}
+	if n.LenMax != 0 || n.Pattern != "" {
+		t.Errorf("numeric column got text stats: %+v", n)
+	}
+
+	phone := stats[1]
+	if phone.Pattern != `\d{3}-\d{4}` || phone.PatternRate != 0.9 {
+		t.Errorf("pattern = %q %v", phone.Pattern, phone.PatternRate)
+	}
+	if phone.LenMin != 3 || phone.LenMax != 8 || phone.LenAvg != 7.5 {
+		t.Errorf("lengths = %d-%d avg %v", phone.LenMin, phone.LenMax, phone.LenAvg)
+	}
+	if len(phone.TopValues) != 2 || phone.TopValues[0].Value != "555-0100" || phone.TopValues[0].Count != 90 {
+		t.Errorf("top values = %v", phone.TopValues)
+	}
+	if phone.Percentiles != nil || phone.Numeric != 0 {
+		t.Errorf("text column got numeric stats: %+v", phone)
+	}
+}
+
+func TestSummarizeText_EmptyStringMarker(t *testing.T) {
+	var s columnStat
+	summarizeText(&s, [][]string{{`""`}, {`""`}, {"ab"}, {"NULL"}}, 0)
+	if s.LenMin != 0 || s.LenMax != 2 || s.LenAvg != 2.0/3 {
+		t.Errorf("lengths = %d-%d avg %v", s.LenMin, s.LenMax, s.LenAvg)
+	}
+	if s.Pattern != "" || s.PatternRate != 2.0/3 {
+		t.Errorf("pattern = %q %v, want the empty shape", s.Pattern, s.PatternRate)
+	}
+}
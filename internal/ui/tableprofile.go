@@ -0,0 +1,425 @@
+package ui
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+
+	"github.com/kwrkb/asql/internal/db"
+	"github.com/kwrkb/asql/internal/db/dbutil"
+)
+
+// statsScope tells what the STATS overlay was computed over.
+type statsScope int
+
+const (
+	statsResult  statsScope = iota // the fetched result rows, in memory
+	statsSampled                   // a random sample of the table, in the database
+	statsFull                      // the whole table, in the database
+	statsCompare                   // the pinned and active results, side by side (see statsdiff.go)
+)
+
+const (
+	// profileSamplePercent is the share of the table a sampled profile reads.
+	profileSamplePercent = 1
+	// profileTimeout bounds each column's queries; a full-table profile of a
+	// large table can take much longer than an interactive query.
+	profileTimeout = time.Minute
+)
+
+// profileColumnsMsg carries the columns of the profiled table.
+type profileColumnsMsg struct {
+	ctx  context.Context
+	seq  uint64
+	cols []db.ColumnInfo
+	err  error
+}
+
+// profileColumnMsg carries the database profile of one column.
+type profileColumnMsg struct {
+	ctx   context.Context // the profile's context, passed on to the next column
+	seq   uint64
+	idx   int
+	stat  columnStat
+	total int64 // rows scanned (the table, or the sample)
+	err   error
+}
+
+// startProfile pushes the column profile of the result's table down to the
+// database, one column at a time, either over the whole table or over a
+// random sample of it. The table's columns are read first, so that
+// aliases and expressions of the result are skipped rather than queried.
+func (m model) startProfile(scope statsScope) (tea.Model, tea.Cmd) {
+	adapter := m.activeDB()
+	tableName := dbutil.SingleTableName(m.lastQuery)
+	if adapter == nil || tableName == "" {
+		m.setStatus("Table profiling needs a result from a single-table SELECT", true)
+		return m, nil
+	}
+	if m.statsSt.cancel != nil {
+		m.statsSt.cancel()
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	m.statsSt.seq++
+	m.statsSt.scope = scope
+	m.statsSt.table = tableName
+	m.statsSt.total = -1
+	m.statsSt.cancel = cancel
+	m.statsSt.loading = false
+	m.statsSt.seed = time.Now().UnixNano() % 1_000_000
+	m.statsSt.fullScan = scope == statsSampled && adapter.Type() != "postgres"
+	m.statsSt.columns = make([]string, len(m.lastResult.Columns))
+	m.statsSt.stats = make([]columnStat, len(m.lastResult.Columns))
+	for i, c := range m.lastResult.Columns {
+		m.statsSt.stats[i] = columnStat{Name: c, Pending: true}
+		if i < len(m.lastResult.ColumnTypes) {
+			m.statsSt.stats[i].Type = m.lastResult.ColumnTypes[i]
+		}
+		if derivedColumn(m.lastResult, i) {
+			// Computed by asql: there is nothing to query in the table.
+			m.statsSt.stats[i].Pending = false
+			m.statsSt.stats[i].Err = "derived column; not in the table"
+		}
+	}
+	m.setStatus(fmt.Sprintf("Reading the columns of %s...", sanitize(tableName)), false)
+	seq := m.statsSt.seq
+	return m, func() tea.Msg {
+		qctx, cancel := context.WithTimeout(ctx, queryTimeout)
+		defer cancel()
+		cols, err := adapter.ColumnDetails(qctx, tableName)
+		return profileColumnsMsg{ctx: ctx, seq: seq, cols: cols, err: err}
+	}
+}
+
+// handleProfileColumns matches the result's columns with the table's and
+// starts profiling those found. Result columns are matched by exact name
+// first, then ignoring case, as MySQL and SQLite names are.
+func (m *model) handleProfileColumns(msg profileColumnsMsg) tea.Cmd {
+	if msg.seq != m.statsSt.seq {
+		return nil
+	}
+	if msg.err == nil && len(msg.cols) == 0 {
+		msg.err = fmt.Errorf("no columns found for %s", m.statsSt.table)
+	}
+	if msg.err != nil {
+		m.statsSt.cancel = nil
+		for i := range m.statsSt.stats {
+			m.statsSt.stats[i].Pending = false
+		}
+		m.setStatus("Table profiling failed: "+msg.err.Error(), true)
+		return nil
+	}
+	for i := range m.statsSt.stats {
+		s := &m.statsSt.stats[i]
+		if !s.Pending {
+			continue
+		}
+		col, ok := tableColumn(msg.cols, s.Name)
+		if !ok {
+			s.Pending = false
+			s.Err = "not a column of " + m.statsSt.table
+			continue
+		}
+		m.statsSt.columns[i] = col.Name
+		if s.Type == "" {
+			s.Type = col.Type
+		}
+	}
+	return m.profileNextColumn(msg.ctx, 0)
+}
+
+// tableColumn finds the column of cols named name.
+func tableColumn(cols []db.ColumnInfo, name string) (db.ColumnInfo, bool) {
+	for _, c := range cols {
+		if c.Name == name {
+			return c, true
+		}
+	}
+	for _, c := range cols {
+		if strings.EqualFold(c.Name, name) {
+			return c, true
+		}
+	}
+	return db.ColumnInfo{}, false
+}
+
+// profileNextColumn starts profiling the first pending column from idx on,
+// or reports the profile done when none is left.
+func (m *model) profileNextColumn(ctx context.Context, idx int) tea.Cmd {
+	for idx < len(m.statsSt.stats) && !m.statsSt.stats[idx].Pending {
+		idx++
+	}
+	if idx >= len(m.statsSt.stats) {
+		m.statsSt.cancel = nil
+		if m.mode == statsMode {
+			m.setStatus(fmt.Sprintf("Profiled %d column(s) of %s", m.profiledColumns(), sanitize(m.statsSt.table)), false)
+		}
+		return nil
+	}
+	if m.mode == statsMode {
+		m.setStatus(m.profileProgress(idx), false)
+	}
+	return m.profileColumnCmd(ctx, idx)
+}
+
+// profiledColumns counts the columns profiled without an error.
+func (m *model) profiledColumns() int {
+	n := 0
+	for _, s := range m.statsSt.stats {
+		if !s.Pending && s.Err == "" {
+			n++
+		}
+	}
+	return n
+}
+
+// profileColumnCmd profiles column idx of statsSt.stats.
+func (m *model) profileColumnCmd(ctx context.Context, idx int) tea.Cmd {
+	adapter := m.activeDB()
+	seq := m.statsSt.seq
+	p := columnProfiler{
+		dbType:  adapter.Type(),
+		quote:   adapter.QuoteIdentifier,
+		table:   m.statsSt.table,
+		column:  m.statsSt.columns[idx],
+		colType: m.statsSt.stats[idx].Type,
+		sampled: m.statsSt.scope == statsSampled,
+		seed:    m.statsSt.seed,
+	}
+	return func() tea.Msg {
+		qctx, cancel := context.WithTimeout(ctx, profileTimeout)
+		defer cancel()
+		stat, total, err := p.run(qctx, adapter)
+		return profileColumnMsg{ctx: ctx, seq: seq, idx: idx, stat: stat, total: total, err: err}
+	}
+}
+
+// handleProfileColumn stores one column's profile and starts the next.
+func (m *model) handleProfileColumn(msg profileColumnMsg) tea.Cmd {
+	if msg.seq != m.statsSt.seq || msg.idx >= len(m.statsSt.stats) {
+		return nil
+	}
+	s := &m.statsSt.stats[msg.idx]
+	if msg.err != nil {
+		s.Pending = false
+		s.Err = msg.err.Error()
+	} else {
+		msg.stat.Name, msg.stat.Type = s.Name, s.Type
+		*s = msg.stat
+		m.statsSt.total = max(m.statsSt.total, msg.total)
+	}
+	return m.profileNextColumn(msg.ctx, msg.idx+1)
+}
+
+// cancelProfile stops a running table profile.
+func (m *model) cancelProfile() {
+	if m.statsSt.cancel != nil {
+		m.statsSt.cancel()
+		m.statsSt.cancel = nil
+		m.statsSt.seq++
+	}
+}
+
+func (m *model) profileProgress(done int) string {
+	return fmt.Sprintf("Profiling %s... %d/%d column(s)", sanitize(m.statsSt.table), done, len(m.statsSt.stats))
+}
+
+// columnProfiler generates and runs the profile queries for one column.
+type columnProfiler struct {
+	dbType  string
+	quote   func(string) string
+	table   string
+	column  string
+	colType string
+	sampled bool
+	seed    int64 // REPEATABLE seed of a Postgres sample
+}
+
+// pgNumericTypes are the short type names PostgreSQL reports for numbers,
+// which detectNumericColumn does not recognise.
+var pgNumericTypes = map[string]bool{
+	"int2": true, "int4": true, "int8": true, "float4": true, "float8": true,
+}
+
+// sortable reports whether percentiles make sense for the column.
+func (p columnProfiler) sortable() bool {
+	return detectNumericColumn(p.colType) || detectDateColumn(p.colType) ||
+		pgNumericTypes[strings.ToLower(p.colType)]
+}
+
+// expr is the column expression aggregated. Postgres cannot compare every
+// type (json, boolean MIN/MAX, ...), so anything that is not a number or a
+// date is profiled as text there.
+func (p columnProfiler) expr() string {
+	col := p.quote(p.column)
+	if p.dbType == "postgres" && !p.sortable() {
+		return col + "::text"
+	}
+	return col
+}
+
+// from returns the FROM clause with a WHERE clause joining conds, sampling
+// the table when requested. Postgres reads the pages of a TABLESAMPLE,
+// whose seed makes every query of the profile see the same sample. MySQL
+// and SQLite have no such thing: a random filter still reads the whole
+// table and draws a new sample per query.
+func (p columnProfiler) from(conds ...string) string {
+	from := " FROM " + quoteTableName(p.table, p.quote)
+	if p.sampled {
+		switch p.dbType {
+		case "postgres":
+			from += fmt.Sprintf(" TABLESAMPLE SYSTEM (%d) REPEATABLE (%d)", profileSamplePercent, p.seed)
+		case "mysql":
+			conds = append([]string{fmt.Sprintf("RAND() < %g", float64(profileSamplePercent)/100)}, conds...)
+		default:
+			conds = append([]string{fmt.Sprintf("abs(random() %% 100) < %d", profileSamplePercent)}, conds...)
+		}
+	}
+	if len(conds) > 0 {
+		from += " WHERE " + strings.Join(conds, " AND ")
+	}
+	return from
+}
+
+// aggregateQuery counts rows, non-NULL and distinct values and finds the
+// extremes. On Postgres it also computes the percentiles of sortable
+// columns.
+func (p columnProfiler) aggregateQuery() string {
+	e := p.expr()
+	sel := []string{"COUNT(*)", "COUNT(" + e + ")", "COUNT(DISTINCT " + e + ")", "MIN(" + e + ")", "MAX(" + e + ")"}
+	if p.dbType == "postgres" && p.sortable() {
+		for _, q := range statsPercentiles {
+			sel = append(sel, fmt.Sprintf("percentile_disc(%g) WITHIN GROUP (ORDER BY %s)", q, e))
+		}
+	}
+	return "SELECT " + strings.Join(sel, ", ") + p.from()
+}
+
+// percentileQuery finds the values at the 0-based positions idx among the
+// sorted non-NULL values in one sort, for databases without percentile
+// functions. It returns each position (1-based) with its value.
+func (p columnProfiler) percentileQuery(idx []int) string {
+	e := p.expr()
+	ranks := make([]string, len(idx))
+	for i, n := range idx {
+		ranks[i] = strconv.Itoa(n + 1)
+	}
+	return fmt.Sprintf("SELECT rn, v FROM (SELECT %s AS v, ROW_NUMBER() OVER (ORDER BY %s) AS rn%s) ranked WHERE rn IN (%s) ORDER BY rn",
+		e, e, p.from(e+" IS NOT NULL"), strings.Join(ranks, ", "))
+}
+
+// topValuesQuery counts the most frequent non-NULL values.
+func (p columnProfiler) topValuesQuery() string {
+	e := p.expr()
+	return fmt.Sprintf("SELECT %s, COUNT(*)%s GROUP BY %s ORDER BY 2 DESC, 1 LIMIT %d", e, p.from(e+" IS NOT NULL"), e, statsTopN)
+}
+
+// run profiles the column and returns its stats and the number of rows
+// scanned.
+func (p columnProfiler) run(ctx context.Context, adapter db.DBAdapter) (columnStat, int64, error) {
+	res, err := adapter.Query(ctx, p.aggregateQuery())
+	if err != nil {
+		return columnStat{}, 0, err
+	}
+	if len(res.Rows) != 1 || len(res.Rows[0]) < 5 {
+		return columnStat{}, 0, fmt.Errorf("unexpected profile result")
+	}
+	row := res.Rows[0]
+	var counts [3]int64
+	for i := range counts {
+		if counts[i], err = strconv.ParseInt(row[i], 10, 64); err != nil {
+			return columnStat{}, 0, fmt.Errorf("parsing count %q: %w", row[i], err)
+		}
+	}
+	total, nonNull := counts[0], counts[1]
+	s := columnStat{
+		NullCnt:  int(total - nonNull),
+		Distinct: int(counts[2]),
+	}
+	if total > 0 {
+		s.NullRate = float64(total-nonNull) / float64(total)
+	}
+	if nonNull > 0 {
+		s.Min, s.Max = row[3], row[4]
+	}
+
+	switch {
+	case len(row) > 5:
+		s.Percentiles = row[5:]
+	case p.sortable() && !p.sampled && nonNull > 0:
+		// Percentiles of a random sample would come from a different
+		// sample than the counts, so only exact profiles get them here.
+		idx := make([]int, len(statsPercentiles))
+		for i, q := range statsPercentiles {
+			idx[i] = percentileIndex(q, int(nonNull))
+		}
+		res, err := adapter.Query(ctx, p.percentileQuery(idx))
+		if err != nil {
+			if ctx.Err() != nil {
+				return s, total, err
+			}
+			// Without window functions (MySQL before 8.0), go without.
+			break
+		}
+		byRank := make(map[string]string, len(res.Rows))
+		for _, r := range res.Rows {
+			if len(r) == 2 {
+				byRank[r[0]] = r[1]
+			}
+		}
+		for _, i := range idx {
+			s.Percentiles = append(s.Percentiles, byRank[strconv.Itoa(i+1)])
+		}
+	}
+
+	if nonNull > 0 {
+		res, err := adapter.Query(ctx, p.topValuesQuery())
+		if err != nil {
+			return s, total, err
+		}
+		for _, r := range res.Rows {
+			if len(r) != 2 {
+				continue
+			}
+			n, err := strconv.Atoi(r[1])
+			if err != nil {
+				return s, total, fmt.Errorf("parsing count %q: %w", r[1], err)
+			}
+			vc := valueCount{Value: r[0], Count: n}
+			if total > 0 {
+				vc.Share = float64(n) / float64(total)
+			}
+			s.TopValues = append(s.TopValues, vc)
+		}
+	}
+	return s, total, nil
+}
+
+// statsTitle describes what the STATS overlay was computed over, so exact,
+// sampled and full-table numbers are never confused.
+func (m model) statsTitle() string {
+	switch m.statsSt.scope {
+	case statsSampled, statsFull:
+		kind := "full table"
+		if m.statsSt.scope == statsSampled {
+			kind = fmt.Sprintf("sampled ~%d%% of", profileSamplePercent)
+			if m.statsSt.fullScan {
+				kind = fmt.Sprintf("sampled ~%d%% (full scan) of", profileSamplePercent)
+			}
+		}
+		rows := "…"
+		if m.statsSt.total >= 0 {
+			rows = strconv.FormatInt(m.statsSt.total, 10)
+		}
+		return fmt.Sprintf("Column Statistics [%s %s] (%s rows)", kind, sanitize(m.statsSt.table), rows)
+	}
+	rowCount := len(m.lastResult.Rows)
+	if m.lastResult.Truncated {
+		return fmt.Sprintf("Column Statistics [sampled: first %d rows]", rowCount)
+	}
+	return fmt.Sprintf("Column Statistics [exact] (%d rows)", rowCount)
+}
@@ -0,0 +1,162 @@
+package ui
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/kwrkb/asql/internal/db"
+)
+
+func TestProfileFullTable(t *testing.T) {
+	m, adapter := newEditModel(t)
+	ctx := context.Background()
+	for i := 3; i <= 10; i++ {
+		q := fmt.Sprintf("INSERT INTO items VALUES (%d, 'ok', NULL)", i)
+		if _, err := adapter.Query(ctx, q); err != nil {
+			t.Fatal(err)
+		}
+	}
+	// The shown result is stale (2 rows); the profile reads the table.
+	m.mode = statsMode
+
+	next, cmd := m.updateStats(runeMsg("f"))
+	rm := next.(model)
+	if rm.statsSt.scope != statsFull || !rm.statsSt.stats[0].Pending {
+		t.Fatalf("expected pending full-table stats, got %+v", rm.statsSt)
+	}
+	rm = runUntilIdle(t, rm, cmd)
+
+	id, flag, note := rm.statsSt.stats[0], rm.statsSt.stats[1], rm.statsSt.stats[2]
+	if rm.statsSt.total != 10 || id.Pending || id.Err != "" {
+		t.Fatalf("total = %d, id = %+v", rm.statsSt.total, id)
+	}
+	if id.Distinct != 10 || id.Min != "1" || id.Max != "10" {
+		t.Errorf("id stats = %+v", id)
+	}
+	if want := []string{"3", "5", "8", "10", "10"}; !slices.Equal(id.Percentiles, want) {
+		t.Errorf("id percentiles = %v, want %v", id.Percentiles, want)
+	}
+	if flag.Distinct != 2 || flag.Min != "bad" || flag.Max != "ok" || flag.Percentiles != nil {
+		t.Errorf("flag stats = %+v", flag)
+	}
+	if want := []valueCount{{"ok", 9, 0.9}, {"bad", 1, 0.1}}; !slices.Equal(flag.TopValues, want) {
+		t.Errorf("flag top values = %v, want %v", flag.TopValues, want)
+	}
+	if note.NullCnt != 9 || note.NullRate != 0.9 {
+		t.Errorf("note stats = %+v", note)
+	}
+	if got := rm.statsTitle(); got != "Column Statistics [full table items] (10 rows)" {
+		t.Errorf("title = %q", got)
+	}
+	if !strings.HasPrefix(rm.statusText, "Profiled 3 column(s)") {
+		t.Errorf("status = %q", rm.statusText)
+	}
+
+	next, cmd = rm.updateStats(runeMsg("r"))
+	rm = runUntilIdle(t, next.(model), cmd)
+	if got := rm.statsTitle(); got != "Column Statistics [exact] (2 rows)" {
+		t.Errorf("title after r = %q", got)
+	}
+}
+
+func TestProfileSkipsColumnsNotInTable(t *testing.T) {
+	m, _ := newEditModel(t)
+	m.lastQuery = "SELECT ID, flag AS kind, length(note) FROM items"
+	m.applyResult(db.QueryResult{
+		Columns: []string{"ID", "kind", "length(note)"},
+		Rows:    [][]string{{"1", "bad", "1"}},
+	})
+	m.mode = statsMode
+
+	next, cmd := m.updateStats(runeMsg("s"))
+	rm := runUntilIdle(t, next.(model), cmd)
+	id, kind, length := rm.statsSt.stats[0], rm.statsSt.stats[1], rm.statsSt.stats[2]
+	if id.Err != "" || id.Pending {
+		t.Errorf("expected ID to match the id column, got %+v", id)
+	}
+	for _, s := range []columnStat{kind, length} {
+		if s.Err != "not a column of items" {
+			t.Errorf("expected %s to be skipped, got %+v", s.Name, s)
+		}
+	}
+	if !strings.HasPrefix(rm.statusText, "Profiled 1 column(s)") {
+		t.Errorf("status = %q", rm.statusText)
+	}
+	if got := rm.statsTitle(); !strings.HasPrefix(got, "Column Statistics [sampled ~1% (full scan) of items]") {
+		t.Errorf("title = %q", got)
+	}
+}
+
+func TestProfileCancelDiscardsResults(t *testing.T) {
+	m, _ := newEditModel(t)
+	m.mode = statsMode
+	next, cmd := m.updateStats(runeMsg("s"))
+	rm := next.(model)
+	msg := cmd()
+	next, _ = rm.updateStats(runeMsg("q"))
+	rm = next.(model)
+	next, cmd = rm.Update(msg)
+	rm = next.(model)
+	if cmd != nil || !rm.statsSt.stats[0].Pending {
+		t.Error("expected a cancelled profile to ignore late results")
+	}
+}
+
+func TestProfileNeedsSingleTable(t *testing.T) {
+	m, _ := newEditModel(t)
+	m.lastQuery = "SELECT 1"
+	next, cmd := m.updateStats(runeMsg("f"))
+	if cmd != nil || !next.(model).statusError {
+		t.Error("expected table profiling to be refused")
+	}
+}
+
+func TestColumnProfilerQueries(t *testing.T) {
+	quote := func(s string) string { return `"` + s + `"` }
+	tests := []struct {
+		name string
+		p    columnProfiler
+		want string
+	}{
+		{
+			"postgres sampled numeric",
+			columnProfiler{dbType: "postgres", quote: quote, table: "t", column: "n", colType: "int4", sampled: true, seed: 42},
+			`SELECT COUNT(*), COUNT("n"), COUNT(DISTINCT "n"), MIN("n"), MAX("n"), ` +
+				`percentile_disc(0.25) WITHIN GROUP (ORDER BY "n"), percentile_disc(0.5) WITHIN GROUP (ORDER BY "n"), ` +
+				`percentile_disc(0.75) WITHIN GROUP (ORDER BY "n"), percentile_disc(0.95) WITHIN GROUP (ORDER BY "n"), ` +
+				`percentile_disc(0.99) WITHIN GROUP (ORDER BY "n") FROM "t" TABLESAMPLE SYSTEM (1) REPEATABLE (42)`,
+		},
+		{
+			"postgres text",
+			columnProfiler{dbType: "postgres", quote: quote, table: "t", column: "j", colType: "jsonb"},
+			`SELECT COUNT(*), COUNT("j"::text), COUNT(DISTINCT "j"::text), MIN("j"::text), MAX("j"::text) FROM "t"`,
+		},
+		{
+			"mysql sampled",
+			columnProfiler{dbType: "mysql", quote: quote, table: "t", column: "c", colType: "VARCHAR", sampled: true},
+			`SELECT COUNT(*), COUNT("c"), COUNT(DISTINCT "c"), MIN("c"), MAX("c") FROM "t" WHERE RAND() < 0.01`,
+		},
+		{
+			"sqlite sampled",
+			columnProfiler{dbType: "sqlite", quote: quote, table: "t", column: "c", sampled: true},
+			`SELECT COUNT(*), COUNT("c"), COUNT(DISTINCT "c"), MIN("c"), MAX("c") FROM "t" WHERE abs(random() % 100) < 1`,
+		},
+	}
+	for _, tt := range tests {
+		if got := tt.p.aggregateQuery(); got != tt.want {
+			t.Errorf("%s:\n got %s\nwant %s", tt.name, got, tt.want)
+		}
+	}
+
+	p := columnProfiler{dbType: "sqlite", quote: quote, table: "t", column: "n", colType: "INTEGER"}
+	if got, want := p.percentileQuery([]int{2, 4}), `SELECT rn, v FROM (SELECT "n" AS v, ROW_NUMBER() OVER (ORDER BY "n") AS rn FROM "t" WHERE "n" IS NOT NULL) ranked WHERE rn IN (3, 5) ORDER BY rn`; got != want {
+		t.Errorf("percentileQuery = %s", got)
+	}
+	p.sampled = true
+	if got, want := p.topValuesQuery(), `SELECT "n", COUNT(*) FROM "t" WHERE abs(random() % 100) < 1 AND "n" IS NOT NULL GROUP BY "n" ORDER BY 2 DESC, 1 LIMIT 10`; got != want {
+		t.Errorf("topValuesQuery = %s", got)
+	}
+}
@@ -0,0 +1,297 @@
+package ui
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+)
+
+// maxChartBuckets caps the buckets of a time series; finer granularities
+// over long spans have to be chosen coarser.
+const maxChartBuckets = 10_000
+
+// chartGranularities maps the CHART mode keys to bucket sizes.
+var chartGranularities = map[string]timeGranularity{
+	"H": granHour,
+	"D": granDay,
+	"W": granWeek,
+	"M": granMonth,
+	"Q": granQuarter,
+}
+
+func granularityName(gran timeGranularity) string {
+	switch gran {
+	case granHour:
+		return "hour"
+	case granWeek:
+		return "week"
+	case granMonth:
+		return "month"
+	case granQuarter:
+		return "quarter"
+	case granYear:
+		return "year"
+	default:
+		return "day"
+	}
+}
+
+// timeSeries buckets the rows by the date in column col. Each bucket's
+// value is its row count, or the sum (average when average is set) of the
+// numbers in column measure unless it is -1. Every bucket between the first
+// and the last date is present; empty ones are missing and reported as
+// gaps.
+func timeSeries(rows [][]string, col, measure int, gran timeGranularity, average bool) ([]chartPoint, []string, error) {
+	type bucket struct {
+		rows, nums int
+		sum        float64
+	}
+	buckets := make(map[string]*bucket)
+	var first, last time.Time
+	seen := false
+	for _, row := range rows {
+		if col >= len(row) || row[col] == "NULL" {
+			continue
+		}
+		t, ok := parseDate(row[col])
+		if !ok {
+			continue
+		}
+		t = truncateTime(t, gran)
+		if !seen || t.Before(first) {
+			first = t
+		}
+		if !seen || t.After(last) {
+			last = t
+		}
+		seen = true
+		key := bucketKey(t, gran)
+		b := buckets[key]
+		if b == nil {
+			b = &bucket{}
+			buckets[key] = b
+		}
+		b.rows++
+		if measure >= 0 && measure < len(row) {
+			if v, err := strconv.ParseFloat(row[measure], 64); err == nil && !math.IsNaN(v) && !math.IsInf(v, 0) {
+				b.sum += v
+				b.nums++
+			}
+		}
+	}
+	if !seen {
+		return nil, nil, fmt.Errorf("no dates in the column")
+	}
+
+	var points []chartPoint
+	var gaps []string
+	for t := first; !t.After(last); t = nextBucket(t, gran) {
+		if len(points) >= maxChartBuckets {
+			return nil, nil, fmt.Errorf("more than %d buckets by %s; pick a coarser granularity", maxChartBuckets, granularityName(gran))
+		}
+		key := bucketKey(t, gran)
+		p := chartPoint{Label: key}
+		b := buckets[key]
+		switch {
+		case b == nil:
+			p.Missing = true
+			gaps = append(gaps, key)
+		case measure < 0:
+			p.Value = float64(b.rows)
+		case average && b.nums > 0:
+			p.Value = b.sum / float64(b.nums)
+		default:
+			p.Value = b.sum
+		}
+		points = append(points, p)
+	}
+	return points, gaps, nil
+}
+
+// startChart opens the time-series chart of the date column under the
+// cursor.
+func (m model) startChart() (tea.Model, tea.Cmd) {
+	if len(m.lastResult.Columns) == 0 || m.rowCount() == 0 {
+		m.setStatus("No rows to chart", true)
+		return m, nil
+	}
+	col := m.colCursor
+	rows := m.viewRows()
+	if !(col < len(m.lastResult.ColumnTypes) && detectDateColumn(m.lastResult.ColumnTypes[col])) && !looksLikeDate(rows, col) {
+		m.setStatus(fmt.Sprintf("%s is not a date column", sanitize(m.lastResult.Columns[col])), true)
+		return m, nil
+	}
+	var measures []int
+	for i := range m.lastResult.Columns {
+		if i != col && ((i < len(m.lastResult.ColumnTypes) && detectNumericColumn(m.lastResult.ColumnTypes[i])) || looksLikeNumeric(rows, i)) {
+			measures = append(measures, i)
+		}
+	}
+
+	// Start from the sparkline's granularity, using quarters for long spans.
+	gran := granDay
+	var times []time.Time
+	for _, row := range rows {
+		if col < len(row) {
+			if t, ok := parseDate(row[col]); ok {
+				times = append(times, t)
+			}
+		}
+	}
+	if len(times) > 0 {
+		lo, hi := times[0], times[0]
+		for _, t := range times {
+			if t.Before(lo) {
+				lo = t
+			}
+			if t.After(hi) {
+				hi = t
+			}
+		}
+		if gran, _ = chooseGranularity(lo, hi); gran == granYear {
+			gran = granQuarter
+		}
+	}
+
+	m.chartSt = chartState{col: col, gran: gran, measure: -1, measures: measures}
+	m.mode = chartMode
+	m.refreshChart()
+	return m, nil
+}
+
+// refreshChart recomputes the series after a setting changed.
+func (m *model) refreshChart() {
+	c := &m.chartSt
+	c.points, c.gaps, c.err = nil, nil, ""
+	points, gaps, err := timeSeries(m.viewRows(), c.col, c.measure, c.gran, c.average)
+	if err != nil {
+		c.err = err.Error()
+		m.setStatus(c.err, true)
+		return
+	}
+	c.points, c.gaps = points, gaps
+	status := fmt.Sprintf("%s: %d bucket(s), %d gap(s)", m.chartTitle(), len(points), len(gaps))
+	if m.lastResult.Truncated {
+		m.setStatus(status+" — "+m.chartTruncatedNotice(), true)
+		return
+	}
+	m.setStatus(status, false)
+}
+
+// chartTruncatedNotice warns that the series only covers the fetched rows.
+func (m model) chartTruncatedNotice() string {
+	return fmt.Sprintf("result truncated at %d rows: the series covers only those", len(m.lastResult.Rows))
+}
+
+// chartTitle describes the series, e.g. "sum(amount) by day of created_at".
+func (m model) chartTitle() string {
+	c := m.chartSt
+	what := "rows"
+	if c.measure >= 0 {
+		agg := "sum"
+		if c.average {
+			agg = "avg"
+		}
+		what = fmt.Sprintf("%s(%s)", agg, sanitize(m.lastResult.Columns[c.measure]))
+	}
+	return fmt.Sprintf("%s by %s of %s", what, granularityName(c.gran), sanitize(m.lastResult.Columns[c.col]))
+}
+
+func (m model) updateChart(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	switch msg.Type {
+	case tea.KeyEsc:
+		m.mode = normalMode
+		m.setStatus("Normal mode", false)
+	case tea.KeyRunes:
+		if msg.Alt {
+			break
+		}
+		key := string(msg.Runes)
+		if gran, ok := chartGranularities[key]; ok {
+			m.chartSt.gran = gran
+			m.refreshChart()
+			break
+		}
+		switch key {
+		case "q":
+			m.mode = normalMode
+			m.setStatus("Normal mode", false)
+		case "m":
+			// Cycle: row count, then each numeric column.
+			c := &m.chartSt
+			next := -1
+			for i, col := range c.measures {
+				if col == c.measure && i+1 < len(c.measures) {
+					next = c.measures[i+1]
+				}
+			}
+			if c.measure < 0 && len(c.measures) > 0 {
+				next = c.measures[0]
+			}
+			c.measure = next
+			m.refreshChart()
+		case "a":
+			if m.chartSt.measure < 0 {
+				m.setStatus("Pick a measure column with m first", true)
+				break
+			}
+			m.chartSt.average = !m.chartSt.average
+			m.refreshChart()
+		case "l":
+			m.chartSt.line = !m.chartSt.line
+		}
+	}
+	return m, nil
+}
+
+func (m model) renderWithChartOverlay(background string) string {
+	modalWidth := calcModalWidth(m.width, m.width)
+	contentWidth := max(modalWidth-6, 10)
+	c := m.chartSt
+
+	var b strings.Builder
+	b.WriteString(lipgloss.NewStyle().Bold(true).Foreground(accentColor).Render(truncateRunes(m.chartTitle(), contentWidth)))
+	b.WriteByte('\n')
+
+	muted := lipgloss.NewStyle().Foreground(mutedTextColor)
+	// Fixed overhead: border(2), padding(2), title(1), x axis(2), gaps(1), status bar(1).
+	overhead := 11
+	if m.lastResult.Truncated {
+		b.WriteString(lipgloss.NewStyle().Foreground(errorColor).Render(truncateRunes(m.chartTruncatedNotice(), contentWidth)))
+		b.WriteByte('\n')
+		overhead++
+	}
+	if c.err != "" {
+		b.WriteString(lipgloss.NewStyle().Foreground(errorColor).Render(truncateRunes(c.err, contentWidth)))
+	} else {
+		height := max(m.height-overhead, 3)
+		kind := chartBar
+		if c.line {
+			kind = chartLine
+		}
+		b.WriteString(renderChart(c.points, kind, c.average && c.measure >= 0, contentWidth, height))
+		b.WriteByte('\n')
+		gaps := fmt.Sprintf("%d bucket(s), no gaps", len(c.points))
+		if len(c.gaps) > 0 {
+			gaps = fmt.Sprintf("%d bucket(s), %d gap(s): %s", len(c.points), len(c.gaps), strings.Join(c.gaps, ", "))
+			b.WriteString(lipgloss.NewStyle().Foreground(errorColor).Render(truncateRunes(gaps, contentWidth)))
+		} else {
+			b.WriteString(muted.Render(gaps))
+		}
+	}
+
+	modal := lipgloss.NewStyle().
+		Border(lipgloss.RoundedBorder()).
+		BorderForeground(accentColor).
+		Padding(1, 2).
+		Width(modalWidth).
+		Background(panelBackground).
+		Render(b.String())
+
+	return overlayModal(m.width, background, modal)
+}
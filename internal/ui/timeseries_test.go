@@ -0,0 +1,159 @@
+package ui
+
+import (
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/kwrkb/asql/internal/db"
+)
+
+func TestTimeSeriesGaps(t *testing.T) {
+	rows := [][]string{
+		{"2024-01-01", "10"},
+		{"2024-01-01 12:30:00", "20"},
+		{"2024-01-03", "5"},
+		{"NULL", "7"},
+		{"2024-01-04", "x"},
+	}
+
+	points, gaps, err := timeSeries(rows, 0, -1, granDay, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var labels []string
+	var values []float64
+	for _, p := range points {
+		labels = append(labels, p.Label)
+		values = append(values, p.Value)
+	}
+	if want := []string{"2024-01-01", "2024-01-02", "2024-01-03", "2024-01-04"}; !slices.Equal(labels, want) {
+		t.Errorf("labels = %v, want %v", labels, want)
+	}
+	if want := []float64{2, 0, 1, 1}; !slices.Equal(values, want) {
+		t.Errorf("counts = %v, want %v", values, want)
+	}
+	if !points[1].Missing || !slices.Equal(gaps, []string{"2024-01-02"}) {
+		t.Errorf("gaps = %v", gaps)
+	}
+
+	sums, _, _ := timeSeries(rows, 0, 1, granDay, false)
+	avgs, _, _ := timeSeries(rows, 0, 1, granDay, true)
+	if sums[0].Value != 30 || avgs[0].Value != 15 {
+		t.Errorf("sum = %v, avg = %v", sums[0].Value, avgs[0].Value)
+	}
+	if sums[3].Missing || sums[3].Value != 0 {
+		t.Errorf("a bucket without numbers is not a gap, got %+v", sums[3])
+	}
+}
+
+func TestTimeSeriesGranularities(t *testing.T) {
+	rows := [][]string{{"2024-12-30T08:15:00Z"}, {"2025-02-01T09:45:00Z"}}
+	tests := []struct {
+		gran        timeGranularity
+		first, last string
+		n           int
+	}{
+		{granWeek, "2025-W01", "2025-W05", 5},
+		{granQuarter, "2024-Q4", "2025-Q1", 2},
+		{granMonth, "2024-12", "2025-02", 3},
+	}
+	for _, tt := range tests {
+		points, _, err := timeSeries(rows, 0, -1, tt.gran, false)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(points) != tt.n || points[0].Label != tt.first || points[len(points)-1].Label != tt.last {
+			t.Errorf("%s: got %d points %s..%s", granularityName(tt.gran), len(points), points[0].Label, points[len(points)-1].Label)
+		}
+	}
+
+	if _, _, err := timeSeries(rows, 0, -1, granHour, false); err != nil {
+		t.Errorf("hourly buckets over a month: %v", err)
+	}
+	long := [][]string{{"2000-01-01"}, {"2024-01-01"}}
+	if _, _, err := timeSeries(long, 0, -1, granHour, false); err == nil {
+		t.Error("expected too many hourly buckets to fail")
+	}
+	if _, _, err := timeSeries([][]string{{"abc"}}, 0, -1, granDay, false); err == nil {
+		t.Error("expected an error without dates")
+	}
+}
+
+func TestChartMode(t *testing.T) {
+	m := newTestModel()
+	m.showDerivedResult(db.QueryResult{
+		Columns:     []string{"amount", "created_at"},
+		ColumnTypes: []string{"INTEGER", "TEXT"},
+		Rows:        [][]string{{"3", "2024-01-01"}, {"4", "2024-01-03"}, {"5", "2024-01-03"}},
+	}, "")
+
+	next, _ := m.updateNormal(runeMsg("T"))
+	if next.(model).mode == chartMode {
+		t.Fatal("expected a non-date column to be refused")
+	}
+
+	m.colCursor = 1
+	next, _ = m.updateNormal(runeMsg("T"))
+	rm := next.(model)
+	if rm.mode != chartMode || rm.chartSt.gran != granDay || len(rm.chartSt.points) != 3 || len(rm.chartSt.gaps) != 1 {
+		t.Fatalf("expected a daily chart with one gap, got mode %q %+v", rm.mode, rm.chartSt)
+	}
+
+	next, _ = rm.updateChart(runeMsg("m"))
+	rm = next.(model)
+	next, _ = rm.updateChart(runeMsg("a"))
+	rm = next.(model)
+	if rm.chartSt.measure != 0 || rm.chartSt.points[2].Value != 4.5 {
+		t.Errorf("expected avg(amount), got %+v", rm.chartSt)
+	}
+	if title := rm.chartTitle(); title != "avg(amount) by day of created_at" {
+		t.Errorf("title = %q", title)
+	}
+
+	next, _ = rm.updateChart(runeMsg("M"))
+	rm = next.(model)
+	if rm.chartSt.gran != granMonth || len(rm.chartSt.points) != 1 || len(rm.chartSt.gaps) != 0 {
+		t.Errorf("expected one monthly bucket, got %+v", rm.chartSt)
+	}
+
+	view := rm.renderWithChartOverlay("")
+	if !strings.Contains(view, "avg(amount) by month") || !strings.Contains(view, "2024-01") {
+		t.Errorf("chart overlay missing title or labels:\n%s", view)
+	}
+
+	next, _ = rm.updateChart(runeMsg("q"))
+	if next.(model).mode != normalMode {
+		t.Error("expected q to close the chart")
+	}
+}
+
+func TestChartWarnsWhenTruncated(t *testing.T) {
+	m := newTestModel()
+	m.showDerivedResult(db.QueryResult{
+		Columns:   []string{"created_at"},
+		Rows:      [][]string{{"2024-01-01"}, {"2024-01-02"}},
+		Truncated: true,
+	}, "")
+	next, _ := m.updateNormal(runeMsg("T"))
+	rm := next.(model)
+	if rm.mode != chartMode || !rm.statusError || !strings.Contains(rm.statusText, "truncated at 2 rows") {
+		t.Fatalf("expected a truncation warning, mode %q status %q", rm.mode, rm.statusText)
+	}
+	if view := rm.renderWithChartOverlay(""); !strings.Contains(view, "result truncated at 2 rows") {
+		t.Errorf("chart overlay missing the truncation notice:\n%s", view)
+	}
+}
+
+func TestRenderChartGapMarkers(t *testing.T) {
+	points := []chartPoint{{Label: "a", Value: 1}, {Label: "b", Missing: true}, {Label: "c", Value: 3}}
+	for _, kind := range []chartKind{chartBar, chartLine} {
+		out := renderChart(points, kind, false, 30, 4)
+		if lines := strings.Split(out, "\n"); len(lines) != 6 {
+			t.Errorf("expected 4 plot rows, an axis and labels, got %d lines", len(lines))
+		}
+		if !strings.Contains(out, "·") || !strings.Contains(out, "└") {
+			t.Errorf("missing gap marker or axis:\n%s", out)
+		}
+	}
+}
@@ -0,0 +1,240 @@
+package ui
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/kwrkb/asql/internal/config"
+	"github.com/kwrkb/asql/internal/db"
+	"github.com/kwrkb/asql/internal/db/dbutil"
+)
+
+// naiveLayout shows a timestamp without a zone. It is what the databases
+// accept back for TIMESTAMP / DATETIME literals.
+const naiveLayout = "2006-01-02 15:04:05"
+
+// temporalKind tells how the cells of a column are displayed.
+type temporalKind int
+
+const (
+	temporalNone  temporalKind = iota
+	temporalDate               // calendar date: shown without a time
+	temporalNaive              // wall-clock time without a zone: shown as stored
+	temporalZoned              // an instant: shown in the display time zone
+)
+
+// temporalColumn classifies a column by its database type. Drivers return
+// every temporal value as a time.Time, which dbutil.StringifyValue formats
+// as RFC3339, so the type decides whether its offset means anything.
+// PostgreSQL's TIMESTAMPTZ and MySQL's TIMESTAMP hold instants; the MySQL
+// adapter reads the latter in the session time zone for that.
+func temporalColumn(dbType, colType string) temporalKind {
+	t := strings.ToLower(colType)
+	switch {
+	case t == "date":
+		return temporalDate
+	case t == "timestamptz" || t == "timestamp with time zone":
+		return temporalZoned
+	case t == "timestamp" && dbType == "mysql":
+		return temporalZoned
+	case t == "timestamp" || t == "datetime" || t == "timestamp without time zone":
+		return temporalNaive
+	}
+	return temporalNone
+}
+
+// zoneCell formats a cell of the given kind. Cells that are not RFC3339
+// timestamps, such as NULL or TEXT dates in SQLite, are kept as they are.
+func zoneCell(cell string, kind temporalKind, loc *time.Location) string {
+	if kind == temporalNone {
+		return cell
+	}
+	t, err := time.Parse(time.RFC3339, cell)
+	if err != nil {
+		return cell
+	}
+	switch kind {
+	case temporalDate:
+		if t.Hour() == 0 && t.Minute() == 0 && t.Second() == 0 {
+			return t.Format(time.DateOnly)
+		}
+		return t.Format(naiveLayout)
+	case temporalNaive:
+		return t.Format(naiveLayout)
+	default:
+		return t.In(loc).Format(time.RFC3339)
+	}
+}
+
+// zoneResult formats the temporal cells of a result fresh from a database
+// of type dbType. Afterwards only zoned cells carry an offset, which is how
+// rezoneResult and the column headers recognize them.
+func zoneResult(result db.QueryResult, dbType string, loc *time.Location) db.QueryResult {
+	kinds := make([]temporalKind, len(result.Columns))
+	temporal := false
+	for i := range kinds {
+		if i < len(result.ColumnTypes) {
+			kinds[i] = temporalColumn(dbType, result.ColumnTypes[i])
+			temporal = temporal || kinds[i] != temporalNone
+		}
+	}
+	if !temporal {
+		return result
+	}
+	result.Rows = zoneRows(result.Rows, kinds, loc)
+	return result
+}
+
+// rezoneResult moves the zoned cells of a result that went through
+// zoneResult to another display time zone.
+func rezoneResult(result db.QueryResult, loc *time.Location) db.QueryResult {
+	kinds := make([]temporalKind, len(result.Columns))
+	for i := range kinds {
+		if zonedColumn(result, i) {
+			kinds[i] = temporalZoned
+		}
+	}
+	result.Rows = zoneRows(result.Rows, kinds, loc)
+	return result
+}
+
+// zoneRows returns copies of the rows with the cells of each column
+// formatted by its kind. The input rows may be shared and are not changed.
+func zoneRows(rows [][]string, kinds []temporalKind, loc *time.Location) [][]string {
+	out := make([][]string, len(rows))
+	for r, row := range rows {
+		zoned := make([]string, len(row))
+		for i, cell := range row {
+			if i < len(kinds) {
+				cell = zoneCell(cell, kinds[i], loc)
+			}
+			zoned[i] = cell
+		}
+		out[r] = zoned
+	}
+	return out
+}
+
+// zonedColumn reports whether column col of a zoned result holds instants:
+// a temporal column whose first non-NULL cell has an offset.
+func zonedColumn(result db.QueryResult, col int) bool {
+	if col >= len(result.ColumnTypes) || !detectDateColumn(result.ColumnTypes[col]) {
+		return false
+	}
+	for _, row := range result.Rows {
+		if col >= len(row) || row[col] == "NULL" {
+			continue
+		}
+		_, err := time.Parse(time.RFC3339, row[col])
+		return err == nil
+	}
+	return false
+}
+
+// zoneLabel is the short name of loc shown in column headers, e.g. "JST".
+func zoneLabel(loc *time.Location) string {
+	return time.Now().In(loc).Format("MST")
+}
+
+// displayLocation returns the zone zoned timestamps are shown in: UTC when
+// toggled with Z, else the active profile's time_zone, else the configured
+// one.
+func (m *model) displayLocation() *time.Location {
+	if m.tz.utc {
+		return time.UTC
+	}
+	if m.tz.profile != nil {
+		return m.tz.profile
+	}
+	if m.tz.base != nil {
+		return m.tz.base
+	}
+	return time.Local
+}
+
+// loadProfileZone resolves the time_zone of the profile behind the active
+// connection. An unknown zone falls back to the configured one.
+func (m *model) loadProfileZone() {
+	m.tz.profile = nil
+	dsn := m.connMgr.ActiveDSN()
+	for _, p := range m.profileSt.items {
+		if p.DSN != dsn || p.TimeZone == "" {
+			continue
+		}
+		loc, err := config.LoadLocation(p.TimeZone)
+		if err != nil {
+			m.setStatus(fmt.Sprintf("Profile %s: %v", sanitize(p.Name), err), true)
+			return
+		}
+		m.tz.profile = loc
+		return
+	}
+}
+
+// applyZone moves every shown result to the current display time zone.
+func (m *model) applyZone() {
+	loc := m.displayLocation()
+	if m.pivotBase != nil {
+		m.pivotBase.result = rezoneResult(m.pivotBase.result, loc)
+	}
+	if p := m.pinned; p != nil {
+		p.result = rezoneResult(p.result, loc)
+		p.resort()
+	}
+	if len(m.lastResult.Columns) > 0 {
+		cursor := m.table.Cursor()
+		m.lastResult = rezoneResult(m.lastResult, loc)
+		m.applySortedResult()
+		m.table.SetCursor(cursor)
+		m.syncCompareTables()
+	}
+}
+
+// toggleUTC switches zoned timestamps between UTC and the display zone.
+func (m *model) toggleUTC() {
+	m.tz.utc = !m.tz.utc
+	m.applyZone()
+	loc := m.displayLocation()
+	m.setStatus(fmt.Sprintf("Showing timestamps in %s (%s)", loc, zoneLabel(loc)), false)
+}
+
+// columnTypeLabel returns the short type shown next to a column name, with
+// the display zone added for zoned columns, e.g. "tstz@JST".
+func (m *model) columnTypeLabel(result db.QueryResult, col int) string {
+	if col >= len(result.ColumnTypes) || result.ColumnTypes[col] == "" {
+		return ""
+	}
+	label := dbutil.ShortenTypeName(sanitize(result.ColumnTypes[col]))
+	if zonedColumn(result, col) {
+		label += "@" + zoneLabel(m.displayLocation())
+	}
+	return label
+}
+
+// zoneHandler formats the rows of a streamed export like zoneResult.
+type zoneHandler struct {
+	next   db.RowHandler
+	dbType string
+	loc    *time.Location
+	kinds  []temporalKind
+}
+
+func (h *zoneHandler) Header(columns, columnTypes []string) error {
+	h.kinds = make([]temporalKind, len(columns))
+	for i := range h.kinds {
+		if i < len(columnTypes) {
+			h.kinds[i] = temporalColumn(h.dbType, columnTypes[i])
+		}
+	}
+	return h.next.Header(columns, columnTypes)
+}
+
+func (h *zoneHandler) Row(row []string, nulls []bool) error {
+	for i, cell := range row {
+		if i < len(h.kinds) {
+			row[i] = zoneCell(cell, h.kinds[i], h.loc)
+		}
+	}
+	return h.next.Row(row, nulls)
+}
@@ -0,0 +1,132 @@
+package ui
+
+import (
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/kwrkb/asql/internal/db"
+)
+
+func tokyo(t *testing.T) *time.Location {
+	t.Helper()
+	loc, err := time.LoadLocation("Asia/Tokyo")
+	if err != nil {
+		t.Skipf("time zone data unavailable: %v", err)
+	}
+	return loc
+}
+
+func TestTemporalColumn(t *testing.T) {
+	tests := []struct {
+		dbType, colType string
+		want            temporalKind
+	}{
+		{"postgres", "TIMESTAMPTZ", temporalZoned},
+		{"postgres", "TIMESTAMP", temporalNaive},
+		{"postgres", "DATE", temporalDate},
+		{"mysql", "TIMESTAMP", temporalZoned},
+		{"mysql", "DATETIME", temporalNaive},
+		{"sqlite", "TIMESTAMP", temporalNaive},
+		{"sqlite", "TEXT", temporalNone},
+		{"postgres", "TIME", temporalNone},
+	}
+	for _, tt := range tests {
+		if got := temporalColumn(tt.dbType, tt.colType); got != tt.want {
+			t.Errorf("temporalColumn(%q, %q) = %d, want %d", tt.dbType, tt.colType, got, tt.want)
+		}
+	}
+}
+
+func TestZoneResult(t *testing.T) {
+	loc := tokyo(t)
+	result := db.QueryResult{
+		Columns:     []string{"at", "local_at", "day", "note"},
+		ColumnTypes: []string{"TIMESTAMPTZ", "TIMESTAMP", "DATE", "TEXT"},
+		Rows: [][]string{
+			{"2024-01-01T15:30:00Z", "2024-01-01T15:30:00Z", "2024-01-01T00:00:00Z", "2024-01-01T15:30:00Z"},
+			{"NULL", "NULL", "NULL", "NULL"},
+		},
+	}
+
+	got := zoneResult(result, "postgres", loc)
+	want := []string{"2024-01-02T00:30:00+09:00", "2024-01-01 15:30:00", "2024-01-01", "2024-01-01T15:30:00Z"}
+	if !slices.Equal(got.Rows[0], want) {
+		t.Errorf("zoned row = %v, want %v", got.Rows[0], want)
+	}
+	if !slices.Equal(got.Rows[1], result.Rows[1]) {
+		t.Errorf("NULLs changed: %v", got.Rows[1])
+	}
+	if result.Rows[0][0] != "2024-01-01T15:30:00Z" {
+		t.Error("zoneResult must not change the input rows")
+	}
+
+	// Only the zoned column keeps an offset, so rezoning touches nothing else.
+	utc := rezoneResult(got, time.UTC)
+	want[0] = "2024-01-01T15:30:00Z"
+	if !slices.Equal(utc.Rows[0], want) {
+		t.Errorf("rezoned row = %v, want %v", utc.Rows[0], want)
+	}
+
+	// The day boundary follows the display zone.
+	points, _, err := timeSeries(got.Rows, 0, -1, granDay, false)
+	if err != nil || len(points) != 1 || points[0].Label != "2024-01-02" {
+		t.Errorf("bucketed in the display zone: %v %v", points, err)
+	}
+}
+
+func TestToggleUTC(t *testing.T) {
+	m := newTestModel()
+	m.tz.base = tokyo(t)
+	result := zoneResult(db.QueryResult{
+		Columns:     []string{"id", "at"},
+		ColumnTypes: []string{"INT8", "TIMESTAMPTZ"},
+		Rows:        [][]string{{"1", "2024-01-01T15:30:00Z"}},
+	}, "postgres", m.displayLocation())
+	m.showDerivedResult(result, "")
+
+	if got := m.columnTypeLabel(m.lastResult, 1); got != "tstz@JST" {
+		t.Errorf("type label = %q", got)
+	}
+	if got := m.columnTypeLabel(m.lastResult, 0); got != "int8" {
+		t.Errorf("type label of a non-temporal column = %q", got)
+	}
+
+	next, _ := m.updateNormal(runeMsg("Z"))
+	rm := next.(model)
+	if got := rm.lastResult.Rows[0][1]; got != "2024-01-01T15:30:00Z" {
+		t.Errorf("expected UTC, got %q", got)
+	}
+	if !strings.Contains(rm.statusText, "UTC") || rm.columnTypeLabel(rm.lastResult, 1) != "tstz@UTC" {
+		t.Errorf("status %q, label %q", rm.statusText, rm.columnTypeLabel(rm.lastResult, 1))
+	}
+
+	next, _ = rm.updateNormal(runeMsg("Z"))
+	rm = next.(model)
+	if got := rm.lastResult.Rows[0][1]; got != "2024-01-02T00:30:00+09:00" {
+		t.Errorf("expected the display zone back, got %q", got)
+	}
+}
+
+type collectRows struct{ rows [][]string }
+
+func (c *collectRows) Header(columns, columnTypes []string) error { return nil }
+func (c *collectRows) Row(row []string, _ []bool) error {
+	c.rows = append(c.rows, row)
+	return nil
+}
+
+func TestZoneHandler(t *testing.T) {
+	sink := &collectRows{}
+	h := &zoneHandler{next: sink, dbType: "mysql", loc: tokyo(t)}
+	if err := h.Header([]string{"at", "d"}, []string{"TIMESTAMP", "DATETIME"}); err != nil {
+		t.Fatal(err)
+	}
+	if err := h.Row([]string{"2024-01-01T15:30:00Z", "2024-01-01T15:30:00Z"}, nil); err != nil {
+		t.Fatal(err)
+	}
+	if want := []string{"2024-01-02T00:30:00+09:00", "2024-01-01 15:30:00"}; !slices.Equal(sink.rows[0], want) {
+		t.Errorf("streamed row = %v, want %v", sink.rows[0], want)
+	}
+}
@@ -0,0 +1,405 @@
+package ui
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/charmbracelet/bubbles/textinput"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+)
+
+// viewerRow is one display row of the value viewer. Wrapped lines span
+// several rows that share the same line index.
+type viewerRow struct {
+	segs []segment
+	line int
+}
+
+// openViewer shows the focused DETAIL field in the full-screen value viewer.
+func (m model) openViewer() (tea.Model, tea.Cmd) {
+	rowIdx := m.table.Cursor()
+	field := m.detail.fieldCursor
+	if rowIdx < 0 || rowIdx >= len(m.displayRows) || field >= len(m.lastResult.Columns) {
+		return m, nil
+	}
+	row := m.displayRows[rowIdx]
+	raw := ""
+	if field < len(row) {
+		raw = row[field]
+	}
+	if raw == `""` {
+		raw = ""
+	}
+	colType := ""
+	if field < len(m.lastResult.ColumnTypes) {
+		colType = m.lastResult.ColumnTypes[field]
+	}
+
+	title := sanitize(m.lastResult.Columns[field])
+	if shortType := m.columnTypeLabel(m.lastResult, field); shortType != "" {
+		title += " " + shortType
+	}
+	search := m.viewer.search
+	search.Reset()
+	search.Blur()
+	m.viewer = viewerState{
+		title:  title,
+		column: field,
+		raw:    raw,
+		kind:   detectViewerKind(raw, colType),
+		wrap:   true,
+		search: search,
+	}
+	m.rebuildViewer()
+	m.mode = viewerMode
+	m.setStatus(fmt.Sprintf("Viewing %s as %s", title, viewerKindLabels[m.viewer.shown]), false)
+	return m, nil
+}
+
+func (m model) updateViewer(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	v := &m.viewer
+	if v.searching {
+		switch msg.Type {
+		case tea.KeyEsc:
+			v.searching = false
+			v.search.Blur()
+			return m, nil
+		case tea.KeyEnter:
+			v.searching = false
+			v.search.Blur()
+			v.query = v.search.Value()
+			m.findViewerMatches()
+			m.jumpViewerMatch(0)
+			return m, nil
+		}
+		var cmd tea.Cmd
+		v.search, cmd = v.search.Update(msg)
+		return m, cmd
+	}
+
+	page := max(m.viewerBodyHeight()-1, 1)
+	switch msg.Type {
+	case tea.KeyEsc:
+		m.closeViewer()
+	case tea.KeyDown:
+		m.moveViewerCursor(1)
+	case tea.KeyUp:
+		m.moveViewerCursor(-1)
+	case tea.KeyPgDown, tea.KeyCtrlD:
+		m.moveViewerCursor(page)
+	case tea.KeyPgUp, tea.KeyCtrlU:
+		m.moveViewerCursor(-page)
+	case tea.KeyLeft:
+		m.scrollViewerX(-8)
+	case tea.KeyRight:
+		m.scrollViewerX(8)
+	case tea.KeyEnter, tea.KeySpace:
+		m.toggleViewerFold()
+	case tea.KeyRunes:
+		if msg.Alt {
+			break
+		}
+		switch string(msg.Runes) {
+		case "q":
+			m.closeViewer()
+		case "j":
+			m.moveViewerCursor(1)
+		case "k":
+			m.moveViewerCursor(-1)
+		case "g":
+			m.moveViewerCursor(-len(v.rows))
+		case "G":
+			m.moveViewerCursor(len(v.rows))
+		case "h":
+			m.scrollViewerX(-8)
+		case "l":
+			m.scrollViewerX(8)
+		case "w":
+			v.wrap = !v.wrap
+			v.xOffset = 0
+			m.rebuildViewer()
+		case "m":
+			v.kind = (v.kind + 1) % viewerKindCount
+			m.rebuildViewer()
+			if v.shown != v.kind {
+				m.setStatus(fmt.Sprintf("Value is not valid %s; showing %s", viewerKindLabels[v.kind], viewerKindLabels[v.shown]), true)
+			} else {
+				m.setStatus("Viewing as "+viewerKindLabels[v.shown], false)
+			}
+		case "-":
+			if v.tree != nil && v.shown == viewerJSON {
+				v.tree.setFolded(true, true)
+				m.rebuildViewer()
+			}
+		case "+", "=":
+			if v.tree != nil && v.shown == viewerJSON {
+				v.tree.setFolded(false, true)
+				m.rebuildViewer()
+			}
+		case "/":
+			v.searching = true
+			v.search.SetValue(v.query)
+			v.search.CursorEnd()
+			v.search.Focus()
+			return m, textinput.Blink
+		case "n":
+			m.jumpViewerMatch(1)
+		case "N":
+			m.jumpViewerMatch(-1)
+		case "a":
+			return m.addJSONPathColumn()
+		case "y":
+			m.copyText(v.raw, fmt.Sprintf("Copied %d byte(s)", len(v.raw)))
+		}
+	}
+	return m, nil
+}
+
+// addJSONPathColumn extracts the JSON path under the cursor from every row
+// of the result and appends it as a derived column, then returns to NORMAL
+// mode with the new column focused.
+func (m model) addJSONPathColumn() (tea.Model, tea.Cmd) {
+	v := &m.viewer
+	if v.shown != viewerJSON || v.cursor >= len(v.rows) {
+		m.setStatus("Add column needs a JSON value", true)
+		return m, nil
+	}
+	path := v.lines[v.rows[v.cursor].line].path
+	if len(path) == 0 {
+		m.setStatus("Move the cursor to a key or element to add it as a column", true)
+		return m, nil
+	}
+	res, name := withJSONPathColumn(m.lastResult, v.column, path)
+	if slices.Contains(m.lastResult.Columns, name) {
+		m.setStatus(fmt.Sprintf("Column %s already exists", sanitize(name)), true)
+		return m, nil
+	}
+	m.lastResult = res
+	m.applySortedResult()
+	m.colCursor = len(res.Columns) - 1
+	m.adjustColOffset()
+	m.syncViewport()
+	m.syncCompareTables()
+	m.viewer.search.Blur()
+	m.mode = normalMode
+	m.setStatus(fmt.Sprintf("Added column %s", sanitize(name)), false)
+	return m, nil
+}
+
+func (m *model) closeViewer() {
+	m.viewer.search.Blur()
+	m.mode = detailMode
+	m.setStatus("Detail mode", false)
+}
+
+// rebuildViewer re-formats the value and re-wraps it for the current width.
+// It runs from Update/resize so View stays a pure read.
+func (m *model) rebuildViewer() {
+	v := &m.viewer
+	var lines []viewerLine
+	lines, v.shown, v.tree = formatViewerLines(v.raw, v.kind, v.tree)
+	v.lines = lines
+
+	width := m.viewerBodyWidth()
+	v.rows = v.rows[:0]
+	for i, l := range lines {
+		if !v.wrap {
+			v.rows = append(v.rows, viewerRow{segs: l.segs, line: i})
+			continue
+		}
+		for _, segs := range wrapSegments(l.segs, width) {
+			v.rows = append(v.rows, viewerRow{segs: segs, line: i})
+		}
+	}
+	v.cursor = min(v.cursor, max(len(v.rows)-1, 0))
+	m.findViewerMatches()
+	m.clampViewerScroll()
+}
+
+func (m *model) moveViewerCursor(delta int) {
+	v := &m.viewer
+	v.cursor = max(min(v.cursor+delta, len(v.rows)-1), 0)
+	m.clampViewerScroll()
+}
+
+func (m *model) clampViewerScroll() {
+	v := &m.viewer
+	height := m.viewerBodyHeight()
+	if v.cursor < v.scroll {
+		v.scroll = v.cursor
+	}
+	if v.cursor >= v.scroll+height {
+		v.scroll = v.cursor - height + 1
+	}
+	v.scroll = max(min(v.scroll, len(v.rows)-height), 0)
+}
+
+func (m *model) scrollViewerX(delta int) {
+	if m.viewer.wrap {
+		return
+	}
+	m.viewer.xOffset = max(m.viewer.xOffset+delta, 0)
+}
+
+// toggleViewerFold folds or unfolds the JSON container under the cursor and
+// keeps the cursor on its opening line.
+func (m *model) toggleViewerFold() {
+	v := &m.viewer
+	if v.shown != viewerJSON || v.cursor >= len(v.rows) {
+		return
+	}
+	node := v.lines[v.rows[v.cursor].line].node
+	if node == nil {
+		return
+	}
+	node.folded = !node.folded
+	m.rebuildViewer()
+	for i, r := range v.rows {
+		if v.lines[r.line].node == node {
+			v.cursor = i
+			break
+		}
+	}
+	m.clampViewerScroll()
+}
+
+// findViewerMatches records the rows containing the search query
+// (case-insensitive).
+func (m *model) findViewerMatches() {
+	v := &m.viewer
+	v.matches = v.matches[:0]
+	if v.query == "" {
+		return
+	}
+	q := strings.ToLower(v.query)
+	for i, r := range v.rows {
+		if strings.Contains(strings.ToLower(viewerLine{segs: r.segs}.plain()), q) {
+			v.matches = append(v.matches, i)
+		}
+	}
+}
+
+// jumpViewerMatch moves the cursor to the next (dir > 0), previous (dir < 0)
+// or first-at-or-after-cursor (dir == 0) match, wrapping around.
+func (m *model) jumpViewerMatch(dir int) {
+	v := &m.viewer
+	if len(v.matches) == 0 {
+		if v.query != "" {
+			m.setStatus(fmt.Sprintf("Pattern not found: %s", v.query), true)
+		}
+		return
+	}
+	target := -1
+	switch {
+	case dir < 0:
+		for i := len(v.matches) - 1; i >= 0; i-- {
+			if v.matches[i] < v.cursor {
+				target = v.matches[i]
+				break
+			}
+		}
+		if target < 0 {
+			target = v.matches[len(v.matches)-1]
+		}
+	default:
+		for _, r := range v.matches {
+			if r > v.cursor || (dir == 0 && r == v.cursor) {
+				target = r
+				break
+			}
+		}
+		if target < 0 {
+			target = v.matches[0]
+		}
+	}
+	v.cursor = target
+	m.clampViewerScroll()
+	for i, r := range v.matches {
+		if r == target {
+			m.setStatus(fmt.Sprintf("Match %d/%d", i+1, len(v.matches)), false)
+			break
+		}
+	}
+}
+
+// viewerBodyWidth is the text width inside the viewer border, padding and
+// cursor gutter.
+func (m *model) viewerBodyWidth() int {
+	return max(m.width-6, 1)
+}
+
+// viewerBodyHeight is the number of value rows shown: screen height minus
+// the status bar, border, header and footer.
+func (m *model) viewerBodyHeight() int {
+	return max(m.height-5, 1)
+}
+
+func (m model) renderViewer() string {
+	v := m.viewer
+	width := m.viewerBodyWidth()
+	height := m.viewerBodyHeight()
+
+	titleStyle := lipgloss.NewStyle().Bold(true).Foreground(accentColor)
+	infoStyle := lipgloss.NewStyle().Foreground(mutedTextColor)
+	cursorStyle := lipgloss.NewStyle().Foreground(accentColor).Bold(true)
+	matchStyle := lipgloss.NewStyle().Foreground(panelBackground).Background(keywordColor)
+
+	info := fmt.Sprintf("  %s · %d line(s)", viewerKindLabels[v.shown], len(v.lines))
+	if !v.wrap {
+		info += " · nowrap"
+	}
+	header := titleStyle.Render(v.title) + infoStyle.Render(info)
+
+	matched := make(map[int]bool, len(v.matches))
+	for _, r := range v.matches {
+		matched[r] = true
+	}
+
+	var body strings.Builder
+	end := min(v.scroll+height, len(v.rows))
+	for i := v.scroll; i < end; i++ {
+		r := v.rows[i]
+		segs := r.segs
+		if !v.wrap {
+			segs = cutSegments(segs, v.xOffset, width)
+		}
+		gutter := "  "
+		if i == v.cursor {
+			gutter = cursorStyle.Render("▸ ")
+		}
+		body.WriteString(gutter)
+		if matched[i] {
+			body.WriteString(matchStyle.Render(viewerLine{segs: segs}.plain()))
+		} else {
+			body.WriteString(renderSegments(segs))
+		}
+		if i < end-1 {
+			body.WriteByte('\n')
+		}
+	}
+
+	var footer string
+	if v.searching {
+		footer = v.search.View()
+	} else {
+		pos := fmt.Sprintf("row %d/%d", min(v.cursor+1, len(v.rows)), len(v.rows))
+		if v.query != "" {
+			pos += fmt.Sprintf(" · /%s (%d match(es))", sanitize(v.query), len(v.matches))
+		}
+		footer = infoStyle.Render(pos)
+	}
+
+	bodyBlock := lipgloss.NewStyle().Height(height).Render(body.String())
+	content := lipgloss.JoinVertical(lipgloss.Left, header, bodyBlock, footer)
+
+	return lipgloss.NewStyle().
+		Border(lipgloss.RoundedBorder()).
+		BorderForeground(accentColor).
+		Padding(0, 1).
+		Width(max(m.width-2, 1)).
+		Height(max(m.height-3, 1)).
+		Background(panelBackground).
+		Render(content)
+}
@@ -0,0 +1,517 @@
+package ui
+
+import (
+	"bytes"
+	"encoding/hex"
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/charmbracelet/lipgloss"
+)
+
+// viewerKind selects how the value viewer formats a cell.
+type viewerKind int
+
+const (
+	viewerText viewerKind = iota
+	viewerJSON
+	viewerXML
+	viewerHex
+	viewerKindCount
+)
+
+var viewerKindLabels = []string{"Text", "JSON", "XML", "Hex"}
+
+var (
+	viewerKeyStyle     = lipgloss.NewStyle().Foreground(accentColor)
+	viewerStringStyle  = lipgloss.NewStyle().Foreground(successColor)
+	viewerNumberStyle  = lipgloss.NewStyle().Foreground(keywordColor)
+	viewerLiteralStyle = lipgloss.NewStyle().Foreground(errorColor)
+	viewerPunctStyle   = lipgloss.NewStyle().Foreground(mutedTextColor)
+	viewerPlainStyle   = lipgloss.NewStyle().Foreground(textColor)
+)
+
+// segment is a run of text rendered with a single style.
+type segment struct {
+	style lipgloss.Style
+	text  string
+}
+
+// viewerLine is one logical line of formatted output. node is set on the
+// opening and closing lines of a foldable JSON container; path is the JSON
+// path of the value that starts on the line.
+type viewerLine struct {
+	segs []segment
+	node *jsonNode
+	path jsonPath
+}
+
+func (l viewerLine) plain() string {
+	var b strings.Builder
+	for _, s := range l.segs {
+		b.WriteString(s.text)
+	}
+	return b.String()
+}
+
+// detectViewerKind picks a formatter from the column type, falling back to
+// sniffing the value itself.
+func detectViewerKind(value, colType string) viewerKind {
+	lower := strings.ToLower(colType)
+	trimmed := strings.TrimSpace(value)
+	switch {
+	case lower == "json" || lower == "jsonb":
+		if json.Valid([]byte(trimmed)) {
+			return viewerJSON
+		}
+	case lower == "xml":
+		if _, err := parseXMLTokens(trimmed); err == nil {
+			return viewerXML
+		}
+	case isBinaryType(lower):
+		if _, ok := decodeHexCell(value); ok {
+			return viewerHex
+		}
+	}
+	if strings.HasPrefix(trimmed, "{") || strings.HasPrefix(trimmed, "[") {
+		if json.Valid([]byte(trimmed)) {
+			return viewerJSON
+		}
+	}
+	if strings.HasPrefix(trimmed, "<") {
+		if _, err := parseXMLTokens(trimmed); err == nil {
+			return viewerXML
+		}
+	}
+	return viewerText
+}
+
+func isBinaryType(lower string) bool {
+	return strings.Contains(lower, "blob") || strings.Contains(lower, "binary") || lower == "bytea"
+}
+
+// decodeHexCell reverses the hex encoding StringifyValue applies to binary
+// values. Bytes that are valid UTF-8 are shown as text by StringifyValue, so
+// a decoded value that is valid UTF-8 means the cell was never hex-encoded.
+func decodeHexCell(value string) ([]byte, bool) {
+	if value == "" || len(value)%2 != 0 {
+		return nil, false
+	}
+	b, err := hex.DecodeString(value)
+	if err != nil || utf8.Valid(b) {
+		return nil, false
+	}
+	return b, true
+}
+
+// formatViewerLines formats value as kind. It falls back to plain text when
+// the value cannot be parsed as the requested kind; the returned kind is the
+// one actually used. tree is reused for JSON so fold state survives rebuilds.
+func formatViewerLines(value string, kind viewerKind, tree *jsonNode) ([]viewerLine, viewerKind, *jsonNode) {
+	switch kind {
+	case viewerJSON:
+		if tree == nil {
+			var err error
+			tree, err = parseJSONTree(value)
+			if err != nil {
+				break
+			}
+		}
+		var lines []viewerLine
+		tree.render(&lines, 0, false, nil)
+		return lines, viewerJSON, tree
+	case viewerXML:
+		if toks, err := parseXMLTokens(strings.TrimSpace(value)); err == nil {
+			return formatXMLLines(toks), viewerXML, nil
+		}
+	case viewerHex:
+		b, ok := decodeHexCell(value)
+		if !ok {
+			b = []byte(value)
+		}
+		return formatHexLines(b), viewerHex, nil
+	}
+	return formatTextLines(value), viewerText, nil
+}
+
+// formatTextLines splits value into lines, expanding tabs and stripping
+// control characters.
+func formatTextLines(value string) []viewerLine {
+	value = strings.ReplaceAll(value, "\r\n", "\n")
+	raw := strings.Split(value, "\n")
+	lines := make([]viewerLine, len(raw))
+	for i, l := range raw {
+		l = sanitize(strings.ReplaceAll(l, "\t", "    "))
+		lines[i] = viewerLine{segs: []segment{{viewerPlainStyle, l}}}
+	}
+	return lines
+}
+
+// formatHexLines renders b as a hexdump: offset, 16 hex bytes and an ASCII
+// gutter.
+func formatHexLines(b []byte) []viewerLine {
+	const perLine = 16
+	lines := make([]viewerLine, 0, len(b)/perLine+1)
+	for off := 0; off < len(b); off += perLine {
+		chunk := b[off:min(off+perLine, len(b))]
+		var hexPart, ascii strings.Builder
+		for i := range perLine {
+			if i == 8 {
+				hexPart.WriteByte(' ')
+			}
+			if i < len(chunk) {
+				fmt.Fprintf(&hexPart, "%02x ", chunk[i])
+			} else {
+				hexPart.WriteString("   ")
+			}
+		}
+		for _, c := range chunk {
+			if c >= 0x20 && c < 0x7f {
+				ascii.WriteByte(c)
+			} else {
+				ascii.WriteByte('.')
+			}
+		}
+		lines = append(lines, viewerLine{segs: []segment{
+			{viewerPunctStyle, fmt.Sprintf("%08x  ", off)},
+			{viewerPlainStyle, hexPart.String()},
+			{viewerPunctStyle, " |"},
+			{viewerStringStyle, ascii.String()},
+			{viewerPunctStyle, "|"},
+		}})
+	}
+	return lines
+}
+
+// jsonNode is a parsed JSON value that keeps object key order. Containers
+// can be folded in the viewer.
+type jsonNode struct {
+	key      string // JSON-encoded object key; empty for array elements and the root
+	name     string // decoded object key
+	kind     byte   // '{' or '[' for containers, 0 for scalars
+	value    string // JSON text of a scalar
+	children []*jsonNode
+	folded   bool
+}
+
+func parseJSONTree(s string) (*jsonNode, error) {
+	dec := json.NewDecoder(strings.NewReader(s))
+	dec.UseNumber()
+	node, err := parseJSONValue(dec)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := dec.Token(); err != io.EOF {
+		return nil, fmt.Errorf("trailing data after JSON value")
+	}
+	return node, nil
+}
+
+func parseJSONValue(dec *json.Decoder) (*jsonNode, error) {
+	tok, err := dec.Token()
+	if err != nil {
+		return nil, err
+	}
+	switch t := tok.(type) {
+	case json.Delim:
+		node := &jsonNode{kind: byte(t)}
+		for dec.More() {
+			name := ""
+			if t == '{' {
+				kt, err := dec.Token()
+				if err != nil {
+					return nil, err
+				}
+				name = kt.(string)
+			}
+			child, err := parseJSONValue(dec)
+			if err != nil {
+				return nil, err
+			}
+			if t == '{' {
+				child.name = name
+				child.key = encodeJSONString(name)
+			}
+			node.children = append(node.children, child)
+		}
+		if _, err := dec.Token(); err != nil { // closing delimiter
+			return nil, err
+		}
+		return node, nil
+	case string:
+		return &jsonNode{value: encodeJSONString(t)}, nil
+	case json.Number:
+		return &jsonNode{value: t.String()}, nil
+	case bool:
+		return &jsonNode{value: fmt.Sprint(t)}, nil
+	default:
+		return &jsonNode{value: "null"}, nil
+	}
+}
+
+// encodeJSONString quotes s as JSON without HTML escaping.
+func encodeJSONString(s string) string {
+	var b bytes.Buffer
+	enc := json.NewEncoder(&b)
+	enc.SetEscapeHTML(false)
+	_ = enc.Encode(s)
+	return strings.TrimSuffix(b.String(), "\n")
+}
+
+func (n *jsonNode) render(lines *[]viewerLine, depth int, comma bool, path jsonPath) {
+	indent := strings.Repeat("  ", depth)
+	var prefix []segment
+	prefix = append(prefix, segment{viewerPlainStyle, indent})
+	if n.key != "" {
+		prefix = append(prefix, segment{viewerKeyStyle, sanitize(n.key)}, segment{viewerPunctStyle, ": "})
+	}
+	trail := ""
+	if comma {
+		trail = ","
+	}
+
+	if n.kind == 0 {
+		segs := append(prefix, segment{scalarStyle(n.value), sanitize(n.value)})
+		if trail != "" {
+			segs = append(segs, segment{viewerPunctStyle, trail})
+		}
+		*lines = append(*lines, viewerLine{segs: segs, path: path})
+		return
+	}
+
+	closing := "}"
+	if n.kind == '[' {
+		closing = "]"
+	}
+	if len(n.children) == 0 {
+		segs := append(prefix, segment{viewerPunctStyle, string(n.kind) + closing + trail})
+		*lines = append(*lines, viewerLine{segs: segs, path: path})
+		return
+	}
+	if n.folded {
+		segs := append(prefix,
+			segment{viewerPunctStyle, string(n.kind) + "…" + closing + trail},
+			segment{viewerPunctStyle, fmt.Sprintf("  (%d)", len(n.children))},
+		)
+		*lines = append(*lines, viewerLine{segs: segs, node: n, path: path})
+		return
+	}
+	*lines = append(*lines, viewerLine{segs: append(prefix, segment{viewerPunctStyle, string(n.kind)}), node: n, path: path})
+	for i, c := range n.children {
+		elem := jsonPathElem{key: c.name}
+		if n.kind == '[' {
+			elem = jsonPathElem{index: i, isIndex: true}
+		}
+		c.render(lines, depth+1, i < len(n.children)-1, path.child(elem))
+	}
+	*lines = append(*lines, viewerLine{segs: []segment{{viewerPunctStyle, indent + closing + trail}}, node: n})
+}
+
+// setFolded folds or unfolds every container below (and including) n.
+// The root stays expanded so the top level remains visible.
+func (n *jsonNode) setFolded(folded bool, root bool) {
+	if n.kind == 0 {
+		return
+	}
+	n.folded = folded && !root
+	for _, c := range n.children {
+		c.setFolded(folded, false)
+	}
+}
+
+func scalarStyle(v string) lipgloss.Style {
+	switch {
+	case strings.HasPrefix(v, `"`):
+		return viewerStringStyle
+	case v == "true" || v == "false" || v == "null":
+		return viewerLiteralStyle
+	default:
+		return viewerNumberStyle
+	}
+}
+
+// parseXMLTokens reads every raw token of an XML document, dropping
+// whitespace-only text between elements.
+func parseXMLTokens(s string) ([]xml.Token, error) {
+	dec := xml.NewDecoder(strings.NewReader(s))
+	dec.Strict = true
+	var toks []xml.Token
+	depth, elements := 0, 0
+	for {
+		tok, err := dec.RawToken()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		switch t := tok.(type) {
+		case xml.StartElement:
+			depth++
+			elements++
+		case xml.EndElement:
+			depth--
+		case xml.CharData:
+			if len(bytes.TrimSpace(t)) == 0 {
+				continue
+			}
+			if depth == 0 {
+				return nil, fmt.Errorf("text outside root element")
+			}
+		}
+		toks = append(toks, xml.CopyToken(tok))
+	}
+	if elements == 0 || depth != 0 {
+		return nil, fmt.Errorf("not an XML document")
+	}
+	return toks, nil
+}
+
+func xmlName(n xml.Name) string {
+	if n.Space != "" {
+		return n.Space + ":" + n.Local
+	}
+	return n.Local
+}
+
+func xmlEscape(s string) string {
+	var b strings.Builder
+	_ = xml.EscapeText(&b, []byte(s))
+	return b.String()
+}
+
+// formatXMLLines indents XML tokens, keeping elements that only hold text
+// on a single line.
+func formatXMLLines(toks []xml.Token) []viewerLine {
+	var lines []viewerLine
+	depth := 0
+	indent := func() segment { return segment{viewerPlainStyle, strings.Repeat("  ", depth)} }
+	for i := 0; i < len(toks); i++ {
+		switch t := toks[i].(type) {
+		case xml.StartElement:
+			segs := []segment{indent(), {viewerPunctStyle, "<"}, {viewerKeyStyle, xmlName(t.Name)}}
+			for _, a := range t.Attr {
+				segs = append(segs,
+					segment{viewerNumberStyle, " " + xmlName(a.Name)},
+					segment{viewerPunctStyle, "="},
+					segment{viewerStringStyle, `"` + xmlEscape(a.Value) + `"`},
+				)
+			}
+			segs = append(segs, segment{viewerPunctStyle, ">"})
+			// <a>text</a> and <a></a> stay on one line.
+			if i+2 < len(toks) {
+				if cd, ok := toks[i+1].(xml.CharData); ok {
+					if _, ok := toks[i+2].(xml.EndElement); ok {
+						segs = append(segs,
+							segment{viewerPlainStyle, sanitize(xmlEscape(strings.TrimSpace(string(cd))))},
+							segment{viewerPunctStyle, "</"}, segment{viewerKeyStyle, xmlName(t.Name)}, segment{viewerPunctStyle, ">"},
+						)
+						lines = append(lines, viewerLine{segs: segs})
+						i += 2
+						continue
+					}
+				}
+			}
+			if i+1 < len(toks) {
+				if _, ok := toks[i+1].(xml.EndElement); ok {
+					segs = append(segs, segment{viewerPunctStyle, "</"}, segment{viewerKeyStyle, xmlName(t.Name)}, segment{viewerPunctStyle, ">"})
+					lines = append(lines, viewerLine{segs: segs})
+					i++
+					continue
+				}
+			}
+			lines = append(lines, viewerLine{segs: segs})
+			depth++
+		case xml.EndElement:
+			depth = max(depth-1, 0)
+			lines = append(lines, viewerLine{segs: []segment{indent(), {viewerPunctStyle, "</"}, {viewerKeyStyle, xmlName(t.Name)}, {viewerPunctStyle, ">"}}})
+		case xml.CharData:
+			for _, l := range strings.Split(strings.TrimSpace(string(t)), "\n") {
+				lines = append(lines, viewerLine{segs: []segment{indent(), {viewerPlainStyle, sanitize(xmlEscape(strings.TrimSpace(l)))}}})
+			}
+		case xml.Comment:
+			lines = append(lines, viewerLine{segs: []segment{indent(), {viewerPunctStyle, "<!--" + sanitize(string(t)) + "-->"}}})
+		case xml.ProcInst:
+			lines = append(lines, viewerLine{segs: []segment{indent(), {viewerPunctStyle, "<?" + t.Target + " " + sanitize(string(t.Inst)) + "?>"}}})
+		case xml.Directive:
+			lines = append(lines, viewerLine{segs: []segment{indent(), {viewerPunctStyle, "<!" + sanitize(string(t)) + ">"}}})
+		}
+	}
+	return lines
+}
+
+// wrapSegments splits segs into rows of at most width display columns.
+// An empty line yields a single empty row.
+func wrapSegments(segs []segment, width int) [][]segment {
+	width = max(width, 1)
+	var rows [][]segment
+	var row []segment
+	col := 0
+	for _, s := range segs {
+		var b strings.Builder
+		for _, r := range s.text {
+			w := lipgloss.Width(string(r))
+			if col+w > width && col > 0 {
+				if b.Len() > 0 {
+					row = append(row, segment{s.style, b.String()})
+					b.Reset()
+				}
+				rows = append(rows, row)
+				row, col = nil, 0
+			}
+			b.WriteRune(r)
+			col += w
+		}
+		if b.Len() > 0 {
+			row = append(row, segment{s.style, b.String()})
+		}
+	}
+	return append(rows, row)
+}
+
+// cutSegments returns the part of segs that starts at display column start
+// and spans at most width columns.
+func cutSegments(segs []segment, start, width int) []segment {
+	var out []segment
+	col := 0
+	for _, s := range segs {
+		var b strings.Builder
+		for _, r := range s.text {
+			w := lipgloss.Width(string(r))
+			if col >= start && col+w <= start+width {
+				b.WriteRune(r)
+			}
+			col += w
+			if col >= start+width {
+				break
+			}
+		}
+		if b.Len() > 0 {
+			out = append(out, segment{s.style, b.String()})
+		}
+		if col >= start+width {
+			break
+		}
+	}
+	return out
+}
+
+func segmentsWidth(segs []segment) int {
+	w := 0
+	for _, s := range segs {
+		w += lipgloss.Width(s.text)
+	}
+	return w
+}
+
+func renderSegments(segs []segment) string {
+	var b strings.Builder
+	for _, s := range segs {
+		b.WriteString(s.style.Render(s.text))
+	}
+	return b.String()
+}
@@ -0,0 +1,231 @@
+package ui
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/charmbracelet/bubbles/textinput"
+	tea "github.com/charmbracelet/bubbletea"
+
+	"github.com/kwrkb/asql/internal/db"
+)
+
+func plainLines(lines []viewerLine) []string {
+	out := make([]string, len(lines))
+	for i, l := range lines {
+		out[i] = l.plain()
+	}
+	return out
+}
+
+func TestDetectViewerKind(t *testing.T) {
+	tests := []struct {
+		name, value, colType string
+		want                 viewerKind
+	}{
+		{"jsonb column", `{"a":1}`, "JSONB", viewerJSON},
+		{"json sniffed", ` [1, 2] `, "TEXT", viewerJSON},
+		{"invalid json", `{"a":`, "TEXT", viewerText},
+		{"xml column", `<a><b>x</b></a>`, "XML", viewerXML},
+		{"xml sniffed", `<?xml version="1.0"?><root/>`, "", viewerXML},
+		{"not xml", `<b>unclosed`, "TEXT", viewerText},
+		{"hex blob", "00ff10", "BLOB", viewerHex},
+		{"utf8 blob stays text", "hello", "BLOB", viewerText},
+		{"hex in text column", "00ff10", "TEXT", viewerText},
+		{"plain", "hello", "TEXT", viewerText},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := detectViewerKind(tt.value, tt.colType); got != tt.want {
+				t.Errorf("detectViewerKind(%q, %q) = %s, want %s", tt.value, tt.colType, viewerKindLabels[got], viewerKindLabels[tt.want])
+			}
+		})
+	}
+}
+
+func TestFormatViewerLines_JSON(t *testing.T) {
+	lines, kind, tree := formatViewerLines(`{"b":1,"a":{"x":[true,null]},"s":"<é>"}`, viewerJSON, nil)
+	if kind != viewerJSON || tree == nil {
+		t.Fatalf("expected JSON, got %s", viewerKindLabels[kind])
+	}
+	want := []string{
+		`{`,
+		`  "b": 1,`,
+		`  "a": {`,
+		`    "x": [`,
+		`      true,`,
+		`      null`,
+		`    ]`,
+		`  },`,
+		`  "s": "<é>"`,
+		`}`,
+	}
+	if got := plainLines(lines); strings.Join(got, "\n") != strings.Join(want, "\n") {
+		t.Errorf("got:\n%s\nwant:\n%s", strings.Join(got, "\n"), strings.Join(want, "\n"))
+	}
+
+	// Folding "a" collapses its subtree onto one line.
+	tree.children[1].folded = true
+	lines, _, _ = formatViewerLines("", viewerJSON, tree)
+	if got := plainLines(lines)[2]; got != `  "a": {…},  (1)` {
+		t.Errorf("folded line = %q", got)
+	}
+}
+
+func TestFormatViewerLines_XML(t *testing.T) {
+	lines, kind, _ := formatViewerLines(`<root id="1"><item>a &amp; b</item><empty></empty><!-- c --></root>`, viewerXML, nil)
+	if kind != viewerXML {
+		t.Fatalf("expected XML, got %s", viewerKindLabels[kind])
+	}
+	want := []string{
+		`<root id="1">`,
+		`  <item>a &amp; b</item>`,
+		`  <empty></empty>`,
+		`  <!-- c -->`,
+		`</root>`,
+	}
+	if got := plainLines(lines); strings.Join(got, "\n") != strings.Join(want, "\n") {
+		t.Errorf("got:\n%s\nwant:\n%s", strings.Join(got, "\n"), strings.Join(want, "\n"))
+	}
+}
+
+func TestFormatViewerLines_Hex(t *testing.T) {
+	// "Hi" + 0xff + 15 zero bytes: StringifyValue hex-encodes invalid UTF-8.
+	value := "4869ff" + strings.Repeat("00", 15)
+	lines, kind, _ := formatViewerLines(value, viewerHex, nil)
+	if kind != viewerHex {
+		t.Fatalf("expected Hex, got %s", viewerKindLabels[kind])
+	}
+	got := plainLines(lines)
+	if len(got) != 2 {
+		t.Fatalf("expected 2 lines for 18 bytes, got %d", len(got))
+	}
+	if !strings.HasPrefix(got[0], "00000000  48 69 ff 00") || !strings.HasSuffix(got[0], "|Hi..............|") {
+		t.Errorf("unexpected first line: %q", got[0])
+	}
+	if !strings.HasPrefix(got[1], "00000010  00 00") || !strings.HasSuffix(got[1], "|..|") {
+		t.Errorf("unexpected second line: %q", got[1])
+	}
+}
+
+func TestFormatViewerLines_FallsBackToText(t *testing.T) {
+	lines, kind, _ := formatViewerLines("not json\tx", viewerJSON, nil)
+	if kind != viewerText {
+		t.Errorf("expected text fallback, got %s", viewerKindLabels[kind])
+	}
+	if got := lines[0].plain(); got != "not json    x" {
+		t.Errorf("expected tab expansion, got %q", got)
+	}
+}
+
+func TestWrapSegments(t *testing.T) {
+	segs := []segment{{viewerPlainStyle, "abc"}, {viewerKeyStyle, "日本de"}}
+	rows := wrapSegments(segs, 4)
+	var got []string
+	for _, r := range rows {
+		got = append(got, viewerLine{segs: r}.plain())
+	}
+	// Wide runes never straddle a row boundary.
+	want := []string{"abc", "日本", "de"}
+	if strings.Join(got, "|") != strings.Join(want, "|") {
+		t.Errorf("got %q, want %q", got, want)
+	}
+	if rows := wrapSegments(nil, 4); len(rows) != 1 {
+		t.Errorf("empty line should yield one row, got %d", len(rows))
+	}
+}
+
+func newViewerModel(value, colType string) *model {
+	m := newTestModel()
+	m.viewer.search = textinput.New()
+	m.applyResult(db.QueryResult{
+		Columns:     []string{"id", "payload"},
+		ColumnTypes: []string{"INTEGER", colType},
+		Rows:        [][]string{{"1", value}},
+	})
+	m.mode = detailMode
+	m.detail.fieldCursor = 1
+	return m
+}
+
+func TestViewer_OpenFromDetailAndBack(t *testing.T) {
+	m := newViewerModel(`{"a":{"b":1},"c":2}`, "JSONB")
+
+	result, _ := m.updateDetail(runeMsg("v"))
+	rm := result.(model)
+	if rm.mode != viewerMode {
+		t.Fatalf("expected viewerMode, got %q", rm.mode)
+	}
+	if rm.viewer.shown != viewerJSON || rm.viewer.raw != `{"a":{"b":1},"c":2}` {
+		t.Errorf("unexpected viewer state: shown=%s raw=%q", viewerKindLabels[rm.viewer.shown], rm.viewer.raw)
+	}
+	if !strings.Contains(rm.renderViewer(), `"a"`) {
+		t.Error("expected formatted JSON in view")
+	}
+
+	result, _ = rm.updateViewer(tea.KeyMsg{Type: tea.KeyEsc})
+	rm = result.(model)
+	if rm.mode != detailMode {
+		t.Errorf("expected detailMode after Esc, got %q", rm.mode)
+	}
+}
+
+func TestViewer_FoldAndSearch(t *testing.T) {
+	m := newViewerModel(`{"a":{"b":1,"c":2},"d":"needle"}`, "JSON")
+	result, _ := m.updateDetail(runeMsg("v"))
+	rm := result.(model)
+	before := len(rm.viewer.rows)
+
+	// Cursor on `"a": {` folds the object.
+	result, _ = rm.updateViewer(runeMsg("j"))
+	rm = result.(model)
+	result, _ = rm.updateViewer(tea.KeyMsg{Type: tea.KeyEnter})
+	rm = result.(model)
+	if len(rm.viewer.rows) != before-3 {
+		t.Errorf("expected fold to hide 3 rows, got %d -> %d", before, len(rm.viewer.rows))
+	}
+	if rm.viewer.cursor != 1 {
+		t.Errorf("expected cursor to stay on folded line, got %d", rm.viewer.cursor)
+	}
+
+	result, _ = rm.updateViewer(runeMsg("/"))
+	rm = result.(model)
+	if !rm.viewer.searching {
+		t.Fatal("expected search input")
+	}
+	rm.viewer.search.SetValue("NEEDLE")
+	result, _ = rm.updateViewer(tea.KeyMsg{Type: tea.KeyEnter})
+	rm = result.(model)
+	if len(rm.viewer.matches) != 1 {
+		t.Fatalf("expected 1 match, got %d", len(rm.viewer.matches))
+	}
+	if got := (viewerLine{segs: rm.viewer.rows[rm.viewer.cursor].segs}).plain(); !strings.Contains(got, "needle") {
+		t.Errorf("cursor not on match: %q", got)
+	}
+}
+
+func TestViewer_WrapAndFormatCycle(t *testing.T) {
+	m := newViewerModel(strings.Repeat("x", 200), "TEXT")
+	m.width = 50
+	m.height = 20
+	result, _ := m.updateDetail(runeMsg("v"))
+	rm := result.(model)
+	if got := len(rm.viewer.rows); got != 5 { // 200 / (50-6)
+		t.Errorf("expected 5 wrapped rows, got %d", got)
+	}
+	result, _ = rm.updateViewer(runeMsg("w"))
+	rm = result.(model)
+	if got := len(rm.viewer.rows); got != 1 {
+		t.Errorf("expected 1 row without wrap, got %d", got)
+	}
+
+	// Text -> JSON falls back to text for a non-JSON value.
+	result, _ = rm.updateViewer(runeMsg("m"))
+	rm = result.(model)
+	if rm.viewer.kind != viewerJSON || rm.viewer.shown != viewerText {
+		t.Errorf("expected requested JSON, shown text; got %s/%s", viewerKindLabels[rm.viewer.kind], viewerKindLabels[rm.viewer.shown])
+	}
+	if !rm.statusError {
+		t.Error("expected fallback to be reported as an error")
+	}
+}
@@ -0,0 +1,302 @@
+package ui
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+
+	"github.com/kwrkb/asql/internal/export"
+)
+
+var selectedCellStyle = lipgloss.NewStyle().
+	Foreground(textColor).
+	Background(lipgloss.Color("#334155"))
+
+// yankFormats maps the key pressed after y to the clipboard format.
+var yankFormats = map[string]string{
+	"c": "CSV",
+	"t": "TSV",
+	"m": "Markdown",
+	"i": "IN list",
+}
+
+// enterVisual starts a selection anchored at the cursor: whole rows, or a
+// rectangular block of cells when block is set.
+func (m *model) enterVisual(block bool) {
+	if m.rowCount() == 0 {
+		m.setStatus("No rows to select", true)
+		return
+	}
+	m.visual = visualState{
+		active:    true,
+		block:     block,
+		anchorRow: m.table.Cursor(),
+		anchorCol: m.colCursor,
+	}
+	m.mode = visualMode
+	m.viewportDirty = true
+	m.setStatus(m.visualLabel(), false)
+}
+
+func (m *model) visualLabel() string {
+	if m.visual.block {
+		return "Visual block"
+	}
+	return "Visual line"
+}
+
+// clearVisual drops the selection and leaves VISUAL mode.
+func (m *model) clearVisual() {
+	if !m.visual.active {
+		return
+	}
+	m.visual = visualState{}
+	m.viewportDirty = true
+	if m.mode == visualMode {
+		m.mode = normalMode
+	}
+}
+
+func (m model) updateVisual(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	if m.visual.yank {
+		m.visual.yank = false
+		if msg.Type == tea.KeyRunes && !msg.Alt {
+			if label, ok := yankFormats[string(msg.Runes)]; ok {
+				m.yankSelection(string(msg.Runes), label)
+				m.syncViewport()
+				return m, nil
+			}
+		}
+		m.setStatus("Yank cancelled", false)
+		return m, nil
+	}
+
+	switch msg.Type {
+	case tea.KeyEsc:
+		m.clearVisual()
+		m.setStatus("Normal mode", false)
+	case tea.KeyDown:
+		m.table.MoveDown(1)
+	case tea.KeyUp:
+		m.table.MoveUp(1)
+	case tea.KeyLeft:
+		m.moveVisualCol(-1)
+	case tea.KeyRight:
+		m.moveVisualCol(1)
+	case tea.KeyPgUp, tea.KeyPgDown:
+		m.table, _ = m.table.Update(msg)
+	case tea.KeyRunes:
+		if msg.Alt {
+			break
+		}
+		switch string(msg.Runes) {
+		case "q":
+			m.clearVisual()
+			m.setStatus("Normal mode", false)
+		case "j":
+			m.table.MoveDown(1)
+		case "k":
+			m.table.MoveUp(1)
+		case "h":
+			m.moveVisualCol(-1)
+		case "l":
+			m.moveVisualCol(1)
+		case "v", "V":
+			block := string(msg.Runes) == "v"
+			if m.visual.block == block {
+				m.clearVisual()
+				m.setStatus("Normal mode", false)
+				break
+			}
+			m.visual.block = block
+			m.viewportDirty = true
+			m.setStatus(m.visualLabel(), false)
+		case "y":
+			m.visual.yank = true
+			m.setStatus("Yank as: c:CSV t:TSV m:Markdown i:IN list", false)
+		case "e":
+			m.mode = exportMode
+			m.exportSt.cursor = 0
+			m.setStatus("Export selection", false)
+		case "D":
+			return m.startRowChange(changeInsert, false)
+		case "X":
+			return m.startRowChange(changeDelete, false)
+		}
+	}
+	m.syncViewport()
+	return m, nil
+}
+
+func (m *model) moveVisualCol(delta int) {
+	next := m.colCursor + delta
+	if next < 0 || next >= len(m.lastResult.Columns) {
+		return
+	}
+	m.colCursor = next
+	m.adjustColOffset()
+}
+
+// visualRows returns the selected display rows as [start, end).
+func (m *model) visualRows() (int, int) {
+	cur := m.table.Cursor()
+	start, end := min(m.visual.anchorRow, cur), max(m.visual.anchorRow, cur)+1
+	return max(start, 0), min(end, len(m.viewRows()))
+}
+
+// visualCols returns the selected columns as [start, end): the block's
+// columns, or every column for a line selection.
+func (m *model) visualCols() (int, int) {
+	if !m.visual.block {
+		return 0, len(m.lastResult.Columns)
+	}
+	start, end := min(m.visual.anchorCol, m.colCursor), max(m.visual.anchorCol, m.colCursor)+1
+	return max(start, 0), min(end, len(m.lastResult.Columns))
+}
+
+// cellSelected reports whether the cell at display row r, column c is
+// inside the selection.
+func (m *model) cellSelected(r, c int) bool {
+	if !m.visual.active {
+		return false
+	}
+	rs, re := m.visualRows()
+	cs, ce := m.visualCols()
+	return r >= rs && r < re && c >= cs && c < ce
+}
+
+// selectedRows returns the rows covered by the selection in display order
+// (all columns), or the row under the cursor when nothing is selected.
+func (m *model) selectedRows() [][]string {
+	start, end := m.selectedRange()
+	if start >= end {
+		return nil
+	}
+	return m.viewRows()[start:end]
+}
+
+// selectedRange returns the range of view rows selectedRows covers.
+func (m *model) selectedRange() (int, int) {
+	if m.visual.active {
+		start, end := m.visualRows()
+		return start, max(start, end)
+	}
+	cur := m.table.Cursor()
+	if cur < 0 || cur >= m.rowCount() {
+		return 0, 0
+	}
+	return cur, cur + 1
+}
+
+// selectionData returns the headers, cells and column types of the
+// selection; a block selection is limited to its columns.
+func (m *model) selectionData() ([]string, [][]string, []string) {
+	rows := m.selectedRows()
+	headers := m.lastResult.Columns
+	types := padCells(m.lastResult.ColumnTypes, len(headers))
+	if !m.visual.active || !m.visual.block {
+		return headers, rows, types
+	}
+	start, end := m.visualCols()
+	sliced := make([][]string, len(rows))
+	for i, row := range rows {
+		sliced[i] = padCells(row, end)[start:end]
+	}
+	return headers[start:end], sliced, types[start:end]
+}
+
+// selectionText renders the selection in a yank format (see yankFormats).
+func (m *model) selectionText(key string) (string, error) {
+	headers, rows, types := m.selectionData()
+	switch key {
+	case "c":
+		return export.FormatCSV(headers, rows)
+	case "t":
+		return export.FormatTSV(headers, rows)
+	case "m":
+		return export.FormatMarkdown(headers, rows), nil
+	case "i":
+		var cells, cellTypes []string
+		for _, row := range rows {
+			for i, cell := range row {
+				cells = append(cells, cell)
+				cellTypes = append(cellTypes, types[i])
+			}
+		}
+		return export.FormatINList(cells, cellTypes, m.activeDB().Type()), nil
+	}
+	return "", fmt.Errorf("unknown yank format %q", key)
+}
+
+// yankSelection copies the selection to the clipboard and leaves VISUAL
+// mode.
+func (m *model) yankSelection(key, label string) {
+	text, err := m.selectionText(key)
+	if err != nil {
+		m.setStatus(fmt.Sprintf("Yank failed: %v", err), true)
+		return
+	}
+	if m.copyText(text, fmt.Sprintf("Yanked %d row(s) as %s", len(m.selectedRows()), label)) {
+		m.clearVisual()
+	}
+}
+
+// selectionSummary is the status bar aggregate of a selection. Numeric
+// aggregates cover the cells that parse as numbers.
+type selectionSummary struct {
+	cells, numeric int
+	sum, min, max  float64
+}
+
+func (m *model) summarizeSelection() selectionSummary {
+	var s selectionSummary
+	rows := m.viewRows()
+	rs, re := m.visualRows()
+	cs, ce := m.visualCols()
+	for r := rs; r < re; r++ {
+		for c := cs; c < ce && c < len(rows[r]); c++ {
+			s.cells++
+			v, err := strconv.ParseFloat(rows[r][c], 64)
+			if err != nil || math.IsNaN(v) || math.IsInf(v, 0) {
+				continue
+			}
+			if s.numeric == 0 || v < s.min {
+				s.min = v
+			}
+			if s.numeric == 0 || v > s.max {
+				s.max = v
+			}
+			s.sum += v
+			s.numeric++
+		}
+	}
+	return s
+}
+
+// selectionInfo returns the status bar aggregates for the selection.
+func (m model) selectionInfo() string {
+	if !m.visual.active {
+		return ""
+	}
+	rs, re := m.visualRows()
+	s := m.summarizeSelection()
+	info := fmt.Sprintf("%d row(s) count:%d", re-rs, s.cells)
+	if s.numeric > 0 {
+		info += fmt.Sprintf(" sum:%s avg:%s min:%s max:%s",
+			formatAggregate(s.sum), formatAggregate(s.sum/float64(s.numeric)),
+			formatAggregate(s.min), formatAggregate(s.max))
+	}
+	return info + " "
+}
+
+// formatAggregate prints whole numbers exactly and fractions with up to six
+// significant digits.
+func formatAggregate(v float64) string {
+	if v == math.Trunc(v) && math.Abs(v) < 1e15 {
+		return strconv.FormatFloat(v, 'f', -1, 64)
+	}
+	return strconv.FormatFloat(v, 'g', 6, 64)
+}
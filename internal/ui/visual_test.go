@@ -0,0 +1,129 @@
+package ui
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/charmbracelet/bubbles/textinput"
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+func TestVisualLineSelection(t *testing.T) {
+	m, _ := newEditModel(t)
+	if got := m.selectedRows(); len(got) != 1 || got[0][0] != "1" {
+		t.Fatalf("without a selection expected the cursor row, got %v", got)
+	}
+
+	next, _ := m.updateNormal(runeMsg("V"))
+	rm := next.(model)
+	next, _ = rm.updateVisual(runeMsg("j"))
+	rm = next.(model)
+	if rm.mode != visualMode || len(rm.selectedRows()) != 2 {
+		t.Fatalf("expected 2 selected rows in VISUAL, got %q/%d", rm.mode, len(rm.selectedRows()))
+	}
+	if !rm.cellSelected(1, 2) {
+		t.Error("expected a line selection to cover every column")
+	}
+
+	headers, rows := rm.exportData(scopeSelected)
+	if len(headers) != 3 || len(rows) != 2 || rows[1][0] != "2" {
+		t.Errorf("export of selection = %v %v", headers, rows)
+	}
+	rm.exportSt.path = textinput.New()
+	rm.exportSt.table = textinput.New()
+	rm.exportSt.keys = textinput.New()
+	next, _ = rm.openSaveDialog()
+	if next.(model).exportSt.scope != scopeSelected {
+		t.Error("expected the save dialog to default to the selection")
+	}
+
+	next, _ = rm.updateVisual(tea.KeyMsg{Type: tea.KeyEsc})
+	rm = next.(model)
+	if rm.visual.active || rm.mode != normalMode {
+		t.Errorf("expected Esc to leave VISUAL, got %q", rm.mode)
+	}
+
+	next, _ = rm.updateNormal(runeMsg("V"))
+	rm = next.(model)
+	rm.toggleSort()
+	if rm.visual.active || rm.mode != normalMode {
+		t.Error("expected sorting to clear the selection")
+	}
+}
+
+func TestVisualBlockSelection(t *testing.T) {
+	m, _ := newEditModel(t)
+
+	// Block over id and flag of both rows.
+	next, _ := m.updateNormal(runeMsg("v"))
+	rm := next.(model)
+	for _, k := range []string{"j", "l"} {
+		next, _ = rm.updateVisual(runeMsg(k))
+		rm = next.(model)
+	}
+	if rm.cellSelected(0, 2) || !rm.cellSelected(1, 1) {
+		t.Error("block selection covers the wrong cells")
+	}
+
+	tests := []struct {
+		key, want string
+	}{
+		{"c", "id,flag\n1,bad\n2,ok\n"},
+		{"t", "id\tflag\n1\tbad\n2\tok\n"},
+		{"i", "(1, 'bad', 2, 'ok')"},
+	}
+	for _, tt := range tests {
+		got, err := rm.selectionText(tt.key)
+		if err != nil {
+			t.Fatalf("%s: %v", tt.key, err)
+		}
+		if got != tt.want {
+			t.Errorf("%s: got %q, want %q", tt.key, got, tt.want)
+		}
+	}
+	if got, _ := rm.selectionText("m"); !strings.Contains(got, "| id | flag |") {
+		t.Errorf("markdown yank = %q", got)
+	}
+
+	// Switching to a line selection widens it to every column.
+	next, _ = rm.updateVisual(runeMsg("V"))
+	rm = next.(model)
+	if rm.visual.block || !rm.cellSelected(0, 2) {
+		t.Error("expected V to switch to a line selection")
+	}
+}
+
+func TestVisualSelectionInfo(t *testing.T) {
+	m, _ := newEditModel(t)
+	next, _ := m.updateNormal(runeMsg("v"))
+	rm := next.(model)
+	next, _ = rm.updateVisual(runeMsg("j"))
+	rm = next.(model)
+	if got, want := rm.selectionInfo(), "2 row(s) count:2 sum:3 avg:1.5 min:1 max:2 "; got != want {
+		t.Errorf("selectionInfo = %q, want %q", got, want)
+	}
+
+	// Text cells are counted but have no numeric aggregates.
+	next, _ = rm.updateVisual(runeMsg("l"))
+	rm = next.(model)
+	next, _ = rm.updateVisual(runeMsg("v"))
+	rm = next.(model)
+	next, _ = rm.updateNormal(runeMsg("v"))
+	rm = next.(model)
+	if got := rm.selectionInfo(); got != "1 row(s) count:1 " {
+		t.Errorf("selectionInfo = %q", got)
+	}
+}
+
+func TestFormatAggregate(t *testing.T) {
+	for v, want := range map[float64]string{
+		42:        "42",
+		-3.5:      "-3.5",
+		1.0 / 3.0: "0.333333",
+		1e20:      "1e+20",
+	} {
+		if got := formatAggregate(v); got != want {
+			t.Errorf("formatAggregate(%v) = %q, want %q", v, got, want)
+		}
+	}
+}
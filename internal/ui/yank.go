@@ -0,0 +1,109 @@
+package ui
+
+import (
+	"encoding/csv"
+	"fmt"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+
+	"github.com/kwrkb/asql/internal/export"
+)
+
+// updateYank handles the key after y in NORMAL mode:
+//
+//	yy  the current row, tab-separated
+//	yc  the current cell
+//	yC  the current column, one value per line
+//	yi  the current column as a de-duplicated SQL IN list
+func (m model) updateYank(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	m.yankPending = false
+	key := ""
+	if msg.Type == tea.KeyRunes && !msg.Alt {
+		key = string(msg.Runes)
+	}
+	if m.rowCount() == 0 || m.colCursor >= len(m.lastResult.Columns) {
+		m.setStatus("Nothing to yank", true)
+		return m, nil
+	}
+	text, what, err := m.yankText(key)
+	switch {
+	case err != nil:
+		m.setStatus(err.Error(), true)
+	case what == "":
+		m.setStatus("Yank cancelled", false)
+	default:
+		m.copyText(text, "Copied "+what)
+	}
+	return m, nil
+}
+
+// yankText returns the clipboard text for a yank key and a description of
+// what it holds. An unknown key returns an empty description. The result
+// must have rows.
+func (m *model) yankText(key string) (string, string, error) {
+	switch key {
+	case "y", "c", "C", "i":
+	default:
+		return "", "", nil
+	}
+	rows := m.viewRows()
+	cur := min(max(m.table.Cursor(), 0), len(rows)-1)
+	col := m.colCursor
+	colName := sanitize(m.lastResult.Columns[col])
+
+	switch key {
+	case "y":
+		var b strings.Builder
+		w := csv.NewWriter(&b)
+		w.Comma = '\t'
+		record := make([]string, len(rows[cur]))
+		for i, c := range rows[cur] {
+			record[i] = clipboardCell(c)
+		}
+		if err := w.Write(record); err != nil {
+			return "", "", err
+		}
+		w.Flush()
+		return strings.TrimSuffix(b.String(), "\n"), fmt.Sprintf("row %d", cur+1), w.Error()
+	case "c":
+		return clipboardCell(cell(rows[cur], col)), "cell " + colName, nil
+	}
+
+	values := make([]string, len(rows))
+	for i, row := range rows {
+		values[i] = cell(row, col)
+	}
+	if key == "C" {
+		for i, v := range values {
+			values[i] = clipboardCell(v)
+		}
+		return strings.Join(values, "\n"), fmt.Sprintf("%d value(s) of %s", len(values), colName), nil
+	}
+	colType := ""
+	if col < len(m.lastResult.ColumnTypes) {
+		colType = m.lastResult.ColumnTypes[col]
+	}
+	types := make([]string, len(values))
+	for i := range types {
+		types[i] = colType
+	}
+	return export.FormatINList(values, types, m.activeDB().Type()), colName + " as IN list", nil
+}
+
+// cell returns row[i], or "" when the row is short.
+func cell(row []string, i int) string {
+	if i < len(row) {
+		return row[i]
+	}
+	return ""
+}
+
+// clipboardCell converts the `""` empty-string marker back to an empty
+// string; NULL is copied as the text NULL.
+func clipboardCell(c string) string {
+	if c == `""` {
+		return ""
+	}
+	return c
+}
@@ -0,0 +1,70 @@
+package ui
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/kwrkb/asql/internal/clipboard"
+)
+
+func TestYankText(t *testing.T) {
+	m, adapter := newEditModel(t)
+	if _, err := adapter.Query(t.Context(), "INSERT INTO items VALUES (3, 'bad', '')"); err != nil {
+		t.Fatal(err)
+	}
+	res, _ := adapter.Query(t.Context(), m.lastQuery)
+	m.applyResult(res)
+	m.table.SetCursor(2)
+	m.colCursor = 1
+
+	tests := []struct {
+		key, want string
+	}{
+		{"y", "3\tbad\t"},
+		{"c", "bad"},
+		{"C", "bad\nok\nbad"},
+		{"i", "('bad', 'ok')"},
+	}
+	for _, tt := range tests {
+		got, what, err := m.yankText(tt.key)
+		if err != nil || what == "" {
+			t.Fatalf("%s: err=%v what=%q", tt.key, err, what)
+		}
+		if got != tt.want {
+			t.Errorf("y%s = %q, want %q", tt.key, got, tt.want)
+		}
+	}
+
+	m.colCursor = 2
+	if got, _, _ := m.yankText("C"); got != "x\nNULL\n" {
+		t.Errorf("yC with NULL and empty cells = %q", got)
+	}
+	if _, what, _ := m.yankText("z"); what != "" {
+		t.Errorf("expected an unknown key to be ignored, got %q", what)
+	}
+}
+
+func TestYank_StatusNamesBackend(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "clip.txt")
+	m, _ := newEditModel(t)
+	m.clip = clipboard.New(clipboard.File, path)
+	m.colCursor = 1
+
+	next, _ := m.updateNormal(runeMsg("y"))
+	rm := next.(model)
+	if !rm.yankPending {
+		t.Fatal("expected y to wait for the yank key")
+	}
+	next, _ = rm.updateNormal(runeMsg("c"))
+	rm = next.(model)
+	if rm.yankPending || rm.statusError {
+		t.Fatalf("yank failed: %s", rm.statusText)
+	}
+	if data, err := os.ReadFile(path); err != nil || string(data) != "bad" {
+		t.Errorf("clipboard file = %q, %v", data, err)
+	}
+	if want := "Copied cell flag (" + path + ")"; rm.statusText != want {
+		t.Errorf("status = %q, want %q", rm.statusText, want)
+	}
+}
@@ -8,6 +8,7 @@ import (
 	tea "github.com/charmbracelet/bubbletea"
 
 	"github.com/kwrkb/asql/internal/ai"
+	"github.com/kwrkb/asql/internal/clipboard"
 	"github.com/kwrkb/asql/internal/config"
 	dbpkg "github.com/kwrkb/asql/internal/db"
 	"github.com/kwrkb/asql/internal/db/opener"
@@ -216,15 +217,27 @@ func main() {
 		aiClient = ai.NewClient(cfg.AI.Endpoint, cfg.AI.Model, cfg.AI.APIKey)
 	}
 
+	clipBackend, err := clipboard.ParseBackend(cfg.Clipboard.Backend)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "warning: %v; using auto\n", err)
+	}
+	clip := clipboard.New(clipBackend, cfg.Clipboard.File)
+	// The UI and the OSC 52 backend share one serialized output.
+	terminal := clipboard.NewTerminal(os.Stdout)
+	clip.SetTerminal(terminal)
+
 	snippets, snippetErr := snippet.Load()
 	if snippetErr != nil {
 		fmt.Fprintf(os.Stderr, "warning: failed to load snippets: %v\n", snippetErr)
 	}
 
-	m := ui.NewModel(adapter, displayDSN, dbPath, connName, aiClient, snippets, profiles)
+	// Load has already dropped an unknown time_zone with a warning.
+	loc, _ := config.LoadLocation(cfg.TimeZone)
+
+	m := ui.NewModel(adapter, displayDSN, dbPath, connName, aiClient, clip, snippets, profiles, loc)
 	defer m.CloseAll()
 
-	program := tea.NewProgram(m, tea.WithAltScreen())
+	program := tea.NewProgram(m, tea.WithAltScreen(), tea.WithOutput(terminal))
 
 	if _, err := program.Run(); err != nil {
 		fmt.Fprintf(os.Stderr, "asql exited with error: %v\n", err)